@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/nicolaananda/catatuang/internal/ai"
 	"github.com/nicolaananda/catatuang/internal/config"
 	"github.com/nicolaananda/catatuang/internal/handler"
+	"github.com/nicolaananda/catatuang/internal/queue"
 	"github.com/nicolaananda/catatuang/internal/repository"
 	"github.com/nicolaananda/catatuang/internal/service"
 	"github.com/nicolaananda/catatuang/internal/statemachine"
@@ -27,7 +35,6 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer db.Close()
 
 	if err := db.Ping(); err != nil {
 		log.Fatalf("Failed to ping database: %v", err)
@@ -44,17 +51,78 @@ func main() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	txRepo := repository.NewTransactionRepository(db)
+	itemRepo := repository.NewTransactionItemRepository(db)
 	dedupRepo := repository.NewDedupRepository(db)
 	auditRepo := repository.NewAuditRepository(db)
+	inboundRepo := repository.NewInboundJobRepository(db)
+	outboundRepo := repository.NewOutboundMessageRepository(db)
+	aiCallRepo := repository.NewAICallRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	categoryMemoryRepo := repository.NewCategoryMemoryRepository(db)
+	promptShadowRepo := repository.NewPromptShadowRepository(db)
+	deferredRepo := repository.NewDeferredMessageRepository(db)
 
 	// Initialize services
 	userService := service.NewUserService(userRepo)
-	txService := service.NewTransactionService(txRepo, userRepo, auditRepo, db)
-	reportService := service.NewReportService(txRepo)
+	categoryService := service.NewCategoryService(categoryRepo, categoryMemoryRepo, db)
+	txService := service.NewTransactionService(txRepo, itemRepo, userRepo, auditRepo, categoryService, db)
+	reportService := service.NewReportService(txRepo, itemRepo)
+	usageService := service.NewUsageService(aiCallRepo)
+
+	// Every model-backed call goes through one breaker, so an outage seen by
+	// one kind of call stops the others from waiting on it too
+	breaker := ai.NewCircuitBreaker(cfg.AIBreakerThreshold, time.Duration(cfg.AIBreakerCooldownSeconds)*time.Second)
 
 	// Initialize AI parsers
-	textParser := ai.NewTextParser(cfg.OpenAIAPIKey, cfg.OpenAIModel, loc)
-	visionParser := ai.NewVisionParser(cfg.OpenAIAPIKey, cfg.OpenAIModel, loc)
+	var textParser ai.TransactionParser
+	var visionParser ai.ImageParser
+	var transcriber ai.Transcriber
+	var queryPlanner ai.QueryPlanner
+	var intentClassifier ai.IntentClassifier
+	switch cfg.AIProvider {
+	case ai.ProviderFake:
+		fakeParser := ai.NewFakeParser(loc)
+		textParser = fakeParser
+		visionParser = fakeParser
+		transcriber = &ai.StaticTranscriber{Text: "beli kopi 20rb"}
+		queryPlanner = fakeParser
+		intentClassifier = fakeParser
+		log.Println("⚠️  Using fake AI provider, messages are parsed offline")
+	default:
+		textModel := ai.NewTextParser(cfg.OpenAIAPIKey, cfg.AIBaseURL, cfg.OpenAIModel, loc, aiCallRepo)
+		visionModel := ai.NewVisionParser(cfg.OpenAIAPIKey, cfg.AIBaseURL, cfg.AIVisionModel, loc, aiCallRepo)
+		var modelParser ai.TransactionParser = textModel
+		visionParser = visionModel
+
+		// Candidate prompts answer nobody; their output is only logged
+		if cfg.AIShadowTextPrompt != "" {
+			candidate, err := ai.LoadPrompt(cfg.AIShadowTextPrompt)
+			if err != nil {
+				log.Fatalf("Failed to load shadow text prompt: %v", err)
+			}
+			modelParser = ai.NewShadowParser(textModel, candidate, cfg.AIShadowPercent, promptShadowRepo)
+			log.Printf("Shadowing text prompt %s on %d%% of messages", candidate.ID(), cfg.AIShadowPercent)
+		}
+		if cfg.AIShadowVisionPrompt != "" {
+			candidate, err := ai.LoadPrompt(cfg.AIShadowVisionPrompt)
+			if err != nil {
+				log.Fatalf("Failed to load shadow vision prompt: %v", err)
+			}
+			visionParser = ai.NewShadowImageParser(visionModel, candidate, cfg.AIShadowPercent, promptShadowRepo)
+			log.Printf("Shadowing vision prompt %s on %d%% of images", candidate.ID(), cfg.AIShadowPercent)
+		}
+
+		// Common messages are parsed by rules, which keep working while the
+		// breaker is open; the model handles the rest
+		textParser = ai.NewRuleParser(ai.NewBreakerParser(modelParser, breaker), loc)
+		visionParser = ai.NewBreakerImageParser(visionParser, breaker)
+		transcriber = ai.NewBreakerTranscriber(ai.NewOpenAITranscriber(cfg.OpenAIAPIKey, cfg.STTBaseURL, cfg.STTModel), breaker)
+		queryPlanner = ai.NewBreakerQueryPlanner(ai.NewQueryPlanner(cfg.OpenAIAPIKey, cfg.AIBaseURL, cfg.OpenAIModel, loc, aiCallRepo), breaker)
+		intentClassifier = ai.NewBreakerIntentClassifier(ai.NewIntentClassifier(cfg.OpenAIAPIKey, cfg.AIBaseURL, cfg.OpenAIModel, aiCallRepo), breaker)
+	}
+
+	// Commands are routed by rules; the model classifies free-form messages
+	intents := ai.NewIntentRouter(intentClassifier)
 
 	// Initialize WhatsApp client
 	waClient := whatsapp.NewClient(cfg.GowaAPIURL, cfg.GowaAPIToken, cfg.GowaDeviceID)
@@ -62,6 +130,17 @@ func main() {
 	// Initialize state machine
 	stateMachine := statemachine.NewStateMachine(db)
 
+	// Initialize outbound message queue
+	outboundQueue := queue.NewOutboundQueue(outboundRepo, waClient, cfg.OutboundWorkers, cfg.OutboundMaxAttempts,
+		cfg.OutboundRatePerSecond, time.Duration(cfg.OutboundRecipientIntervalMs)*time.Millisecond)
+
+	// Initialize inbound message queue
+	var locker queue.Locker = queue.NewLocalLocker()
+	if cfg.InboundLock == "postgres" {
+		locker = queue.NewAdvisoryLocker(db)
+	}
+	inboundQueue := queue.NewInboundQueue(inboundRepo, locker, cfg.InboundWorkers, cfg.InboundMaxAttempts)
+
 	// Initialize webhook handler
 	webhookHandler := handler.NewWebhookHandler(
 		cfg,
@@ -69,41 +148,143 @@ func main() {
 		waClient,
 		textParser,
 		visionParser,
+		transcriber,
+		queryPlanner,
+		intents,
 		userService,
 		txService,
 		reportService,
+		categoryService,
 		stateMachine,
 		dedupRepo,
 		auditRepo,
+		inboundQueue,
+		outboundQueue,
+		deferredRepo,
+		breaker,
 	)
 
+	// Start processing queued messages
+	outboundQueue.Start()
+	inboundQueue.Start(webhookHandler)
+
+	// Background jobs stop when ctx is cancelled on shutdown
+	ctx, stopBackground := context.WithCancel(context.Background())
+	var background sync.WaitGroup
+
+	// Notify users whose pending confirmations expired
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				webhookHandler.ExpirePendingConfirmations(ctx)
+			}
+		}
+	}()
+
+	// Parse messages deferred while the model was unavailable
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				webhookHandler.ProcessDeferred(ctx)
+			}
+		}
+	}()
+
 	// Initialize admin handler
-	adminHandler := handler.NewAdminHandler(userService, txService)
+	adminHandler := handler.NewAdminHandler(userService, txService, usageService)
 
-	// Setup HTTP server
-	http.Handle("/webhook", webhookHandler)
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	// Setup HTTP routes
+	mux := http.NewServeMux()
+	mux.Handle("/webhook", webhookHandler)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
 	// Admin API endpoints
-	http.HandleFunc("/api/admin/users", adminHandler.GetUsers)
-	http.HandleFunc("/api/admin/upgrade", adminHandler.UpgradeUser)
-	http.HandleFunc("/api/admin/block", adminHandler.BlockUser)
-	http.HandleFunc("/api/admin/unblock", adminHandler.UnblockUser)
-	http.HandleFunc("/api/admin/delete", adminHandler.DeleteUser)
-	http.HandleFunc("/api/admin/downgrade", adminHandler.DowngradePremium)
-	http.HandleFunc("/api/admin/transactions", adminHandler.GetUserTransactions)
+	mux.HandleFunc("/api/admin/users", adminHandler.GetUsers)
+	mux.HandleFunc("/api/admin/upgrade", adminHandler.UpgradeUser)
+	mux.HandleFunc("/api/admin/block", adminHandler.BlockUser)
+	mux.HandleFunc("/api/admin/unblock", adminHandler.UnblockUser)
+	mux.HandleFunc("/api/admin/delete", adminHandler.DeleteUser)
+	mux.HandleFunc("/api/admin/downgrade", adminHandler.DowngradePremium)
+	mux.HandleFunc("/api/admin/transactions", adminHandler.GetUserTransactions)
+	mux.HandleFunc("/api/admin/ai-usage/daily", adminHandler.GetAIUsageDaily)
+	mux.HandleFunc("/api/admin/ai-usage/plans", adminHandler.GetAIUsageByPlan)
+	mux.HandleFunc("/api/admin/ai-usage/users", adminHandler.GetAIUsageByUser)
+	mux.HandleFunc("/api/admin/ai-retries", adminHandler.GetAIRetries)
 
 	// Serve admin panel
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "web/index.html")
 	})
 
-	log.Printf("🚀 Server starting on port %s", cfg.Port)
-	log.Printf("📊 Admin panel: http://localhost:%s", cfg.Port)
-	if err := http.ListenAndServe(":"+cfg.Port, nil); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		// Admin reports and media-heavy webhooks can take a while to answer
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("🚀 Server starting on port %s", cfg.Port)
+		log.Printf("📊 Admin panel: http://localhost:%s", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case s := <-sig:
+		log.Printf("Received %s, shutting down...", s)
+	case err := <-serverErr:
+		log.Printf("Server failed: %v", err)
 	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	// Stop accepting webhooks first; GOWA redelivers anything refused here to
+	// another instance
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server did not shut down cleanly: %v", err)
+	}
+
+	// Finish messages being processed, then send the replies they queued
+	if err := inboundQueue.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Inbound queue did not drain: %v", err)
+	}
+	if err := outboundQueue.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Outbound queue did not drain: %v", err)
+	}
+
+	stopBackground()
+	background.Wait()
+
+	if err := db.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
+	}
+
+	log.Println("👋 Shutdown complete")
 }
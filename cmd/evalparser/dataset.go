@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Case is one labelled message or receipt image
+type Case struct {
+	ID string `json:"id"`
+	// Message is a chat message; Image a receipt or transfer screenshot,
+	// relative to the dataset file, sent with Caption
+	Message string `json:"message,omitempty"`
+	Image   string `json:"image,omitempty"`
+	Caption string `json:"caption,omitempty"`
+	// Expected is empty for messages that aren't transactions
+	Expected []ExpectedTransaction `json:"expected"`
+}
+
+// ExpectedTransaction is the label for one transaction in a case
+type ExpectedTransaction struct {
+	Type   string  `json:"type"`
+	Amount float64 `json:"amount"`
+	// Category lists the accepted names separated by "|", canonical name
+	// first: "makanan & minuman|makan"
+	Category string `json:"category"`
+	// Date is YYYY-MM-DD; DaysAgo labels relative dates ("kemarin" is 1)
+	// so the dataset doesn't go stale. Neither means today.
+	Date    string `json:"date,omitempty"`
+	DaysAgo int    `json:"days_ago,omitempty"`
+}
+
+// categories returns the accepted category names, lowercased
+func (e ExpectedTransaction) categories() []string {
+	var names []string
+	for _, name := range strings.Split(e.Category, "|") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// date returns the expected date as YYYY-MM-DD relative to today
+func (e ExpectedTransaction) date(today time.Time) string {
+	if e.Date != "" {
+		return e.Date
+	}
+	return today.AddDate(0, 0, -e.DaysAgo).Format("2006-01-02")
+}
+
+// loadDataset reads a JSONL dataset; blank lines and lines starting with //
+// are skipped
+func loadDataset(path string) ([]*Case, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	var cases []*Case
+	ids := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+
+		c := &Case{}
+		if err := json.Unmarshal([]byte(text), c); err != nil {
+			return nil, fmt.Errorf("failed to parse dataset line %d: %w", line, err)
+		}
+		switch {
+		case c.ID == "":
+			return nil, fmt.Errorf("dataset line %d has no id", line)
+		case ids[c.ID]:
+			return nil, fmt.Errorf("dataset line %d repeats id %s", line, c.ID)
+		case (c.Message == "") == (c.Image == ""):
+			return nil, fmt.Errorf("case %s needs exactly one of message or image", c.ID)
+		case c.Image != "" && len(c.Expected) > 1:
+			return nil, fmt.Errorf("case %s: images have at most one transaction", c.ID)
+		}
+		ids[c.ID] = true
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	return cases, nil
+}
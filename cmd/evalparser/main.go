@@ -0,0 +1,263 @@
+// Command evalparser runs a labelled golden dataset of chat messages and
+// receipt images through a parser and reports per-field accuracy, confidence
+// calibration and confusion matrices. Saved runs can be diffed to compare two
+// prompt or model versions:
+//
+//	go run ./cmd/evalparser -parser pipeline -out runs/before.json
+//	go run ./cmd/evalparser -parser pipeline -model gpt-4.1-mini -out runs/after.json
+//	go run ./cmd/evalparser -parser pipeline -prompt text_v2 -out runs/candidate.json
+//	go run ./cmd/evalparser -diff runs/before.json runs/after.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/nicolaananda/catatuang/internal/ai"
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// Parsers that can be evaluated
+const (
+	parserFake     = "fake"
+	parserRules    = "rules"
+	parserModel    = "model"
+	parserPipeline = "pipeline"
+)
+
+// Default taxonomy from migrations/007_categories.sql, passed to the model as
+// the bot does for a user who hasn't changed their categories
+var (
+	defaultExpenseCategories = []string{
+		"makanan & minuman", "kopi & jajan", "bahan makanan", "transportasi", "bensin", "ojek & taksi",
+		"parkir & tol", "belanja", "rumah tangga", "pakaian", "tagihan", "listrik & air", "internet & pulsa",
+		"tempat tinggal", "kesehatan", "hiburan", "pendidikan", "donasi", "lainnya",
+	}
+	defaultIncomeCategories = []string{"gaji", "bonus", "penjualan", "transfer masuk", "pemasukan lainnya"}
+)
+
+// Run is one evaluation, as printed and saved with -out
+type Run struct {
+	Label       string `json:"label"`
+	Parser      string `json:"parser"`
+	Model       string `json:"model,omitempty"`
+	VisionModel string `json:"vision_model,omitempty"`
+	// Prompts are the prompt versions used, e.g. "text@3f2a9c1b"
+	Prompts   []string      `json:"prompts,omitempty"`
+	Dataset   string        `json:"dataset"`
+	StartedAt time.Time     `json:"started_at"`
+	Summary   *Summary      `json:"summary"`
+	Cases     []*CaseResult `json:"cases"`
+}
+
+func main() {
+	_ = godotenv.Load()
+
+	var (
+		datasetPath  = flag.String("dataset", "eval/golden.jsonl", "Labelled cases, one JSON object per line")
+		parserName   = flag.String("parser", parserPipeline, "Parser to evaluate: fake, rules (rules only), model (model only) or pipeline (rules, then model, as the bot runs)")
+		model        = flag.String("model", getEnv("OPENAI_MODEL", "gpt-4o-mini"), "Text model")
+		visionModel  = flag.String("vision-model", os.Getenv("AI_VISION_MODEL"), "Vision model (default -model)")
+		textPrompt   = flag.String("prompt", ai.PromptText, "Text prompt template in internal/ai/prompts")
+		visionPrompt = flag.String("vision-prompt", ai.PromptVision, "Vision prompt template in internal/ai/prompts")
+		label        = flag.String("label", "", "Name of the run in reports (default parser and model)")
+		outPath      = flag.String("out", "", "Save the run with every case result to this JSON file")
+		timeout      = flag.Duration("timeout", 30*time.Second, "Timeout per case")
+		diff         = flag.Bool("diff", false, "Compare two saved runs: evalparser -diff a.json b.json")
+		verbose      = flag.Bool("v", false, "List every failed case")
+	)
+	flag.Parse()
+
+	if *diff {
+		if flag.NArg() != 2 {
+			log.Fatal("-diff needs two saved runs")
+		}
+		a, err := loadRun(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		b, err := loadRun(flag.Arg(1))
+		if err != nil {
+			log.Fatal(err)
+		}
+		printDiff(os.Stdout, a, b)
+		return
+	}
+
+	loc, err := time.LoadLocation(getEnv("TIMEZONE", "Asia/Jakarta"))
+	if err != nil {
+		log.Fatalf("Failed to load timezone: %v", err)
+	}
+	if *visionModel == "" {
+		*visionModel = *model
+	}
+
+	prompts, err := loadPrompts(*textPrompt, *visionPrompt)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	textParser, imageParser, err := newParsers(*parserName, *model, *visionModel, prompts, loc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cases, err := loadDataset(*datasetPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	run := &Run{
+		Label:     *label,
+		Parser:    *parserName,
+		Dataset:   *datasetPath,
+		StartedAt: time.Now(),
+	}
+	if *parserName == parserModel || *parserName == parserPipeline {
+		run.Model, run.VisionModel = *model, *visionModel
+		run.Prompts = []string{prompts[0].ID(), prompts[1].ID()}
+	}
+	if run.Label == "" {
+		run.Label = run.Parser
+		if run.Model != "" {
+			run.Label += "/" + run.Model + "/" + prompts[0].ID()
+		}
+	}
+
+	ctx := ai.WithCategories(context.Background(), defaultIncomeCategories, defaultExpenseCategories)
+	for i, c := range cases {
+		log.Printf("[%d/%d] %s", i+1, len(cases), c.ID)
+		run.Cases = append(run.Cases, evaluate(ctx, c, textParser, imageParser, filepath.Dir(*datasetPath), *timeout, loc))
+	}
+	run.Summary = summarize(run.Cases)
+
+	printSummary(os.Stdout, run, *verbose)
+
+	if *outPath != "" {
+		if err := saveRun(*outPath, run); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Saved run to %s", *outPath)
+	}
+}
+
+// loadPrompts loads the text and vision prompt templates
+func loadPrompts(text, vision string) ([2]*ai.Prompt, error) {
+	var prompts [2]*ai.Prompt
+	for i, name := range []string{text, vision} {
+		p, err := ai.LoadPrompt(name)
+		if err != nil {
+			return prompts, err
+		}
+		prompts[i] = p
+	}
+	return prompts, nil
+}
+
+// newParsers builds the parsers the bot would use for the given name
+func newParsers(name, model, visionModel string, prompts [2]*ai.Prompt, loc *time.Location) (ai.TransactionParser, ai.ImageParser, error) {
+	apiKey, baseURL := os.Getenv("OPENAI_API_KEY"), os.Getenv("AI_BASE_URL")
+	textModel := ai.NewTextParser(apiKey, baseURL, model, loc, nil).WithPrompt(prompts[0])
+	visionParser := ai.NewVisionParser(apiKey, baseURL, visionModel, loc, nil).WithPrompt(prompts[1])
+
+	switch name {
+	case parserFake:
+		fake := ai.NewFakeParser(loc)
+		return fake, fake, nil
+	case parserRules:
+		// Images have no rule-based path
+		return ai.NewRuleParser(rulesOnly{}, loc), rulesOnly{}, nil
+	case parserModel:
+		return textModel, visionParser, nil
+	case parserPipeline:
+		return ai.NewRuleParser(textModel, loc), visionParser, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown parser %q: use fake, rules, model or pipeline", name)
+}
+
+// rulesOnly is the fallback for -parser rules, so messages the rules leave
+// to the model count as unparsed
+type rulesOnly struct{}
+
+var errLeftToModel = errors.New("left to the model")
+
+func (rulesOnly) Parse(ctx context.Context, message string) ([]*domain.ParsedTransaction, error) {
+	return nil, errLeftToModel
+}
+
+func (rulesOnly) ParseImage(ctx context.Context, imageData []byte, mimeType, caption string) (*domain.ParsedTransaction, error) {
+	return nil, errLeftToModel
+}
+
+// evaluate runs one case through the parser and scores it
+func evaluate(ctx context.Context, c *Case, textParser ai.TransactionParser, imageParser ai.ImageParser, dir string, timeout time.Duration, loc *time.Location) *CaseResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var got []*domain.ParsedTransaction
+	var err error
+
+	if c.Image != "" {
+		var data []byte
+		data, err = os.ReadFile(filepath.Join(dir, c.Image))
+		if err == nil {
+			var pt *domain.ParsedTransaction
+			pt, err = imageParser.ParseImage(ctx, data, http.DetectContentType(data), c.Caption)
+			if pt != nil {
+				got = []*domain.ParsedTransaction{pt}
+			}
+		}
+	} else {
+		got, err = textParser.Parse(ctx, c.Message)
+	}
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	result := scoreCase(c, got, err, today, loc)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	return result
+}
+
+func loadRun(path string) (*Run, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run: %w", err)
+	}
+	run := &Run{}
+	if err := json.Unmarshal(data, run); err != nil {
+		return nil, fmt.Errorf("failed to parse run %s: %w", path, err)
+	}
+	return run, nil
+}
+
+func saveRun(path string, run *Run) error {
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create run directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write run: %w", err)
+	}
+	return nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
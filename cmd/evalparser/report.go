@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// KPI targets from the PRD
+const (
+	targetWithoutCorrection = 0.85
+	targetClassification    = 0.90
+)
+
+// metric is one line of the summary and of diffs
+type metric struct {
+	name   string
+	n, of  int
+	target float64
+}
+
+func metrics(s *Summary) []metric {
+	return []metric{
+		{"Processed without correction", s.WithoutCorrection, s.TransactionCases, targetWithoutCorrection},
+		{"Classification (type + category)", s.Classified, s.Transactions, targetClassification},
+		{"Transaction count", s.CountCorrect, s.TransactionCases, 0},
+		{"Type", s.FieldCorrect["type"], s.Transactions, 0},
+		{"Amount", s.FieldCorrect["amount"], s.Transactions, 0},
+		{"Category", s.FieldCorrect["category"], s.Transactions, 0},
+		{"Date", s.FieldCorrect["date"], s.Transactions, 0},
+		{"All fields", s.Correct, s.TransactionCases, 0},
+		{"Auto-saved with a mistake", s.AutoSavedWrong, s.TransactionCases, 0},
+		{"Non-transactions rejected", s.NonTransactionsRejected, s.NonTransactions, 0},
+		{"Parse errors", s.Errors, s.TransactionCases, 0},
+	}
+}
+
+func printSummary(w io.Writer, run *Run, verbose bool) {
+	s := run.Summary
+	fmt.Fprintf(w, "%s: %d cases from %s, %.0f ms per case\n\n", run.Label, s.Cases, run.Dataset, s.MeanLatencyMs)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, m := range metrics(s) {
+		target := ""
+		if m.target > 0 {
+			status := "✅"
+			if rate(m.n, m.of) < m.target {
+				status = "❌"
+			}
+			target = fmt.Sprintf("target ≥ %.0f%% %s", m.target*100, status)
+		}
+		fmt.Fprintf(tw, "%s\t%d/%d\t%5.1f%%\t%s\n", m.name, m.n, m.of, rate(m.n, m.of)*100, target)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nCalibration (ECE %.3f)\n", s.ECE)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "confidence\ttransactions\tmean confidence\taccuracy\t")
+	for _, b := range s.Calibration {
+		fmt.Fprintf(tw, "%.1f-%.1f\t%d\t%.2f\t%.2f\t\n", b.Low, b.High, b.Count, b.MeanConfidence, b.Accuracy)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nType confusion (rows expected, columns returned)")
+	printMatrix(w, s.TypeConfusion)
+
+	fmt.Fprintln(w, "\nCategory confusion (expected → returned)")
+	printSparseMatrix(w, s.CategoryConfusion)
+
+	if !verbose {
+		return
+	}
+	fmt.Fprintln(w, "\nFailed cases")
+	for _, r := range run.Cases {
+		if !r.Correct {
+			fmt.Fprintf(w, "- %s: %q\n  %s\n", r.ID, r.Input, describeFailure(r))
+		}
+	}
+}
+
+// printMatrix prints a dense matrix; fine for the handful of types
+func printMatrix(w io.Writer, matrix map[string]map[string]int) {
+	rows := sortedKeys(matrix)
+	colSet := make(map[string]int)
+	for _, row := range matrix {
+		for col := range row {
+			colSet[col]++
+		}
+	}
+	cols := sortedKeys(colSet)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "\t%s\t\n", strings.Join(cols, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(cols))
+		for i, col := range cols {
+			cells[i] = fmt.Sprint(matrix[row][col])
+		}
+		fmt.Fprintf(tw, "%s\t%s\t\n", row, strings.Join(cells, "\t"))
+	}
+	tw.Flush()
+}
+
+// printSparseMatrix prints one line per expected label, hits first, since
+// category matrices are mostly empty
+func printSparseMatrix(w io.Writer, matrix map[string]map[string]int) {
+	for _, row := range sortedKeys(matrix) {
+		cols := sortedKeys(matrix[row])
+		sort.SliceStable(cols, func(i, j int) bool {
+			if (cols[i] == row) != (cols[j] == row) {
+				return cols[i] == row
+			}
+			return matrix[row][cols[i]] > matrix[row][cols[j]]
+		})
+
+		cells := make([]string, len(cols))
+		for i, col := range cols {
+			cells[i] = fmt.Sprintf("%s %d", col, matrix[row][col])
+		}
+		fmt.Fprintf(w, "  %s → %s\n", row, strings.Join(cells, ", "))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// describeFailure explains what a case got wrong
+func describeFailure(r *CaseResult) string {
+	if r.Error != "" {
+		return "error: " + r.Error
+	}
+	if len(r.Expected) == 0 {
+		return fmt.Sprintf("not a transaction, but got %s", formatGot(r.Got))
+	}
+
+	var problems []string
+	if len(r.Got) != len(r.Expected) {
+		problems = append(problems, fmt.Sprintf("expected %d transactions, got %d", len(r.Expected), len(r.Got)))
+	}
+	for i, e := range r.Expected {
+		if i >= len(r.Got) {
+			break
+		}
+		g := r.Got[i]
+		for _, f := range fields {
+			if r.Checks[i][f] {
+				continue
+			}
+			var want, have string
+			switch f {
+			case "type":
+				want, have = e.Type, g.Type
+			case "amount":
+				want, have = fmt.Sprintf("%.0f", e.Amount), fmt.Sprintf("%.0f", g.Amount)
+			case "category":
+				want, have = e.Category, g.Category
+			case "date":
+				want, have = r.ExpectedDates[i], g.Date
+			}
+			problems = append(problems, fmt.Sprintf("#%d %s: want %s, got %s", i+1, f, want, have))
+		}
+	}
+	if len(problems) == 0 && r.Rejected {
+		problems = append(problems, "right, but too unsure to be saved")
+	}
+	return strings.Join(problems, "; ")
+}
+
+func formatGot(got []GotTransaction) string {
+	parts := make([]string, len(got))
+	for i, g := range got {
+		parts[i] = fmt.Sprintf("%s %.0f %s (%.2f)", g.Type, g.Amount, g.Category, g.Confidence)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printDiff compares two runs metric by metric and lists the cases that
+// changed outcome
+func printDiff(w io.Writer, a, b *Run) {
+	fmt.Fprintf(w, "A: %s (%s)\nB: %s (%s)\n\n", a.Label, a.StartedAt.Format("2006-01-02 15:04"), b.Label, b.StartedAt.Format("2006-01-02 15:04"))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tA\tB\tΔ")
+	ma, mb := metrics(a.Summary), metrics(b.Summary)
+	for i := range ma {
+		ra, rb := rate(ma[i].n, ma[i].of)*100, rate(mb[i].n, mb[i].of)*100
+		fmt.Fprintf(tw, "%s\t%5.1f%%\t%5.1f%%\t%+.1f\n", ma[i].name, ra, rb, rb-ra)
+	}
+	fmt.Fprintf(tw, "ECE\t%.3f\t%.3f\t%+.3f\n", a.Summary.ECE, b.Summary.ECE, b.Summary.ECE-a.Summary.ECE)
+	fmt.Fprintf(tw, "Latency (ms)\t%.0f\t%.0f\t%+.0f\n", a.Summary.MeanLatencyMs, b.Summary.MeanLatencyMs, b.Summary.MeanLatencyMs-a.Summary.MeanLatencyMs)
+	tw.Flush()
+
+	before := make(map[string]*CaseResult, len(a.Cases))
+	for _, r := range a.Cases {
+		before[r.ID] = r
+	}
+
+	var fixed, regressed, added []*CaseResult
+	for _, r := range b.Cases {
+		old, ok := before[r.ID]
+		switch {
+		case !ok:
+			added = append(added, r)
+		case !old.Correct && r.Correct:
+			fixed = append(fixed, r)
+		case old.Correct && !r.Correct:
+			regressed = append(regressed, r)
+		}
+		delete(before, r.ID)
+	}
+
+	fmt.Fprintf(w, "\nFixed in B (%d)\n", len(fixed))
+	for _, r := range fixed {
+		fmt.Fprintf(w, "- %s: %q\n", r.ID, r.Input)
+	}
+	fmt.Fprintf(w, "\nRegressed in B (%d)\n", len(regressed))
+	for _, r := range regressed {
+		fmt.Fprintf(w, "- %s: %q\n  %s\n", r.ID, r.Input, describeFailure(r))
+	}
+	if len(added) > 0 || len(before) > 0 {
+		fmt.Fprintf(w, "\n%d cases only in B, %d only in A; the runs used different datasets\n", len(added), len(before))
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+func TestPrintDiff(t *testing.T) {
+	right := func(id string) *CaseResult {
+		return result(id, domain.TypeExpense, "makan", &GotTransaction{Type: domain.TypeExpense, Category: "makan", Confidence: 0.9}, allRight, true)
+	}
+	wrong := func(id string) *CaseResult {
+		return result(id, domain.TypeExpense, "makan", &GotTransaction{Type: domain.TypeExpense, Category: "belanja", Confidence: 0.9},
+			map[string]bool{"type": true, "amount": true, "category": false, "date": true}, true)
+	}
+
+	a := &Run{Label: "before", StartedAt: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		Cases: []*CaseResult{right("same"), wrong("fixed"), right("regressed"), right("dropped")}}
+	b := &Run{Label: "after", StartedAt: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		Cases: []*CaseResult{right("same"), right("fixed"), wrong("regressed"), right("new")}}
+	a.Summary, b.Summary = summarize(a.Cases), summarize(b.Cases)
+
+	var buf bytes.Buffer
+	printDiff(&buf, a, b)
+	out := buf.String()
+
+	for _, want := range []string{
+		"A: before (2026-03-01 09:00)",
+		"Fixed in B (1)\n- fixed:",
+		"Regressed in B (1)\n- regressed:",
+		"#1 category: want makan, got belanja",
+		"1 cases only in B, 1 only in A",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("diff is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "- same:") {
+		t.Errorf("diff lists an unchanged case:\n%s", out)
+	}
+
+	// Both runs score 75% on all fields, so the line shows no change
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "All fields") && (strings.Count(line, "75.0%") != 2 || !strings.HasSuffix(line, "+0.0")) {
+			t.Errorf("all-field line = %q, want 75.0%% in both runs and +0.0", line)
+		}
+	}
+}
+
+func TestDescribeFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *CaseResult
+		want string
+	}{
+		{"error", &CaseResult{Error: "timeout", Expected: []ExpectedTransaction{{}}}, "error: timeout"},
+		{"non-transaction", &CaseResult{Got: []GotTransaction{{Type: domain.TypeExpense, Amount: 1, Category: "lainnya", Confidence: 0.8}}},
+			"not a transaction, but got EXPENSE 1 lainnya (0.80)"},
+		{"count", &CaseResult{Expected: []ExpectedTransaction{{}, {}}, Got: []GotTransaction{{}}, Checks: []map[string]bool{allRight, {}}},
+			"expected 2 transactions, got 1"},
+		{"date", &CaseResult{
+			Expected:      []ExpectedTransaction{{Type: domain.TypeExpense, Amount: 1000, Category: "makan"}},
+			ExpectedDates: []string{"2026-03-17"},
+			Got:           []GotTransaction{{Type: domain.TypeExpense, Amount: 1000, Category: "makan", Date: "2026-03-18"}},
+			Checks:        []map[string]bool{{"type": true, "amount": true, "category": true}},
+		}, "#1 date: want 2026-03-17, got 2026-03-18"},
+		{"unsure", &CaseResult{Expected: []ExpectedTransaction{{}}, Got: []GotTransaction{{}}, Checks: []map[string]bool{allRight}, Rejected: true},
+			"right, but too unsure to be saved"},
+	}
+
+	for _, tt := range tests {
+		if got := describeFailure(tt.r); got != tt.want {
+			t.Errorf("%s: describeFailure = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// Fields scored for every expected transaction
+var fields = []string{"type", "amount", "category", "date"}
+
+// noneLabel stands for a missing transaction in confusion matrices
+const noneLabel = "(none)"
+
+// calibrationBins follow the confidence thresholds the bot acts on: reject,
+// ask for confirmation, auto-save
+var calibrationBins = [][2]float64{{0, 0.4}, {0.4, 0.7}, {0.7, 0.9}, {0.9, 1.01}}
+
+// GotTransaction is what the parser returned for one transaction
+type GotTransaction struct {
+	Type       string  `json:"type"`
+	Amount     float64 `json:"amount"`
+	Category   string  `json:"category"`
+	Date       string  `json:"date"`
+	Confidence float64 `json:"confidence"`
+}
+
+// CaseResult is the outcome of one case
+type CaseResult struct {
+	ID       string                `json:"id"`
+	Input    string                `json:"input"`
+	Expected []ExpectedTransaction `json:"expected"`
+	// ExpectedDates are the labelled dates as of the run
+	ExpectedDates []string         `json:"expected_dates,omitempty"`
+	Got           []GotTransaction `json:"got,omitempty"`
+	Error         string           `json:"error,omitempty"`
+	// Checks holds, per expected transaction, which fields were right
+	Checks []map[string]bool `json:"checks,omitempty"`
+	// Correct means the right number of transactions with every field right;
+	// for non-transactions it means nothing would have been saved
+	Correct bool `json:"correct"`
+	// AutoSaved means the bot would have saved the result without asking;
+	// Rejected that it would have asked the user to rephrase
+	AutoSaved bool  `json:"auto_saved"`
+	Rejected  bool  `json:"rejected"`
+	LatencyMs int64 `json:"latency_ms"`
+}
+
+// scoreCase compares a parser result with the labels
+func scoreCase(c *Case, got []*domain.ParsedTransaction, err error, today time.Time, loc *time.Location) *CaseResult {
+	r := &CaseResult{ID: c.ID, Input: c.Message, Expected: c.Expected}
+	if c.Image != "" {
+		r.Input = c.Image
+		if c.Caption != "" {
+			r.Input += " (" + c.Caption + ")"
+		}
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+		got = nil
+	}
+	for _, pt := range got {
+		r.Got = append(r.Got, GotTransaction{
+			Type:       pt.Type,
+			Amount:     pt.Amount,
+			Category:   strings.ToLower(pt.Category),
+			Date:       pt.Date.In(loc).Format("2006-01-02"),
+			Confidence: pt.Confidence,
+		})
+	}
+
+	lowest := domain.LeastConfident(got)
+	r.AutoSaved = lowest != nil && lowest.ShouldAutoSave()
+	r.Rejected = lowest == nil || lowest.ShouldReject()
+
+	if len(c.Expected) == 0 {
+		r.Correct = r.Rejected
+		return r
+	}
+
+	r.Correct = len(got) == len(c.Expected)
+	for i, e := range c.Expected {
+		r.ExpectedDates = append(r.ExpectedDates, e.date(today))
+		checks := make(map[string]bool, len(fields))
+		if i < len(r.Got) {
+			g := r.Got[i]
+			checks["type"] = g.Type == e.Type
+			checks["amount"] = math.Abs(g.Amount-e.Amount) < 0.5
+			checks["category"] = contains(e.categories(), g.Category)
+			checks["date"] = g.Date == e.date(today)
+		}
+		for _, f := range fields {
+			r.Correct = r.Correct && checks[f]
+		}
+		r.Checks = append(r.Checks, checks)
+	}
+
+	return r
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// CalibrationBin compares stated confidence with how often the parser was
+// actually right
+type CalibrationBin struct {
+	Low            float64 `json:"low"`
+	High           float64 `json:"high"`
+	Count          int     `json:"count"`
+	MeanConfidence float64 `json:"mean_confidence"`
+	Accuracy       float64 `json:"accuracy"`
+}
+
+// Summary aggregates the results of a run
+type Summary struct {
+	Cases            int `json:"cases"`
+	TransactionCases int `json:"transaction_cases"`
+	Transactions     int `json:"transactions"`
+	// Errors counts transaction cases the parser failed on
+	Errors int `json:"errors"`
+
+	CountCorrect int            `json:"count_correct"`
+	FieldCorrect map[string]int `json:"field_correct"`
+	// Classified counts transactions with both type and category right
+	Classified int `json:"classified"`
+	Correct    int `json:"correct"`
+	// WithoutCorrection are transaction cases saved right, whether
+	// automatically or after a plain "ya"; AutoSavedWrong were saved with a
+	// mistake the user has to fix
+	WithoutCorrection int `json:"without_correction"`
+	AutoSavedWrong    int `json:"auto_saved_wrong"`
+
+	NonTransactions         int `json:"non_transactions"`
+	NonTransactionsRejected int `json:"non_transactions_rejected"`
+
+	Calibration []CalibrationBin `json:"calibration"`
+	// ECE is the expected calibration error: the gap between confidence and
+	// accuracy, weighted by how many transactions fall in each bin
+	ECE float64 `json:"ece"`
+
+	// Confusion matrices, expected label → returned label → count
+	TypeConfusion     map[string]map[string]int `json:"type_confusion"`
+	CategoryConfusion map[string]map[string]int `json:"category_confusion"`
+
+	MeanLatencyMs float64 `json:"mean_latency_ms"`
+}
+
+// summarize aggregates case results
+func summarize(results []*CaseResult) *Summary {
+	s := &Summary{
+		Cases:             len(results),
+		FieldCorrect:      make(map[string]int),
+		TypeConfusion:     make(map[string]map[string]int),
+		CategoryConfusion: make(map[string]map[string]int),
+	}
+
+	bins := make([]CalibrationBin, len(calibrationBins))
+	correctInBin := make([]int, len(calibrationBins))
+	var latency int64
+
+	for _, r := range results {
+		latency += r.LatencyMs
+
+		if len(r.Expected) == 0 {
+			s.NonTransactions++
+			if r.Correct {
+				s.NonTransactionsRejected++
+			}
+			continue
+		}
+
+		s.TransactionCases++
+		s.Transactions += len(r.Expected)
+		if r.Error != "" {
+			s.Errors++
+		}
+		if len(r.Got) == len(r.Expected) {
+			s.CountCorrect++
+		}
+		if r.Correct {
+			s.Correct++
+		}
+		if r.Correct && !r.Rejected {
+			s.WithoutCorrection++
+		}
+		if r.AutoSaved && !r.Correct {
+			s.AutoSavedWrong++
+		}
+
+		for i, e := range r.Expected {
+			checks := r.Checks[i]
+			for _, f := range fields {
+				if checks[f] {
+					s.FieldCorrect[f]++
+				}
+			}
+			if checks["type"] && checks["category"] {
+				s.Classified++
+			}
+
+			gotType, gotCategory := noneLabel, noneLabel
+			if i < len(r.Got) {
+				gotType, gotCategory = r.Got[i].Type, r.Got[i].Category
+			}
+			accepted := e.categories()
+			expectedCategory := noneLabel
+			if len(accepted) > 0 {
+				expectedCategory = accepted[0]
+			}
+			// Accepted alternatives count as the canonical name
+			if checks["category"] {
+				gotCategory = expectedCategory
+			}
+			increment(s.TypeConfusion, e.Type, gotType)
+			increment(s.CategoryConfusion, expectedCategory, gotCategory)
+
+			if i >= len(r.Got) {
+				continue
+			}
+			confidence := r.Got[i].Confidence
+			for b, bounds := range calibrationBins {
+				if confidence >= bounds[0] && confidence < bounds[1] {
+					bins[b].Count++
+					bins[b].MeanConfidence += confidence
+					if allTrue(checks) {
+						correctInBin[b]++
+					}
+					break
+				}
+			}
+		}
+	}
+
+	var scored int
+	for _, b := range bins {
+		scored += b.Count
+	}
+	for i := range bins {
+		bins[i].Low, bins[i].High = calibrationBins[i][0], math.Min(calibrationBins[i][1], 1)
+		if bins[i].Count == 0 {
+			continue
+		}
+		bins[i].MeanConfidence /= float64(bins[i].Count)
+		bins[i].Accuracy = float64(correctInBin[i]) / float64(bins[i].Count)
+		s.ECE += float64(bins[i].Count) / float64(scored) * math.Abs(bins[i].Accuracy-bins[i].MeanConfidence)
+	}
+	s.Calibration = bins
+
+	if len(results) > 0 {
+		s.MeanLatencyMs = float64(latency) / float64(len(results))
+	}
+
+	return s
+}
+
+func increment(matrix map[string]map[string]int, row, col string) {
+	if matrix[row] == nil {
+		matrix[row] = make(map[string]int)
+	}
+	matrix[row][col]++
+}
+
+func allTrue(checks map[string]bool) bool {
+	for _, f := range fields {
+		if !checks[f] {
+			return false
+		}
+	}
+	return true
+}
+
+// rate returns n/total, or 0 for an empty total
+func rate(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
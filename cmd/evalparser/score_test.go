@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+var (
+	evalLoc   = time.FixedZone("WIB", 7*3600)
+	evalToday = time.Date(2026, 3, 18, 0, 0, 0, 0, evalLoc)
+)
+
+func parsed(txType string, amount float64, category string, daysAgo int, confidence float64) *domain.ParsedTransaction {
+	return &domain.ParsedTransaction{
+		Type:       txType,
+		Amount:     amount,
+		Category:   category,
+		Date:       evalToday.AddDate(0, 0, -daysAgo).Add(10 * time.Hour),
+		Confidence: confidence,
+	}
+}
+
+func TestScoreCase(t *testing.T) {
+	coffee := ExpectedTransaction{Type: domain.TypeExpense, Amount: 20000, Category: "kopi & jajan|makan"}
+	yesterday := ExpectedTransaction{Type: domain.TypeExpense, Amount: 50000, Category: "bensin", DaysAgo: 1}
+
+	tests := []struct {
+		name      string
+		expected  []ExpectedTransaction
+		got       []*domain.ParsedTransaction
+		err       error
+		checks    []map[string]bool
+		correct   bool
+		autoSaved bool
+		rejected  bool
+	}{
+		{"all right",
+			[]ExpectedTransaction{coffee}, []*domain.ParsedTransaction{parsed(domain.TypeExpense, 20000, "Kopi & Jajan", 0, 0.95)}, nil,
+			[]map[string]bool{{"type": true, "amount": true, "category": true, "date": true}}, true, true, false},
+		{"accepted alternative category",
+			[]ExpectedTransaction{coffee}, []*domain.ParsedTransaction{parsed(domain.TypeExpense, 20000.4, "makan", 0, 0.5)}, nil,
+			[]map[string]bool{{"type": true, "amount": true, "category": true, "date": true}}, true, false, false},
+		{"wrong amount and date",
+			[]ExpectedTransaction{yesterday}, []*domain.ParsedTransaction{parsed(domain.TypeExpense, 5000, "bensin", 0, 0.8)}, nil,
+			[]map[string]bool{{"type": true, "amount": false, "category": true, "date": false}}, false, true, false},
+		{"missing second transaction",
+			[]ExpectedTransaction{coffee, yesterday}, []*domain.ParsedTransaction{parsed(domain.TypeExpense, 20000, "kopi & jajan", 0, 0.9)}, nil,
+			[]map[string]bool{{"type": true, "amount": true, "category": true, "date": true}, {}}, false, true, false},
+		{"error",
+			[]ExpectedTransaction{coffee}, []*domain.ParsedTransaction{parsed(domain.TypeExpense, 20000, "kopi & jajan", 0, 0.9)}, errors.New("timeout"),
+			[]map[string]bool{{}}, false, false, true},
+		{"non-transaction rejected",
+			nil, []*domain.ParsedTransaction{parsed(domain.TypeExpense, 1, "lainnya", 0, 0.2)}, nil,
+			nil, true, false, true},
+		{"non-transaction saved",
+			nil, []*domain.ParsedTransaction{parsed(domain.TypeExpense, 1, "lainnya", 0, 0.8)}, nil,
+			nil, false, true, false},
+	}
+
+	for _, tt := range tests {
+		r := scoreCase(&Case{ID: tt.name, Message: "msg", Expected: tt.expected}, tt.got, tt.err, evalToday, evalLoc)
+		if !reflect.DeepEqual(r.Checks, tt.checks) {
+			t.Errorf("%s: checks = %v, want %v", tt.name, r.Checks, tt.checks)
+		}
+		if r.Correct != tt.correct || r.AutoSaved != tt.autoSaved || r.Rejected != tt.rejected {
+			t.Errorf("%s: correct/auto-saved/rejected = %v/%v/%v, want %v/%v/%v", tt.name,
+				r.Correct, r.AutoSaved, r.Rejected, tt.correct, tt.autoSaved, tt.rejected)
+		}
+	}
+}
+
+func TestScoreCaseRecordsWhatWasReturned(t *testing.T) {
+	c := &Case{ID: "img", Image: "images/receipt.png", Caption: "makan siang",
+		Expected: []ExpectedTransaction{{Type: domain.TypeExpense, Amount: 45000, Category: "makanan & minuman", Date: "2026-03-17"}}}
+
+	r := scoreCase(c, []*domain.ParsedTransaction{parsed(domain.TypeExpense, 45000, "Makanan & Minuman", 1, 0.9)}, nil, evalToday, evalLoc)
+
+	if r.Input != "images/receipt.png (makan siang)" {
+		t.Errorf("input = %q, want the image and its caption", r.Input)
+	}
+	want := []GotTransaction{{Type: domain.TypeExpense, Amount: 45000, Category: "makanan & minuman", Date: "2026-03-17", Confidence: 0.9}}
+	if !reflect.DeepEqual(r.Got, want) || !reflect.DeepEqual(r.ExpectedDates, []string{"2026-03-17"}) || !r.Correct {
+		t.Errorf("result = %+v, want %+v on the labelled date", r, want)
+	}
+}
+
+// result builds a scored case with one expected transaction
+func result(id string, expectedType, expectedCategory string, got *GotTransaction, checks map[string]bool, autoSaved bool) *CaseResult {
+	r := &CaseResult{
+		ID:        id,
+		Expected:  []ExpectedTransaction{{Type: expectedType, Amount: 1000, Category: expectedCategory}},
+		Checks:    []map[string]bool{checks},
+		AutoSaved: autoSaved,
+		Rejected:  got == nil || got.Confidence < 0.4,
+		LatencyMs: 100,
+	}
+	if got != nil {
+		r.Got = []GotTransaction{*got}
+	}
+	r.Correct = got != nil && allTrue(checks)
+	return r
+}
+
+var allRight = map[string]bool{"type": true, "amount": true, "category": true, "date": true}
+
+func TestSummarize(t *testing.T) {
+	results := []*CaseResult{
+		result("right", domain.TypeExpense, "makan", &GotTransaction{Type: domain.TypeExpense, Category: "makan", Confidence: 0.95}, allRight, true),
+		// Accepted alternatives count as the canonical name in the confusion matrix
+		result("alternative", domain.TypeExpense, "kopi & jajan|kopi", &GotTransaction{Type: domain.TypeExpense, Category: "kopi", Confidence: 0.5}, allRight, false),
+		result("wrong category", domain.TypeExpense, "makan", &GotTransaction{Type: domain.TypeExpense, Category: "belanja", Confidence: 0.8},
+			map[string]bool{"type": true, "amount": true, "category": false, "date": true}, true),
+		result("wrong type", domain.TypeIncome, "gaji", &GotTransaction{Type: domain.TypeExpense, Category: "gaji", Confidence: 0.3},
+			map[string]bool{"type": false, "amount": true, "category": true, "date": true}, false),
+		result("error", domain.TypeExpense, "bensin", nil, map[string]bool{}, false),
+		{ID: "hello", Correct: true, Rejected: true, LatencyMs: 100},
+		{ID: "saved hello", Correct: false, AutoSaved: true, LatencyMs: 400},
+	}
+	results[4].Error = "timeout"
+
+	s := summarize(results)
+
+	if s.Cases != 7 || s.TransactionCases != 5 || s.Transactions != 5 || s.Errors != 1 {
+		t.Errorf("counts = %d cases, %d transaction cases, %d transactions, %d errors, want 7, 5, 5, 1",
+			s.Cases, s.TransactionCases, s.Transactions, s.Errors)
+	}
+	if want := map[string]int{"type": 3, "amount": 4, "category": 3, "date": 4}; !reflect.DeepEqual(s.FieldCorrect, want) {
+		t.Errorf("field correct = %v, want %v", s.FieldCorrect, want)
+	}
+	if s.CountCorrect != 4 || s.Correct != 2 || s.Classified != 2 {
+		t.Errorf("count/all/classified = %d/%d/%d, want 4/2/2", s.CountCorrect, s.Correct, s.Classified)
+	}
+	if s.WithoutCorrection != 2 || s.AutoSavedWrong != 1 {
+		t.Errorf("without correction = %d, auto-saved wrong = %d, want 2 and 1", s.WithoutCorrection, s.AutoSavedWrong)
+	}
+	if s.NonTransactions != 2 || s.NonTransactionsRejected != 1 {
+		t.Errorf("non-transactions = %d with %d rejected, want 2 with 1", s.NonTransactions, s.NonTransactionsRejected)
+	}
+	if s.MeanLatencyMs != 1000.0/7 {
+		t.Errorf("mean latency = %v, want %v", s.MeanLatencyMs, 1000.0/7)
+	}
+
+	wantType := map[string]map[string]int{
+		domain.TypeExpense: {domain.TypeExpense: 3, noneLabel: 1},
+		domain.TypeIncome:  {domain.TypeExpense: 1},
+	}
+	if !reflect.DeepEqual(s.TypeConfusion, wantType) {
+		t.Errorf("type confusion = %v, want %v", s.TypeConfusion, wantType)
+	}
+	wantCategory := map[string]map[string]int{
+		"makan":        {"makan": 1, "belanja": 1},
+		"kopi & jajan": {"kopi & jajan": 1},
+		"gaji":         {"gaji": 1},
+		"bensin":       {noneLabel: 1},
+	}
+	if !reflect.DeepEqual(s.CategoryConfusion, wantCategory) {
+		t.Errorf("category confusion = %v, want %v", s.CategoryConfusion, wantCategory)
+	}
+
+	// 0.3 is wrong; 0.5 right; 0.8 wrong; 0.95 right
+	wantBins := []CalibrationBin{
+		{Low: 0, High: 0.4, Count: 1, MeanConfidence: 0.3, Accuracy: 0},
+		{Low: 0.4, High: 0.7, Count: 1, MeanConfidence: 0.5, Accuracy: 1},
+		{Low: 0.7, High: 0.9, Count: 1, MeanConfidence: 0.8, Accuracy: 0},
+		{Low: 0.9, High: 1, Count: 1, MeanConfidence: 0.95, Accuracy: 1},
+	}
+	if !reflect.DeepEqual(s.Calibration, wantBins) {
+		t.Errorf("calibration = %+v, want %+v", s.Calibration, wantBins)
+	}
+	if want := (0.3 + 0.5 + 0.8 + 0.05) / 4; math.Abs(s.ECE-want) > 1e-9 {
+		t.Errorf("ECE = %v, want %v", s.ECE, want)
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	s := summarize(nil)
+	if s.Cases != 0 || s.ECE != 0 || s.MeanLatencyMs != 0 || len(s.Calibration) != len(calibrationBins) {
+		t.Errorf("empty summary = %+v", s)
+	}
+}
+
+func TestRate(t *testing.T) {
+	if got := rate(3, 4); got != 0.75 {
+		t.Errorf("rate(3, 4) = %v, want 0.75", got)
+	}
+	if got := rate(0, 0); got != 0 {
+		t.Errorf("rate(0, 0) = %v, want 0", got)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
 
 	_ "github.com/lib/pq"
 	"github.com/nicolaananda/catatuang/internal/config"
@@ -39,8 +41,64 @@ func main() {
 }
 
 func migrateUp(db *sql.DB) error {
+	// Track applied migrations so each file runs once
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT NOW()
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	files, err := filepath.Glob("migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+	sort.Strings(files)
+
+	if err := baselineInitialSchema(db); err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		version := filepath.Base(path)
+
+		var applied bool
+		if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied); err != nil {
+			return fmt.Errorf("failed to check migration %s: %w", version, err)
+		}
+		if applied {
+			continue
+		}
+
+		if err := applyMigration(db, path, version); err != nil {
+			return err
+		}
+		log.Printf("Applied %s", version)
+	}
+
+	return nil
+}
+
+// baselineInitialSchema marks 001 as applied on databases migrated before
+// schema_migrations existed, since its CREATE INDEX statements can't run twice
+func baselineInitialSchema(db *sql.DB) error {
+	_, err := db.Exec(`
+		INSERT INTO schema_migrations (version)
+		SELECT '001_initial_schema.sql'
+		WHERE to_regclass('public.users') IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM schema_migrations)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to baseline initial schema: %w", err)
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, path, version string) error {
 	// Read migration file
-	file, err := os.Open("migrations/001_initial_schema.sql")
+	file, err := os.Open(path)
 	if err != nil {
 		return fmt.Errorf("failed to open migration file: %w", err)
 	}
@@ -51,10 +109,20 @@ func migrateUp(db *sql.DB) error {
 		return fmt.Errorf("failed to read migration file: %w", err)
 	}
 
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// Execute migration
-	if _, err := db.Exec(string(content)); err != nil {
-		return fmt.Errorf("failed to execute migration: %w", err)
+	if _, err := tx.Exec(string(content)); err != nil {
+		return fmt.Errorf("failed to execute migration %s: %w", version, err)
 	}
 
-	return nil
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", version, err)
+	}
+
+	return tx.Commit()
 }
@@ -0,0 +1,332 @@
+package ai
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AmountToken is an amount found in a message. Start and End are byte
+// offsets into the original text.
+type AmountToken struct {
+	Value float64
+	Text  string
+	Start int
+	End   int
+	// Explicit is true when the text marks the number as money: a currency
+	// sign ("Rp"), a suffix ("rb", "jt", "k", "M"), a spelled-out scale
+	// ("seratus ribu") or slang ("goceng"). Bare numbers like "2" may be
+	// quantities, dates or times.
+	Explicit bool
+}
+
+var (
+	// Numerals and words; "1,5jt" splits into "1,5" and "jt"
+	amountWordPattern = regexp.MustCompile(`\d(?:[\d.,]*\d)?|[a-z]+`)
+
+	idThousandsPattern = regexp.MustCompile(`^\d{1,3}(?:\.\d{3})+(?:,\d{1,2})?$`) // 1.250.000 / 1.250,50
+	enThousandsPattern = regexp.MustCompile(`^\d{1,3}(?:,\d{3})+(?:\.\d{1,2})?$`) // 1,250,000 / 1,250.50
+	decimalPattern     = regexp.MustCompile(`^\d+[.,]\d+$`)                       // 1,5 / 2.5
+	plainPattern       = regexp.MustCompile(`^\d+$`)
+
+	currencyPrefixes = map[string]bool{"rp": true, "idr": true}
+	currencySuffixes = map[string]bool{"rupiah": true, "perak": true}
+
+	// Spelled-out digits
+	amountDigits = map[string]float64{
+		"satu": 1, "dua": 2, "tiga": 3, "empat": 4, "lima": 5,
+		"enam": 6, "tujuh": 7, "delapan": 8, "sembilan": 9,
+	}
+
+	// Words with "se-" (one) built in that start a group below a thousand
+	amountOnes = map[string]float64{"sepuluh": 10, "sebelas": 11, "seratus": 100}
+
+	// amountScales multiply the number before them. Scales with an implicit
+	// one ("seribu", "goceng") also stand alone. Only a capital "M" is
+	// miliar: a lowercase "m" is as often minutes, metres or million.
+	amountScales = map[string]float64{
+		"k": 1e3, "rb": 1e3, "rbu": 1e3, "ribu": 1e3, "rebu": 1e3,
+		"jt": 1e6, "juta": 1e6,
+		"ewu": 1e3, "yuta": 1e6, "thousand": 1e3, "million": 1e6,
+		"M": 1e9, "miliar": 1e9, "milyar": 1e9,
+		"triliun": 1e12,
+	}
+	amountImplicitScales = map[string]float64{
+		"seribu": 1e3, "sejuta": 1e6, "semiliar": 1e9, "semilyar": 1e9,
+		"sewu": 1e3, "sayuta": 1e6,
+		// Betawi/Hokkien slang
+		"gocap": 50, "cepek": 100, "gopek": 500, "seceng": 1e3, "noceng": 2e3,
+		"goceng": 5e3, "ceban": 1e4, "noban": 2e4, "goban": 5e4,
+	}
+
+	// amountSuffixes are the abbreviated scales. A number with one is a whole
+	// amount, so "2jt 50rb" is two amounts while "2 juta 50 ribu" is one.
+	amountSuffixes = map[string]bool{"k": true, "rb": true, "rbu": true, "jt": true, "M": true}
+)
+
+type amountWord struct {
+	text       string
+	start, end int
+}
+
+// TokenizeAmounts finds every amount in text: numerals with Indonesian or
+// English separators ("1.250.000", "1,5"), suffixes ("25k", "1,5jt", "2 M"),
+// spelled-out numbers ("seratus lima puluh ribu", "dua setengah juta") and
+// slang ("goceng", "cepek")
+func TokenizeAmounts(text string) []AmountToken {
+	lower := asciiLower(text)
+
+	var words []amountWord
+	for _, loc := range amountWordPattern.FindAllStringIndex(lower, -1) {
+		word := lower[loc[0]:loc[1]]
+		if text[loc[0]:loc[1]] == "M" {
+			word = "M"
+		}
+		words = append(words, amountWord{text: word, start: loc[0], end: loc[1]})
+	}
+
+	var tokens []AmountToken
+	for i := 0; i < len(words); {
+		start, currency := i, false
+		if currencyPrefixes[words[i].text] && i+1 < len(words) {
+			currency = true
+			i++
+		}
+
+		value, n, explicit := parseAmountRun(words[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		end := i + n
+		if end < len(words) && currencySuffixes[words[end].text] {
+			explicit = true
+			end++
+		}
+		if !currency {
+			start = i
+		}
+		tokenEnd := words[end-1].end
+		// "Rp50.000,-" is written with a dash for the cents
+		if rest := text[tokenEnd:]; strings.HasPrefix(rest, ",-") || strings.HasPrefix(rest, ".-") {
+			tokenEnd += 2
+		}
+
+		tokens = append(tokens, AmountToken{
+			Value:    math.Round(value*100) / 100,
+			Text:     text[words[start].start:tokenEnd],
+			Start:    words[start].start,
+			End:      tokenEnd,
+			Explicit: explicit || currency,
+		})
+		i = end
+	}
+
+	return tokens
+}
+
+// parseAmountRun reads one amount from the start of words and returns it with
+// the number of words used (0 if words doesn't start with a number)
+func parseAmountRun(words []amountWord) (value float64, n int, explicit bool) {
+	var (
+		total, group, pending float64 // committed total, current group below a thousand, last digit(s)
+		lastScale             = math.Inf(1)
+		spelled, composed     bool // composed: group used puluh/ratus/belas since the last scale
+		committed             int  // words used up to the last scale
+		skip                  bool // the word was read with the scale before it
+	)
+
+run:
+	for j, w := range words {
+		if skip {
+			skip = false
+			n = j + 1
+			continue
+		}
+		switch {
+		case parseNumeralOK(w.text):
+			// A numeral starts a group: "2 juta 500 ribu", not "dua 5"
+			if group != 0 || pending != 0 {
+				break run
+			}
+			pending, _ = parseNumeral(w.text)
+		case amountDigits[w.text] != 0:
+			// "dua tiga" is two numbers
+			if pending != 0 {
+				break run
+			}
+			pending = amountDigits[w.text]
+			spelled = true
+		case amountOnes[w.text] != 0:
+			if pending != 0 || (group != 0 && amountOnes[w.text] == 100) {
+				break run
+			}
+			group += amountOnes[w.text]
+			spelled, composed = true, true
+		case w.text == "belas":
+			if pending < 1 || pending > 9 {
+				break run
+			}
+			pending += 10
+			composed = true
+		case w.text == "puluh" || w.text == "ratus":
+			if pending < 1 || pending > 9 {
+				break run
+			}
+			multiplier := 10.0
+			if w.text == "ratus" {
+				multiplier = 100
+			}
+			group += pending * multiplier
+			pending = 0
+			composed = true
+		case w.text == "setengah":
+			// "setengah juta", "dua setengah juta"
+			if pending != math.Trunc(pending) {
+				break run
+			}
+			pending += 0.5
+			spelled = true
+		case amountScales[w.text] != 0 || amountImplicitScales[w.text] != 0:
+			scale, implicit := amountScales[w.text], false
+			if scale == 0 {
+				scale, implicit = amountImplicitScales[w.text], true
+			}
+			v := group + pending
+			if v == 0 {
+				if !implicit {
+					break run
+				}
+				v = 1
+			}
+			// Scales only go down: "2 juta 500 ribu", not "2 ribu 3 ribu".
+			// An abbreviated scale ends the previous amount instead.
+			if scale >= lastScale || (!math.IsInf(lastScale, 1) && amountSuffixes[w.text]) {
+				break run
+			}
+			total += v * scale
+			group, pending = 0, 0
+			lastScale = scale
+			explicit, composed = true, false
+			committed = j + 1
+
+			// "1jt500" and "2rb5": digits right after an abbreviated scale
+			// are its leading digits, 1.500.000 and 2.500
+			if next := j + 1; amountSuffixes[w.text] && next < len(words) && words[next].start == w.end &&
+				plainPattern.MatchString(words[next].text) && len(words[next].text) <= 3 {
+				digits, _ := strconv.ParseFloat(words[next].text, 64)
+				total += digits / math.Pow(10, float64(len(words[next].text))) * scale
+				committed, skip = next+1, true
+			}
+		default:
+			break run
+		}
+		n = j + 1
+	}
+
+	trailing := group + pending
+	switch {
+	case n == 0:
+		return 0, 0, false
+	case math.IsInf(lastScale, 1):
+		// No scale: "seratus lima puluh", "1.250.000", "2"
+		return trailing, n, spelled && trailing >= 100
+	case trailing == 0.5 && !composed:
+		// "sejuta setengah" is one and a half million
+		return total + 0.5*lastScale, n, true
+	case trailing > 0 && composed && trailing < lastScale:
+		// "seribu lima ratus"
+		return total + trailing, n, true
+	}
+	// Anything after the last scale ("50rb 2 porsi") isn't part of the amount
+	return total, committed, true
+}
+
+func parseNumeralOK(s string) bool {
+	_, ok := parseNumeral(s)
+	return ok
+}
+
+// parseNumeral reads "1.250.000", "1,250,000", "1.250,50", "1,5" and "25"
+func parseNumeral(s string) (float64, bool) {
+	switch {
+	case idThousandsPattern.MatchString(s):
+		s = strings.Replace(strings.ReplaceAll(s, ".", ""), ",", ".", 1)
+	case enThousandsPattern.MatchString(s):
+		s = strings.ReplaceAll(s, ",", "")
+	case decimalPattern.MatchString(s):
+		s = strings.Replace(s, ",", ".", 1)
+	case !plainPattern.MatchString(s):
+		return 0, false
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	return value, err == nil
+}
+
+// asciiLower lowercases ASCII letters only, so byte offsets stay valid for
+// the original text
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + ('a' - 'A')
+		}
+	}
+	return string(b)
+}
+
+// NormalizeAmounts rewrites amounts as plain numbers ("Rp 1,5jt" →
+// "1500000", "goceng" → "5000") and leaves the rest of text unchanged. Bare
+// small numbers stay as written since they may be quantities or dates.
+func NormalizeAmounts(text string) string {
+	tokens := TokenizeAmounts(text)
+
+	var sb strings.Builder
+	last := 0
+	for _, t := range tokens {
+		if !t.Explicit && t.Value < 1000 {
+			continue
+		}
+		sb.WriteString(text[last:t.Start])
+		sb.WriteString(strconv.FormatFloat(t.Value, 'f', -1, 64))
+		// Keep "2rb5000" as two numbers
+		if t.End < len(text) && text[t.End] >= '0' && text[t.End] <= '9' {
+			sb.WriteString(" ")
+		}
+		last = t.End
+	}
+	sb.WriteString(text[last:])
+
+	return sb.String()
+}
+
+// ExtractAmount returns the first explicit amount in text, or the largest
+// bare number if there is none: in "beli 2 kopi 40000" the 2 is a quantity
+func ExtractAmount(text string) (float64, bool) {
+	tokens := TokenizeAmounts(text)
+	for _, t := range tokens {
+		if t.Explicit && t.Value > 0 {
+			return t.Value, true
+		}
+	}
+
+	var largest float64
+	for _, t := range tokens {
+		if t.Value > largest {
+			largest = t.Value
+		}
+	}
+	return largest, largest > 0
+}
+
+// HasExplicitAmount reports whether text contains an amount marked as money
+func HasExplicitAmount(text string) bool {
+	for _, t := range TokenizeAmounts(text) {
+		if t.Explicit && t.Value > 0 {
+			return true
+		}
+	}
+	return false
+}
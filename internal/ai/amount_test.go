@@ -0,0 +1,114 @@
+package ai
+
+import "testing"
+
+func TestTokenizeAmounts(t *testing.T) {
+	type token struct {
+		value    float64
+		text     string
+		explicit bool
+	}
+	tests := []struct {
+		text string
+		want []token
+	}{
+		{"beli kopi 20rb", []token{{20000, "20rb", true}}},
+		{"makan 25k", []token{{25000, "25k", true}}},
+		{"gaji 7,5 juta", []token{{7500000, "7,5 juta", true}}},
+		{"hp 1,5jt", []token{{1500000, "1,5jt", true}}},
+		{"tanah 2M", []token{{2e9, "2M", true}}},
+		{"rumah 1,2 M", []token{{1.2e9, "1,2 M", true}}},
+		{"Rp 20.000", []token{{20000, "Rp 20.000", true}}},
+		{"bayar 1.250.000", []token{{1250000, "1.250.000", false}}},
+		{"bayar 1,250,000", []token{{1250000, "1,250,000", false}}},
+		{"parkir goceng", []token{{5000, "goceng", true}}},
+		{"gorengan cepek", []token{{100, "cepek", true}}},
+		{"seratus lima puluh ribu", []token{{150000, "seratus lima puluh ribu", true}}},
+		{"dua setengah juta", []token{{2500000, "dua setengah juta", true}}},
+		{"sejuta setengah", []token{{1500000, "sejuta setengah", true}}},
+		{"2 juta 500 ribu", []token{{2500000, "2 juta 500 ribu", true}}},
+		{"beli 2 kopi 40000", []token{{2, "2", false}, {40000, "40000", false}}},
+		{"50rb 2 porsi", []token{{50000, "50rb", true}, {2, "2", false}}},
+		// A lowercase m is minutes or metres, not miliar
+		{"lari 2m", []token{{2, "2", false}}},
+		{"beli kain 2m 50rb", []token{{2, "2", false}, {50000, "50rb", true}}},
+		// An abbreviated scale starts a new amount
+		{"2jt 50rb", []token{{2e6, "2jt", true}, {50000, "50rb", true}}},
+		{"2M 50rb", []token{{2e9, "2M", true}, {50000, "50rb", true}}},
+		// Digits glued to an abbreviated scale are its leading digits
+		{"1jt500 bayar kos", []token{{1500000, "1jt500", true}}},
+		{"1jt250", []token{{1250000, "1jt250", true}}},
+		{"2rb5", []token{{2500, "2rb5", true}}},
+		{"1jt500 2 porsi", []token{{1500000, "1jt500", true}, {2, "2", false}}},
+		{"Rp50.000,-", []token{{50000, "Rp50.000,-", true}}},
+	}
+
+	for _, tt := range tests {
+		got := TokenizeAmounts(tt.text)
+		if len(got) != len(tt.want) {
+			t.Errorf("TokenizeAmounts(%q) = %+v, want %+v", tt.text, got, tt.want)
+			continue
+		}
+		for i, w := range tt.want {
+			g := got[i]
+			if g.Value != w.value || g.Text != w.text || g.Explicit != w.explicit {
+				t.Errorf("TokenizeAmounts(%q)[%d] = {%v %q %v}, want {%v %q %v}",
+					tt.text, i, g.Value, g.Text, g.Explicit, w.value, w.text, w.explicit)
+			}
+			if tt.text[g.Start:g.End] != g.Text {
+				t.Errorf("TokenizeAmounts(%q)[%d] offsets %d:%d don't match %q", tt.text, i, g.Start, g.End, g.Text)
+			}
+		}
+	}
+}
+
+func TestNormalizeAmounts(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"beli kopi 20rb", "beli kopi 20000"},
+		{"Rp 1,5jt buat servis", "1500000 buat servis"},
+		{"bayar Rp 20.000 tunai", "bayar 20000 tunai"},
+		{"parkir goceng", "parkir 5000"},
+		{"beli 2 kopi 40rb", "beli 2 kopi 40000"},
+		{"tanggal 5 beli 1.250.000", "tanggal 5 beli 1250000"},
+		{"beli kain 2m 50rb", "beli kain 2m 50000"},
+		{"DP rumah 1M", "DP rumah 1000000000"},
+		{"1jt500 bayar kos", "1500000 bayar kos"},
+		{"bayar Rp50.000,- tunai", "bayar 50000 tunai"},
+		{"Rp50.000,-", "50000"},
+		{"2rb5000", "2000 5000"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeAmounts(tt.text); got != tt.want {
+			t.Errorf("NormalizeAmounts(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExtractAmount(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		ok   bool
+	}{
+		{"beli kopi 20rb", 20000, true},
+		{"beli 2 kopi 40000", 40000, true},
+		{"beli 2 kopi 40rb", 40000, true},
+		{"3 bungkus rokok Rp 75.000", 75000, true},
+		{"gaji 1,5jt", 1500000, true},
+		{"1jt500 bayar kos", 1500000, true},
+		{"noban buat bensin", 20000, true},
+		{"beli kain 2m 50rb", 50000, true},
+		{"beli kopi", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ExtractAmount(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ExtractAmount(%q) = %v, %v, want %v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrCircuitOpen is returned without calling the model while the provider is
+// considered down
+var ErrCircuitOpen = errors.New("AI provider unavailable (circuit open)")
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// CircuitBreaker stops calling the model after threshold consecutive
+// failures. After cooldown one probe call is let through: success closes the
+// circuit, failure opens it for another cooldown.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, state: CircuitClosed}
+}
+
+// State returns the current state; an open circuit past its cooldown reports
+// half-open
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.cooldown {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Allow reports whether a call may go ahead, returning ErrCircuitOpen if not
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return nil
+	case CircuitHalfOpen:
+		// One probe at a time
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Record updates the circuit with the result of an allowed call. Only
+// outages count as failures; a model answer that fails validation means the
+// provider is up.
+func (b *CircuitBreaker) Record(err error) {
+	// The caller gave up, which says nothing about the provider
+	if errors.Is(err, context.Canceled) {
+		b.mu.Lock()
+		b.probing = false
+		b.mu.Unlock()
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+
+	if !IsUnavailable(err) {
+		if b.state != CircuitClosed {
+			slog.Info("AI circuit breaker closed", "breaker", CircuitClosed, "from", b.state)
+		}
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		if b.state != CircuitOpen {
+			slog.Warn("AI circuit breaker opened", "breaker", CircuitOpen, "from", b.state,
+				"failures", b.failures, "cooldown_ms", b.cooldown.Milliseconds(), "error", err)
+		}
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// IsUnavailable reports whether err means the provider could not answer:
+// timeouts, connection failures, rate limits and server errors
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return unavailableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return unavailableStatus(reqErr.HTTPStatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func unavailableStatus(code int) bool {
+	return code == 429 || code >= 500
+}
+
+// guard runs fn through the breaker
+func guard[T any](b *CircuitBreaker, fn func() (T, error)) (T, error) {
+	if err := b.Allow(); err != nil {
+		var zero T
+		return zero, err
+	}
+	result, err := fn()
+	b.Record(err)
+	return result, err
+}
+
+// BreakerParser guards a model-backed TransactionParser with a circuit breaker
+type BreakerParser struct {
+	next    TransactionParser
+	breaker *CircuitBreaker
+}
+
+func NewBreakerParser(next TransactionParser, breaker *CircuitBreaker) *BreakerParser {
+	return &BreakerParser{next: next, breaker: breaker}
+}
+
+func (p *BreakerParser) Parse(ctx context.Context, message string) ([]*domain.ParsedTransaction, error) {
+	return guard(p.breaker, func() ([]*domain.ParsedTransaction, error) {
+		return p.next.Parse(ctx, message)
+	})
+}
+
+// BreakerImageParser guards an ImageParser with a circuit breaker
+type BreakerImageParser struct {
+	next    ImageParser
+	breaker *CircuitBreaker
+}
+
+func NewBreakerImageParser(next ImageParser, breaker *CircuitBreaker) *BreakerImageParser {
+	return &BreakerImageParser{next: next, breaker: breaker}
+}
+
+func (p *BreakerImageParser) ParseImage(ctx context.Context, imageData []byte, mimeType, caption string) (*domain.ParsedTransaction, error) {
+	return guard(p.breaker, func() (*domain.ParsedTransaction, error) {
+		return p.next.ParseImage(ctx, imageData, mimeType, caption)
+	})
+}
+
+// BreakerQueryPlanner guards a QueryPlanner with a circuit breaker
+type BreakerQueryPlanner struct {
+	next    QueryPlanner
+	breaker *CircuitBreaker
+}
+
+func NewBreakerQueryPlanner(next QueryPlanner, breaker *CircuitBreaker) *BreakerQueryPlanner {
+	return &BreakerQueryPlanner{next: next, breaker: breaker}
+}
+
+func (p *BreakerQueryPlanner) PlanQuery(ctx context.Context, question string) (*domain.LedgerQuery, error) {
+	return guard(p.breaker, func() (*domain.LedgerQuery, error) {
+		return p.next.PlanQuery(ctx, question)
+	})
+}
+
+// BreakerIntentClassifier guards an IntentClassifier with a circuit breaker
+type BreakerIntentClassifier struct {
+	next    IntentClassifier
+	breaker *CircuitBreaker
+}
+
+func NewBreakerIntentClassifier(next IntentClassifier, breaker *CircuitBreaker) *BreakerIntentClassifier {
+	return &BreakerIntentClassifier{next: next, breaker: breaker}
+}
+
+func (p *BreakerIntentClassifier) ClassifyIntent(ctx context.Context, message string) (*domain.Intent, error) {
+	return guard(p.breaker, func() (*domain.Intent, error) {
+		return p.next.ClassifyIntent(ctx, message)
+	})
+}
+
+// BreakerTranscriber guards a Transcriber with a circuit breaker
+type BreakerTranscriber struct {
+	next    Transcriber
+	breaker *CircuitBreaker
+}
+
+func NewBreakerTranscriber(next Transcriber, breaker *CircuitBreaker) *BreakerTranscriber {
+	return &BreakerTranscriber{next: next, breaker: breaker}
+}
+
+func (t *BreakerTranscriber) Transcribe(ctx context.Context, audio []byte, mimeType, lang string) (string, error) {
+	return guard(t.breaker, func() (string, error) {
+		return t.next.Transcribe(ctx, audio, mimeType, lang)
+	})
+}
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"circuit open", ErrCircuitOpen, true},
+		{"deadline", fmt.Errorf("openai API error: %w", context.DeadlineExceeded), true},
+		{"rate limit", &openai.APIError{HTTPStatusCode: 429}, true},
+		{"server error", &openai.APIError{HTTPStatusCode: 503}, true},
+		{"bad gateway", &openai.RequestError{HTTPStatusCode: 502}, true},
+		{"connection refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"bad request", &openai.APIError{HTTPStatusCode: 400}, false},
+		{"invalid response", &ValidationError{Problems: []string{"amount is missing"}}, false},
+		{"cancelled", context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsUnavailable(tt.err); got != tt.want {
+			t.Errorf("%s: IsUnavailable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	outage := &openai.APIError{HTTPStatusCode: 503}
+	b := NewCircuitBreaker(2, cooldown)
+
+	// A bad answer means the provider is up and resets the count
+	b.Record(outage)
+	b.Record(&ValidationError{Problems: []string{"amount is missing"}})
+	b.Record(outage)
+	if b.State() != CircuitClosed {
+		t.Fatalf("state = %s after non-consecutive failures, want closed", b.State())
+	}
+
+	b.Record(outage)
+	if b.State() != CircuitOpen {
+		t.Fatalf("state = %s after 2 consecutive failures, want open", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow while open = %v, want ErrCircuitOpen", err)
+	}
+
+	// After the cooldown one probe goes through at a time
+	time.Sleep(cooldown)
+	if b.State() != CircuitHalfOpen {
+		t.Fatalf("state = %s after cooldown, want half_open", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe not allowed: %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second probe = %v, want ErrCircuitOpen", err)
+	}
+
+	// A failed probe reopens the circuit straight away
+	b.Record(outage)
+	if b.State() != CircuitOpen {
+		t.Fatalf("state = %s after a failed probe, want open", b.State())
+	}
+
+	time.Sleep(cooldown)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe not allowed: %v", err)
+	}
+	// A cancelled probe frees the slot without deciding anything
+	b.Record(context.Canceled)
+	if b.State() != CircuitHalfOpen {
+		t.Fatalf("state = %s after a cancelled probe, want half_open", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe after a cancelled one not allowed: %v", err)
+	}
+	b.Record(nil)
+	if b.State() != CircuitClosed {
+		t.Fatalf("state = %s after a successful probe, want closed", b.State())
+	}
+}
+
+func TestBreakerParser(t *testing.T) {
+	b := NewCircuitBreaker(1, time.Minute)
+	p := NewBreakerParser(NewFakeParser(time.UTC), b)
+
+	if _, err := p.Parse(context.Background(), "kopi 20rb"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	b.Record(context.DeadlineExceeded)
+	if _, err := p.Parse(context.Background(), "kopi 20rb"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Parse while open = %v, want ErrCircuitOpen", err)
+	}
+}
@@ -0,0 +1,73 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type categoriesKey struct{}
+
+type allowedCategories struct {
+	income  []string
+	expense []string
+}
+
+// WithCategories limits the categories the parsers may return for calls made
+// with ctx to the user's taxonomy
+func WithCategories(ctx context.Context, income, expense []string) context.Context {
+	return context.WithValue(ctx, categoriesKey{}, allowedCategories{income: income, expense: expense})
+}
+
+// categoryPrompt returns the prompt section listing the allowed categories,
+// or "" when ctx carries none
+func categoryPrompt(ctx context.Context) string {
+	allowed, ok := ctx.Value(categoriesKey{}).(allowedCategories)
+	if !ok || (len(allowed.income) == 0 && len(allowed.expense) == 0) {
+		return ""
+	}
+
+	return fmt.Sprintf(`
+
+Category must be one of these (pick the closest, use "lainnya" or "pemasukan lainnya" if nothing fits):
+- EXPENSE: %s
+- INCOME: %s`, quoteAll(allowed.expense), quoteAll(allowed.income))
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+type correctionsKey struct{}
+
+// CorrectionExample is a category the user chose for a merchant or keyword
+type CorrectionExample struct {
+	Keyword  string
+	Category string
+}
+
+// WithCorrections passes the user's past category corrections to the text
+// parser as few-shot examples
+func WithCorrections(ctx context.Context, examples []CorrectionExample) context.Context {
+	return context.WithValue(ctx, correctionsKey{}, examples)
+}
+
+// correctionPrompt returns the prompt section with the user's corrections,
+// or "" when ctx carries none
+func correctionPrompt(ctx context.Context) string {
+	examples, _ := ctx.Value(correctionsKey{}).([]CorrectionExample)
+	if len(examples) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\nThis user corrected these categories before; use the same category whenever the merchant or keyword appears:")
+	for _, e := range examples {
+		sb.WriteString(fmt.Sprintf("\n- %q → %q", e.Keyword, strings.ToLower(e.Category)))
+	}
+	return sb.String()
+}
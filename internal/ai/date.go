@@ -0,0 +1,494 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+type sentAtKey struct{}
+
+// WithSentAt sets when the message being parsed was sent, so relative dates
+// in messages parsed late (e.g. deferred during an outage) resolve correctly
+func WithSentAt(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, sentAtKey{}, t)
+}
+
+// SentAt returns the time set by WithSentAt, or now
+func SentAt(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(sentAtKey{}).(time.Time); ok {
+		return t
+	}
+	return time.Now()
+}
+
+// ErrAmbiguousDate is returned when a message mentions more than one date,
+// e.g. "kemarin kopi 20rb, hari ini bensin 50rb"
+var ErrAmbiguousDate = errors.New("message mentions more than one date")
+
+// ResolvedDate is the date a message refers to, in the timezone of the now
+// it was resolved against
+type ResolvedDate struct {
+	Time time.Time
+	// HasTime is true when the text gave a time of day ("jam 7", "tadi
+	// malam"). Without one Time is midnight, or the current time for today.
+	HasTime bool
+	// Text is the date and time expressions as written
+	Text string
+
+	spans [][2]int
+}
+
+// Strip removes the date and time expressions from text, which must be the
+// text the date was resolved from
+func (d *ResolvedDate) Strip(text string) string {
+	var sb strings.Builder
+	last := 0
+	for _, span := range d.spans {
+		sb.WriteString(text[last:span[0]])
+		sb.WriteString(" ")
+		last = span[1]
+	}
+	sb.WriteString(text[last:])
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// dateValue is what one date or time expression says
+type dateValue struct {
+	day          time.Time // midnight; zero for time-only expressions
+	hour, minute int
+	hasTime      bool
+	// part is the part of the day named, if any ("sore")
+	part string
+	// past marks "tadi malam": a time later than now means yesterday
+	past bool
+	// bare marks "5/2" without a year or "tgl", which is only a date when set
+	// off from the words around it: "beli 2/3 lusin telur" is a quantity
+	bare bool
+}
+
+type dateRule struct {
+	pattern *regexp.Regexp
+	resolve func(m []string, today time.Time) (dateValue, bool)
+}
+
+const monthNames = `januari|februari|pebruari|maret|april|mei|juni|juli|agustus|september|oktober|november|nopember|desember|` +
+	`jan|feb|mar|apr|jun|jul|agu|agt|ags|aug|sept|sep|okt|oct|nov|nop|des|dec`
+
+var (
+	months = map[string]time.Month{
+		"januari": 1, "jan": 1, "februari": 2, "pebruari": 2, "feb": 2, "maret": 3, "mar": 3,
+		"april": 4, "apr": 4, "mei": 5, "juni": 6, "jun": 6, "juli": 7, "jul": 7,
+		"agustus": 8, "agu": 8, "agt": 8, "ags": 8, "aug": 8, "september": 9, "sept": 9, "sep": 9,
+		"oktober": 10, "okt": 10, "oct": 10, "november": 11, "nopember": 11, "nov": 11, "nop": 11,
+		"desember": 12, "des": 12, "dec": 12,
+	}
+	weekdays = map[string]time.Weekday{
+		"minggu": time.Sunday, "ahad": time.Sunday, "senin": time.Monday, "selasa": time.Tuesday,
+		"rabu": time.Wednesday, "kamis": time.Thursday, "jumat": time.Friday, "jum'at": time.Friday,
+		"sabtu": time.Saturday,
+	}
+	relativeDays = map[string]int{
+		"kemarin lusa": -2, "kemarin dulu": -2, "kemaren dulu": -2,
+		"kemarin": -1, "kemaren": -1, "kmrn": -1, "kmrin": -1, "kmarin": -1, "yesterday": -1, "wingi": -1,
+		"hari ini": 0, "tadi": 0, "today": 0, "dina iki": 0, "dino iki": 0,
+		"besok": 1, "besoknya": 1, "bsk": 1, "tomorrow": 1, "sesuk": 1,
+		"lusa": 2,
+	}
+	// Hours that a part of the day stands for on its own ("kemarin sore")
+	dayPartHours = map[string]int{"subuh": 5, "pagi": 8, "siang": 12, "sore": 16, "malam": 19, "malem": 19}
+
+	dayPartPattern = regexp.MustCompile(`^\s+(subuh|pagi|siang|sore|malam|malem)\b`)
+
+	dateRules = []dateRule{
+		{regexp.MustCompile(`\b(\d{4})-(\d{1,2})-(\d{1,2})\b`), resolveISODate},
+		{regexp.MustCompile(`\b(?:(?:tgl|tanggal)\.?\s*)?(\d{1,2})(?:/(\d{1,2})(?:/(\d{4}|\d{2}))?|-(\d{1,2})-(\d{4}))\b`), resolveNumericDate},
+		{regexp.MustCompile(`\b(?:tgl|tanggal)\.?\s*(\d{1,2})(?:\s+(` + monthNames + `)(?:\s+(\d{4}))?)?(?:\s+bulan\s+(lalu|kemarin|kemaren|ini|depan))?\b`), resolveDayOfMonth},
+		{regexp.MustCompile(`\b(\d{1,2})\s+(` + monthNames + `)(?:\s+(\d{4}))?\b`), resolveDayMonth},
+		{regexp.MustCompile(`\b(awal|akhir|tengah|pertengahan)\s+(?:bulan(?:\s+(lalu|kemarin|kemaren|ini|depan))?|(` + monthNames + `)(?:\s+(\d{4}))?)\b`), resolveMonthPart},
+		{regexp.MustCompile(`\b(?:(\d{1,2}|satu|dua|tiga|empat|lima|enam|tujuh|delapan|sembilan)\s+)?(hari|minggu|seminggu)\s+(?:yang\s+)?(lalu|kemarin|kemaren|kmrn|depan)\b`), resolveDaysAgo},
+		{regexp.MustCompile(`\b(hari\s+)?(senin|selasa|rabu|kamis|jumat|jum'at|sabtu|minggu|ahad)(?:\s+(lalu|kemarin|kemaren|kmrn|ini|depan))?\b`), resolveWeekday},
+		{regexp.MustCompile(`\b(semalam|semalem)\b`), resolveLastNight},
+		{regexp.MustCompile(`\b(kemarin\s+lusa|kemarin\s+dulu|kemaren\s+dulu|kemarin|kemaren|kmrn|kmrin|kmarin|hari\s+ini|tadi|besok|besoknya|bsk|lusa|today|yesterday|tomorrow|wingi|dina\s+iki|dino\s+iki|sesuk)\b`), resolveRelativeDay},
+
+		// Times of day
+		{regexp.MustCompile(`\b(?:jam|jm|pukul|pkl)\.?\s*(\d{1,2})(?:[.:](\d{2}))?(?:\s*(subuh|pagi|siang|sore|malam|malem))?\b`), resolveClock},
+		{regexp.MustCompile(`\b(\d{1,2}):(\d{2})\b`), resolveClock},
+	}
+)
+
+type dateMatch struct {
+	start, end int
+	value      dateValue
+}
+
+// ResolveDate finds the date and time a message refers to without asking the
+// model: "kemarin", "lusa", "tadi malam", "senin lalu", "tgl 5", "5/2",
+// "awal bulan", "17 agustus", "jam 7 malam". Day and month without a year
+// ("tgl 20", "5 des") mean the latest such date that isn't in the future. A
+// bare "5/2" is only a date at the start or end of the text or next to
+// punctuation.
+// It returns nil when the text mentions no date or time.
+func ResolveDate(text string, now time.Time) (*ResolvedDate, error) {
+	lower := asciiLower(text)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var candidates []dateMatch
+	for _, rule := range dateRules {
+		for _, loc := range rule.pattern.FindAllStringSubmatchIndex(lower, -1) {
+			m := make([]string, len(loc)/2)
+			for i := range m {
+				if loc[2*i] >= 0 {
+					m[i] = lower[loc[2*i]:loc[2*i+1]]
+				}
+			}
+			if value, ok := rule.resolve(m, today); ok && (!value.bare || setOff(lower, loc[0], loc[1])) {
+				candidates = append(candidates, dateMatch{start: loc[0], end: loc[1], value: value})
+			}
+		}
+	}
+
+	// Longest expression wins where they overlap: "senin kemarin" over "kemarin"
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].start != candidates[j].start {
+			return candidates[i].start < candidates[j].start
+		}
+		return candidates[i].end > candidates[j].end
+	})
+
+	var date, clock *dateMatch
+	resolved := &ResolvedDate{}
+	end := 0
+	for i := range candidates {
+		c := &candidates[i]
+		if c.start < end {
+			continue
+		}
+		end = c.end
+
+		// "kemarin sore", "senin malam"
+		if !c.value.day.IsZero() && !c.value.hasTime {
+			if part := dayPartPattern.FindStringSubmatch(lower[c.end:]); part != nil {
+				c.value.hour, c.value.part, c.value.hasTime = dayPartHours[part[1]], part[1], true
+				c.end += len(part[0])
+				end = c.end
+			}
+		}
+
+		switch {
+		case c.value.day.IsZero():
+			if clock != nil && (clock.value.hour != c.value.hour || clock.value.minute != c.value.minute) {
+				return nil, ErrAmbiguousDate
+			}
+			clock = c
+		case date != nil && !date.value.day.Equal(c.value.day):
+			return nil, ErrAmbiguousDate
+		default:
+			if date == nil {
+				date = c
+			}
+		}
+		resolved.spans = append(resolved.spans, [2]int{c.start, c.end})
+	}
+
+	if date == nil && clock == nil {
+		return nil, nil
+	}
+
+	value := dateValue{day: today}
+	if date != nil {
+		value = date.value
+	}
+	if clock != nil {
+		// "tadi malam jam 9": the clock is more precise than the part of day
+		hour := clock.value.hour
+		if clock.value.part == "" {
+			hour = clockHour(hour, value.part)
+		}
+		value.hour, value.minute, value.hasTime = hour, clock.value.minute, true
+	}
+
+	switch {
+	case value.hasTime:
+		resolved.Time = time.Date(value.day.Year(), value.day.Month(), value.day.Day(), value.hour, value.minute, 0, 0, value.day.Location())
+		if value.past && resolved.Time.After(now) {
+			resolved.Time = resolved.Time.AddDate(0, 0, -1)
+		}
+		resolved.HasTime = true
+	case value.day.Equal(today):
+		resolved.Time = now.Truncate(time.Minute)
+	default:
+		resolved.Time = value.day
+	}
+
+	parts := make([]string, len(resolved.spans))
+	for i, span := range resolved.spans {
+		parts[i] = text[span[0]:span[1]]
+	}
+	resolved.Text = strings.Join(parts, " ")
+
+	return resolved, nil
+}
+
+// calendarDate returns midnight on the given day, or false when the day
+// doesn't exist in that month
+func calendarDate(year int, month time.Month, day int, loc *time.Location) (time.Time, bool) {
+	t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	return t, month >= 1 && month <= 12 && t.Month() == month && t.Day() == day
+}
+
+// latestDate finds the latest day-month on or before today, trying this
+// year and then the one before
+func latestDate(month time.Month, day int, today time.Time) (time.Time, bool) {
+	for year := today.Year(); year >= today.Year()-1; year-- {
+		if t, ok := calendarDate(year, month, day, today.Location()); ok && !t.After(today) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fullYear reads "2026" and "26"
+func fullYear(s string) int {
+	year, _ := strconv.Atoi(s)
+	if len(s) == 2 {
+		year += 2000
+	}
+	return year
+}
+
+// monthOffset reads "bulan lalu", "bulan ini" and "bulan depan"
+func monthOffset(s string) int {
+	switch s {
+	case "lalu", "kemarin", "kemaren":
+		return -1
+	case "depan":
+		return 1
+	}
+	return 0
+}
+
+func resolveISODate(m []string, today time.Time) (dateValue, bool) {
+	year, _ := strconv.Atoi(m[1])
+	month, _ := strconv.Atoi(m[2])
+	day, _ := strconv.Atoi(m[3])
+	t, ok := calendarDate(year, time.Month(month), day, today.Location())
+	return dateValue{day: t}, ok
+}
+
+// setOff reports whether text[start:end] begins or ends the text, or is
+// separated from the words next to it by punctuation: "5/2 beli obat",
+// "beli obat, 5/2"
+func setOff(text string, start, end int) bool {
+	before := strings.TrimRight(text[:start], " ")
+	after := strings.TrimLeft(text[end:], " ")
+	return before == "" || after == "" ||
+		strings.ContainsAny(before[len(before)-1:], ".,;:(") || strings.ContainsAny(after[:1], ".,;:)")
+}
+
+// resolveNumericDate reads day-first dates: "5/2", "5/2/26", "05-02-2026"
+func resolveNumericDate(m []string, today time.Time) (dateValue, bool) {
+	day, _ := strconv.Atoi(m[1])
+	monthText, yearText := m[2], m[3]
+	if m[4] != "" {
+		monthText, yearText = m[4], m[5]
+	}
+	month, _ := strconv.Atoi(monthText)
+
+	if yearText == "" {
+		t, ok := latestDate(time.Month(month), day, today)
+		// Unless it follows "tgl" or "tanggal"
+		return dateValue{day: t, bare: !strings.HasPrefix(m[0], "t")}, ok
+	}
+	t, ok := calendarDate(fullYear(yearText), time.Month(month), day, today.Location())
+	return dateValue{day: t}, ok
+}
+
+// resolveDayOfMonth reads "tgl 5", "tgl 5 maret 2026" and "tgl 25 bulan lalu"
+func resolveDayOfMonth(m []string, today time.Time) (dateValue, bool) {
+	day, _ := strconv.Atoi(m[1])
+	switch {
+	case m[2] != "" && m[3] != "":
+		t, ok := calendarDate(fullYear(m[3]), months[m[2]], day, today.Location())
+		return dateValue{day: t}, ok
+	case m[2] != "":
+		t, ok := latestDate(months[m[2]], day, today)
+		return dateValue{day: t}, ok
+	case m[4] != "":
+		first := today.AddDate(0, monthOffset(m[4]), 1-today.Day())
+		t, ok := calendarDate(first.Year(), first.Month(), day, today.Location())
+		return dateValue{day: t}, ok
+	}
+
+	// This month, or the latest month before it with that day: "tgl 31" on
+	// 15 March is 31 January. Of any two months in a row one has a 31st.
+	for offset := 0; offset >= -2; offset-- {
+		first := today.AddDate(0, offset, 1-today.Day())
+		if t, ok := calendarDate(first.Year(), first.Month(), day, today.Location()); ok && !t.After(today) {
+			return dateValue{day: t}, true
+		}
+	}
+	return dateValue{}, false
+}
+
+// resolveDayMonth reads "17 agustus" and "5 des 2025"
+func resolveDayMonth(m []string, today time.Time) (dateValue, bool) {
+	day, _ := strconv.Atoi(m[1])
+	if m[3] != "" {
+		t, ok := calendarDate(fullYear(m[3]), months[m[2]], day, today.Location())
+		return dateValue{day: t}, ok
+	}
+	t, ok := latestDate(months[m[2]], day, today)
+	return dateValue{day: t}, ok
+}
+
+// resolveMonthPart reads "awal bulan", "akhir bulan lalu" and "pertengahan maret"
+func resolveMonthPart(m []string, today time.Time) (dateValue, bool) {
+	var first time.Time
+	switch {
+	case m[3] != "" && m[4] != "":
+		first = time.Date(fullYear(m[4]), months[m[3]], 1, 0, 0, 0, 0, today.Location())
+	case m[3] != "":
+		first = time.Date(today.Year(), months[m[3]], 1, 0, 0, 0, 0, today.Location())
+		if first.After(today) {
+			first = first.AddDate(-1, 0, 0)
+		}
+	default:
+		first = today.AddDate(0, monthOffset(m[2]), 1-today.Day())
+	}
+
+	switch m[1] {
+	case "awal":
+		return dateValue{day: first}, true
+	case "akhir":
+		last := first.AddDate(0, 1, -1)
+		// Today is the latest "akhir bulan" there is so far
+		if m[2] == "" && m[3] == "" && last.After(today) {
+			last = today
+		}
+		return dateValue{day: last}, true
+	}
+	return dateValue{day: first.AddDate(0, 0, 14)}, true
+}
+
+// resolveDaysAgo reads "3 hari lalu", "dua hari yang lalu", "seminggu lalu"
+// and "2 minggu lalu"
+func resolveDaysAgo(m []string, today time.Time) (dateValue, bool) {
+	count := 1
+	if m[1] != "" {
+		if d, ok := amountDigits[m[1]]; ok {
+			count = int(d)
+		} else {
+			count, _ = strconv.Atoi(m[1])
+		}
+	}
+
+	var days int
+	switch {
+	case m[2] == "hari" && m[1] != "":
+		days = count
+	case m[2] == "minggu":
+		days = count * 7
+	case m[2] == "seminggu" && m[1] == "":
+		days = 7
+	default:
+		// "hari lalu", "2 seminggu lalu"
+		return dateValue{}, false
+	}
+	if m[3] != "depan" {
+		days = -days
+	}
+	return dateValue{day: today.AddDate(0, 0, days)}, true
+}
+
+// resolveWeekday reads "senin", "senin lalu", "jumat kemarin" and "rabu depan".
+// A bare day name is the latest such day up to today; "lalu" skips today.
+func resolveWeekday(m []string, today time.Time) (dateValue, bool) {
+	hari, name, modifier := m[1] != "", m[2], m[3]
+	// "minggu" is also a week: "2 minggu", "minggu ini". Only "hari minggu"
+	// is Sunday; with lalu or depan it's a week, read by resolveDaysAgo.
+	if name == "minggu" && !hari {
+		return dateValue{}, false
+	}
+
+	back := (int(today.Weekday()) - int(weekdays[name]) + 7) % 7
+	switch modifier {
+	case "lalu", "kemarin", "kemaren", "kmrn":
+		if back == 0 {
+			back = 7
+		}
+	case "depan":
+		return dateValue{day: today.AddDate(0, 0, 7-back)}, true
+	}
+	return dateValue{day: today.AddDate(0, 0, -back)}, true
+}
+
+func resolveLastNight(m []string, today time.Time) (dateValue, bool) {
+	return dateValue{day: today.AddDate(0, 0, -1), hour: 20, hasTime: true}, true
+}
+
+func resolveRelativeDay(m []string, today time.Time) (dateValue, bool) {
+	word := strings.Join(strings.Fields(m[1]), " ")
+	return dateValue{day: today.AddDate(0, 0, relativeDays[word]), past: word == "tadi"}, true
+}
+
+// resolveClock reads "jam 7", "jam 19.30", "pukul 7 malam" and "19:30"
+func resolveClock(m []string, today time.Time) (dateValue, bool) {
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	part := ""
+	if len(m) > 3 {
+		part = m[3]
+	}
+	hour = clockHour(hour, part)
+	if hour > 23 || minute > 59 {
+		return dateValue{}, false
+	}
+	return dateValue{hour: hour, minute: minute, part: part, hasTime: true}, true
+}
+
+// clockHour converts a 12-hour clock with a part of the day to 24 hours:
+// "jam 1 siang" is 13:00, "jam 7 malam" 19:00
+func clockHour(hour int, part string) int {
+	switch part {
+	case "siang":
+		if hour < 6 {
+			return hour + 12
+		}
+	case "sore":
+		if hour < 12 {
+			return hour + 12
+		}
+	case "malam", "malem":
+		if hour == 12 {
+			return 0
+		}
+		if hour >= 6 && hour < 12 {
+			return hour + 12
+		}
+	}
+	return hour
+}
+
+// applyResolvedDate gives every transaction the date resolved from the
+// message. Without one, transactions the model dated today get the current
+// time instead of midnight.
+func applyResolvedDate(parsed []*domain.ParsedTransaction, resolved *ResolvedDate, now time.Time) {
+	for _, pt := range parsed {
+		switch {
+		case resolved != nil:
+			pt.Date = resolved.Time
+		case pt.Date.Year() == now.Year() && pt.Date.YearDay() == now.YearDay():
+			pt.Date = now.Truncate(time.Minute)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package ai
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolveDate(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+	// Wednesday
+	now := time.Date(2026, 3, 18, 14, 5, 30, 0, loc)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 0, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		text     string
+		want     time.Time
+		hasTime  bool
+		stripped string
+	}{
+		{"kemarin beli kopi 20rb", day(3, 17), false, "beli kopi 20rb"},
+		{"kmrn parkir 5rb", day(3, 17), false, "parkir 5rb"},
+		{"lusa bayar arisan", day(3, 20), false, "bayar arisan"},
+		{"hari ini makan siang", now.Truncate(time.Minute), false, "makan siang"},
+		{"3 hari lalu isi bensin", day(3, 15), false, "isi bensin"},
+		{"dua hari yang lalu servis motor", day(3, 16), false, "servis motor"},
+		{"seminggu lalu beli sepatu", day(3, 11), false, "beli sepatu"},
+		{"minggu lalu beli sepatu", day(3, 11), false, "beli sepatu"},
+		{"2 minggu lalu beli sepatu", day(3, 4), false, "beli sepatu"},
+		{"beli sepatu dua minggu yang lalu", day(3, 4), false, "beli sepatu"},
+		{"senin beli buku", day(3, 16), false, "beli buku"},
+		{"senin lalu beli buku", day(3, 16), false, "beli buku"},
+		{"rabu lalu beli buku", day(3, 11), false, "beli buku"},
+		{"hari minggu beli bunga", day(3, 15), false, "beli bunga"},
+		{"2 minggu kmrn beli sepatu", day(3, 4), false, "beli sepatu"},
+		{"tgl 10 bayar listrik", day(3, 10), false, "bayar listrik"},
+		{"tgl 31 bayar listrik", day(1, 31), false, "bayar listrik"},
+		{"tgl 25 bulan lalu bayar kos", day(2, 25), false, "bayar kos"},
+		{"5/2 beli obat", day(2, 5), false, "beli obat"},
+		{"beli obat 5/2", day(2, 5), false, "beli obat"},
+		{"tgl 2/3 beli 2 lusin telur", day(3, 2), false, "beli 2 lusin telur"},
+		{"beli 2/3/2026 telur", day(3, 2), false, "beli telur"},
+		{"17 agustus beli bendera", time.Date(2025, 8, 17, 0, 0, 0, 0, loc), false, "beli bendera"},
+		{"makan jam 19.30", time.Date(2026, 3, 18, 19, 30, 0, 0, loc), true, "makan"},
+		{"kemarin sore beli gorengan", time.Date(2026, 3, 17, 16, 0, 0, 0, loc), true, "beli gorengan"},
+		{"tadi malam makan sate", time.Date(2026, 3, 17, 19, 0, 0, 0, loc), true, "makan sate"},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolveDate(tt.text, now)
+		if err != nil {
+			t.Errorf("ResolveDate(%q): %v", tt.text, err)
+			continue
+		}
+		if got == nil {
+			t.Errorf("ResolveDate(%q) = nil, want %v", tt.text, tt.want)
+			continue
+		}
+		if !got.Time.Equal(tt.want) || got.HasTime != tt.hasTime {
+			t.Errorf("ResolveDate(%q) = %v (time %v), want %v (time %v)", tt.text, got.Time, got.HasTime, tt.want, tt.hasTime)
+		}
+		if s := got.Strip(tt.text); s != tt.stripped {
+			t.Errorf("ResolveDate(%q).Strip = %q, want %q", tt.text, s, tt.stripped)
+		}
+	}
+}
+
+func TestResolveDateDayOfMonthRollsBack(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+
+	tests := []struct {
+		now  time.Time
+		text string
+		want time.Time
+	}{
+		// April has no 31st
+		{time.Date(2026, 5, 15, 9, 0, 0, 0, loc), "tgl 31", time.Date(2026, 3, 31, 0, 0, 0, 0, loc)},
+		{time.Date(2026, 3, 1, 9, 0, 0, 0, loc), "tgl 30", time.Date(2026, 1, 30, 0, 0, 0, 0, loc)},
+		{time.Date(2026, 1, 10, 9, 0, 0, 0, loc), "tgl 31", time.Date(2025, 12, 31, 0, 0, 0, 0, loc)},
+		// Today keeps the time of day
+		{time.Date(2026, 1, 31, 9, 0, 0, 0, loc), "tgl 31", time.Date(2026, 1, 31, 9, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolveDate(tt.text, tt.now)
+		if err != nil || got == nil {
+			t.Errorf("ResolveDate(%q, %v) = %v, %v", tt.text, tt.now, got, err)
+			continue
+		}
+		if !got.Time.Equal(tt.want) {
+			t.Errorf("ResolveDate(%q, %v) = %v, want %v", tt.text, tt.now, got.Time, tt.want)
+		}
+	}
+}
+
+func TestResolveDateNone(t *testing.T) {
+	now := time.Date(2026, 3, 18, 14, 0, 0, 0, time.UTC)
+
+	for _, text := range []string{"beli kopi 20rb", "beli 2 kopi", "hari lalu", "beli 2/3 lusin telur",
+		// "minggu" on its own is a week, not Sunday
+		"bayar laundry 2 minggu 50rb", "langganan minggu ini 50rb", "sewa motor per minggu 300rb"} {
+		got, err := ResolveDate(text, now)
+		if err != nil || got != nil {
+			t.Errorf("ResolveDate(%q) = %v, %v, want nil", text, got, err)
+		}
+	}
+
+	if _, err := ResolveDate("kemarin kopi 20rb, hari ini bensin 50rb", now); !errors.Is(err, ErrAmbiguousDate) {
+		t.Errorf("ResolveDate with two dates: err = %v, want ErrAmbiguousDate", err)
+	}
+}
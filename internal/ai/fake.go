@@ -0,0 +1,156 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// FakeParser is a deterministic TransactionParser and ImageParser that never
+// calls a model, so the webhook flow can run offline and in local setups.
+// The same input always gives the same result.
+type FakeParser struct {
+	timezone *time.Location
+}
+
+func NewFakeParser(timezone *time.Location) *FakeParser {
+	return &FakeParser{timezone: timezone}
+}
+
+var (
+	fakeSplitPattern = regexp.MustCompile(`[,;\n]|\s+dan\s+`)
+	fakeIncomeWords  = []string{"gaji", "dapat", "terima", "pemasukan", "jual", "transferan", "bonus"}
+	fakeCategories   = map[string]string{
+		"gaji": "gaji", "bonus": "gaji",
+		"makan": "makan", "kopi": "makan", "minum": "makan", "jajan": "makan",
+		"bensin": "transport", "ojek": "transport", "parkir": "transport", "tol": "transport",
+		"belanja": "belanja",
+		"listrik": "tagihan", "pulsa": "tagihan", "internet": "tagihan",
+		"jual": "penjualan",
+	}
+)
+
+// Parse returns one transaction per comma-, semicolon-, newline- or
+// "dan"-separated part that contains an amount
+func (p *FakeParser) Parse(ctx context.Context, message string) ([]*domain.ParsedTransaction, error) {
+	now := SentAt(ctx).In(p.timezone)
+	resolved, _ := ResolveDate(message, now)
+	text := message
+	if resolved != nil {
+		text = resolved.Strip(message)
+	}
+
+	var parsed []*domain.ParsedTransaction
+	for _, part := range fakeSplitPattern.Split(NormalizeAmounts(strings.ToLower(text)), -1) {
+		part = strings.TrimSpace(part)
+		amount, ok := ExtractAmount(part)
+		if !ok {
+			continue
+		}
+		parsed = append(parsed, p.fakeTransaction(now, part, amount, 0.9))
+	}
+
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("no transaction in message")
+	}
+
+	applyResolvedDate(parsed, resolved, now)
+
+	return parsed, nil
+}
+
+// ParseImage reads the amount from the caption; without one it returns a
+// low-confidence result, like a model would for an unreadable image
+func (p *FakeParser) ParseImage(ctx context.Context, imageData []byte, mimeType, caption string) (*domain.ParsedTransaction, error) {
+	now := SentAt(ctx).In(p.timezone)
+	caption = strings.ToLower(strings.TrimSpace(caption))
+	if amount, ok := ExtractAmount(caption); ok {
+		return p.fakeTransaction(now, caption, amount, 0.85), nil
+	}
+
+	return p.fakeTransaction(now, "struk", 0, 0.3), nil
+}
+
+// fakeTransaction dates the transaction on the day the message was sent, as
+// the model parsers do
+func (p *FakeParser) fakeTransaction(now time.Time, text string, amount, confidence float64) *domain.ParsedTransaction {
+	txType := domain.TypeExpense
+	for _, word := range fakeIncomeWords {
+		if strings.Contains(text, word) {
+			txType = domain.TypeIncome
+			break
+		}
+	}
+
+	category := "lainnya"
+	for _, word := range strings.Fields(text) {
+		if c, ok := fakeCategories[word]; ok {
+			category = c
+			break
+		}
+	}
+
+	return &domain.ParsedTransaction{
+		Type:        txType,
+		Amount:      amount,
+		Category:    category,
+		Description: strings.TrimSpace(strings.TrimPrefix(text, "catat")),
+		Date:        time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, p.timezone),
+		Confidence:  confidence,
+	}
+}
+
+// PlanQuery answers every question about this month's expenses, picking the
+// aggregation and period from a few keywords
+func (p *FakeParser) PlanQuery(ctx context.Context, question string) (*domain.LedgerQuery, error) {
+	question = strings.ToLower(question)
+	now := SentAt(ctx).In(p.timezone)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, p.timezone)
+
+	plan := &domain.LedgerQuery{
+		Type:        domain.TypeExpense,
+		Start:       today.AddDate(0, 0, 1-today.Day()),
+		End:         today.AddDate(0, 0, 1),
+		Aggregation: domain.AggregateSum,
+	}
+
+	switch {
+	case strings.Contains(question, "hari ini"):
+		plan.Start = today
+	case strings.Contains(question, "minggu ini"):
+		plan.Start = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	case strings.Contains(question, "bulan lalu"):
+		plan.End = plan.Start
+		plan.Start = plan.Start.AddDate(0, -1, 0)
+	}
+
+	switch {
+	case strings.Contains(question, "terbesar") || strings.Contains(question, "paling mahal"):
+		plan.Aggregation, plan.Limit = domain.AggregateMax, 1
+	case strings.Contains(question, "kategori"):
+		plan.Aggregation, plan.Limit = domain.AggregateTop, 3
+	case strings.Contains(question, "berapa kali"):
+		plan.Aggregation = domain.AggregateCount
+	case strings.Contains(question, "rata"):
+		plan.Aggregation = domain.AggregateAvg
+	}
+
+	if strings.Contains(question, "pemasukan") || strings.Contains(question, "gaji") {
+		plan.Type = domain.TypeIncome
+	}
+
+	return plan, nil
+}
+
+// ClassifyIntent treats anything with an amount as a transaction and
+// everything else as a request for help
+func (p *FakeParser) ClassifyIntent(ctx context.Context, message string) (*domain.Intent, error) {
+	if _, ok := ExtractAmount(message); ok {
+		return &domain.Intent{Kind: domain.IntentRecord, Confidence: 0.6}, nil
+	}
+	return &domain.Intent{Kind: domain.IntentHelp, Confidence: 0.6}, nil
+}
@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+func TestFakeParserUsesSentAt(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+	p := NewFakeParser(loc)
+	sentAt := time.Date(2026, 1, 15, 20, 30, 0, 0, loc)
+	ctx := WithSentAt(context.Background(), sentAt)
+
+	// Without a date the transaction is dated when the message was sent
+	tests := []struct {
+		message string
+		want    time.Time
+	}{
+		{"beli kopi 20rb", sentAt},
+		{"kemarin beli bensin 50rb", time.Date(2026, 1, 14, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		parsed, err := p.Parse(ctx, tt.message)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.message, err)
+		}
+		if len(parsed) != 1 {
+			t.Fatalf("Parse(%q) returned %d transactions", tt.message, len(parsed))
+		}
+		if got := parsed[0].Date; !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) date = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+
+	img, err := p.ParseImage(ctx, nil, "image/jpeg", "struk belanja 120rb")
+	if err != nil {
+		t.Fatalf("ParseImage: %v", err)
+	}
+	if img.Date.YearDay() != sentAt.YearDay() {
+		t.Errorf("ParseImage date = %v, want day of %v", img.Date, sentAt)
+	}
+	if img.Type != domain.TypeExpense || img.Amount != 120000 {
+		t.Errorf("ParseImage = %s %.0f, want EXPENSE 120000", img.Type, img.Amount)
+	}
+}
+
+func TestFakeParserPlanQueryUsesSentAt(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+	p := NewFakeParser(loc)
+	ctx := WithSentAt(context.Background(), time.Date(2026, 3, 10, 9, 0, 0, 0, loc))
+
+	plan, err := p.PlanQuery(ctx, "berapa total jajan bulan lalu?")
+	if err != nil {
+		t.Fatalf("PlanQuery: %v", err)
+	}
+	if want := time.Date(2026, 2, 1, 0, 0, 0, 0, loc); !plan.Start.Equal(want) {
+		t.Errorf("start = %v, want %v", plan.Start, want)
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, loc); !plan.End.Equal(want) {
+		t.Errorf("end = %v, want %v", plan.End, want)
+	}
+}
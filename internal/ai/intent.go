@@ -0,0 +1,166 @@
+package ai
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// IntentClassifier classifies messages the deterministic rules can't place
+type IntentClassifier interface {
+	ClassifyIntent(ctx context.Context, message string) (*domain.Intent, error)
+}
+
+// minIntentConfidence is the lowest model confidence acted on; vaguer
+// messages get the help text
+const minIntentConfidence = 0.5
+
+var (
+	intentTxIDPattern = regexp.MustCompile(`(?i)tx#[a-z0-9_-]+`)
+
+	intentUndoWords      = map[string]bool{"undo": true, "batal": true, "batalkan": true}
+	intentHelpWords      = map[string]bool{"help": true, "bantuan": true, "menu": true, "tolong": true, "info": true, "?": true}
+	intentSmalltalkWords = map[string]bool{
+		"halo": true, "hallo": true, "hai": true, "hi": true, "hello": true, "pagi": true, "siang": true,
+		"sore": true, "malam": true, "makasih": true, "terima kasih": true, "thanks": true, "thx": true,
+		"ok": true, "oke": true, "okay": true, "sip": true, "mantap": true, "assalamualaikum": true,
+	}
+
+	intentItemsPattern    = regexp.MustCompile(`^(rincian|details?)\b`)
+	intentLanguagePattern = regexp.MustCompile(`^((ganti|ubah|set|change|switch)\s+)?(bahasa|basa|language|lang)\b`)
+	intentSettingsPattern = regexp.MustCompile(`^(daftar )?kategori$|^(categories|category)$|^(tambah|buat|ubah|ganti|rename|gabung|gabungkan|hapus) kategori\b|^(add|rename|merge|delete) category\b`)
+	intentEditPattern     = regexp.MustCompile(`^(edit|ubah|koreksi|change)\b`)
+	intentDeletePattern   = regexp.MustCompile(`^(hapus|delete|del)\b`)
+	intentRecordPattern   = regexp.MustCompile(`^(catat|simpan|record)\b`)
+	intentReportPattern   = regexp.MustCompile(`\b(rekap|laporan|ringkasan|report|summary|recap)\b`)
+	// Reports only cover the current day, week or month
+	intentPastPeriodPattern = regexp.MustCompile(`\b(minggu|bulan|tahun|wulan|sasi)\s+(lalu|kemarin|kemaren|kmrn|wingi|kepungkur)\b|` +
+		`\blast\s+(week|month|year)\b|\b(kemarin|kemaren|kmrn|yesterday|wingi)\b`)
+	// intentAskPattern is intentQuestionPattern without words that also
+	// describe transactions ("total belanja 150rb")
+	intentAskPattern      = regexp.MustCompile(`\b(berapa|brp|apa|apakah|kapan|mana|how much|how many|what|which|pira|piro)\b|\?`)
+	intentQuestionPattern = regexp.MustCompile(`\b(berapa|brp|apa|apakah|kapan|mana|top|total|rata-rata|rata2|how much|how many|what|which|pira|piro)\b|\?`)
+	intentLedgerPattern   = regexp.MustCompile(`\b(berapa|brp|total|pengeluaran|pemasukan|habis|keluar|jajan|belanja|terbesar|terkecil|paling|kategori|rata-rata|rata2|kali|boros|gaji|spent|spend|spending|expenses?|income|salary|biggest|average)\b`)
+)
+
+// IntentRouter classifies chat messages: deterministic commands first, the
+// fallback classifier for everything else
+type IntentRouter struct {
+	fallback IntentClassifier
+}
+
+// NewIntentRouter creates a router; fallback may be nil to use rules only
+func NewIntentRouter(fallback IntentClassifier) *IntentRouter {
+	return &IntentRouter{fallback: fallback}
+}
+
+// Route always returns an intent; when nothing fits it is IntentHelp
+func (r *IntentRouter) Route(ctx context.Context, message string) *domain.Intent {
+	if intent := RouteRules(message); intent != nil {
+		return intent
+	}
+
+	if r.fallback != nil {
+		intent, err := r.fallback.ClassifyIntent(ctx, message)
+		if err != nil {
+			log.Printf("Intent classification failed: %v", err)
+		} else if intent.Confidence >= minIntentConfidence {
+			intent.Source = domain.IntentSourceModel
+			// IDs are copied from the text, never trusted from the model
+			intent.TxID = extractIntentTxID(message)
+			return intent
+		}
+	}
+
+	return &domain.Intent{Kind: domain.IntentHelp, Confidence: 1, Source: domain.IntentSourceRule}
+}
+
+// RouteRules classifies a message with the deterministic rules only, or
+// returns nil when none apply
+func RouteRules(message string) *domain.Intent {
+	text := strings.ToLower(strings.TrimSpace(message))
+	intent := func(kind string) *domain.Intent {
+		return &domain.Intent{Kind: kind, Confidence: 1, Source: domain.IntentSourceRule}
+	}
+
+	switch {
+	case text == "" || intentHelpWords[text]:
+		return intent(domain.IntentHelp)
+	case intentUndoWords[text]:
+		return intent(domain.IntentUndo)
+	case intentItemsPattern.MatchString(text):
+		i := intent(domain.IntentReport)
+		i.Period = domain.PeriodItems
+		i.TxID = extractIntentTxID(message)
+		return i
+	case intentLanguagePattern.MatchString(text):
+		i := intent(domain.IntentSettings)
+		i.Setting = domain.SettingLanguage
+		return i
+	case intentSettingsPattern.MatchString(text):
+		i := intent(domain.IntentSettings)
+		i.Setting = domain.SettingCategory
+		return i
+	case intentEditPattern.MatchString(text):
+		i := intent(domain.IntentEdit)
+		i.TxID = extractIntentTxID(message)
+		return i
+	case intentDeletePattern.MatchString(text):
+		i := intent(domain.IntentDelete)
+		i.TxID = extractIntentTxID(message)
+		return i
+	case intentRecordPattern.MatchString(text):
+		return intent(domain.IntentRecord)
+	case intentAskPattern.MatchString(text) && intentLedgerPattern.MatchString(text):
+		// Before the amount check: "berapa kali jajan di atas 50rb?" is a question
+		return intent(domain.IntentQuery)
+	case HasExplicitAmount(text):
+		// An amount marked as money means a transaction: "rekap kantor 50rb"
+		// is not a report
+		return intent(domain.IntentRecord)
+	case intentReportPattern.MatchString(text) && intentPastPeriodPattern.MatchString(text):
+		// "rekap bulan lalu" is answered like "berapa pengeluaran bulan lalu?"
+		return intent(domain.IntentQuery)
+	case intentReportPattern.MatchString(text):
+		i := intent(domain.IntentReport)
+		i.Period = reportPeriod(text)
+		return i
+	case intentQuestionPattern.MatchString(text) && intentLedgerPattern.MatchString(text):
+		return intent(domain.IntentQuery)
+	case ShouldTriggerParsing(text):
+		return intent(domain.IntentRecord)
+	case intentSmalltalkWords[strings.Trim(text, "!. ")]:
+		return intent(domain.IntentSmalltalk)
+	}
+
+	return nil
+}
+
+// reportPeriod reads the current period of "rekap minggu ini"; today by
+// default. Past periods are routed to IntentQuery before this is reached.
+func reportPeriod(text string) string {
+	switch {
+	case strings.Contains(text, "hari ini") || strings.Contains(text, "harian") ||
+		strings.Contains(text, "today") || strings.Contains(text, "dina iki") || strings.Contains(text, "dino iki"):
+		return domain.PeriodToday
+	case strings.Contains(text, "minggu") || strings.Contains(text, "week"):
+		return domain.PeriodWeek
+	case strings.Contains(text, "bulan") || strings.Contains(text, "month") ||
+		strings.Contains(text, "wulan") || strings.Contains(text, "sasi"):
+		return domain.PeriodMonth
+	}
+	return domain.PeriodToday
+}
+
+// extractIntentTxID finds a TX ID in the raw message text. TX IDs keep the WA
+// message ID casing, so only the "TX#" prefix is normalised.
+func extractIntentTxID(text string) string {
+	txID := intentTxIDPattern.FindString(text)
+	if txID == "" {
+		return ""
+	}
+	return "TX#" + txID[3:]
+}
@@ -0,0 +1,121 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+var intentKinds = map[string]bool{
+	domain.IntentRecord: true, domain.IntentReport: true, domain.IntentQuery: true,
+	domain.IntentUndo: true, domain.IntentEdit: true, domain.IntentDelete: true,
+	domain.IntentSettings: true, domain.IntentHelp: true, domain.IntentSmalltalk: true,
+}
+
+var intentSettings = map[string]bool{domain.SettingCategory: true, domain.SettingLanguage: true}
+
+var reportPeriods = map[string]bool{
+	"": true, domain.PeriodToday: true, domain.PeriodWeek: true, domain.PeriodMonth: true, domain.PeriodItems: true,
+}
+
+// ModelIntentClassifier asks the model what a free-form message wants
+type ModelIntentClassifier struct {
+	client *openai.Client
+	model  string
+	usage  UsageStore
+}
+
+// NewIntentClassifier creates a classifier; usage may be nil to skip call accounting
+func NewIntentClassifier(apiKey, baseURL, model string, usage UsageStore) *ModelIntentClassifier {
+	return &ModelIntentClassifier{
+		client: NewOpenAIClient(apiKey, baseURL),
+		model:  model,
+		usage:  usage,
+	}
+}
+
+const intentPrompt = `You classify WhatsApp messages sent to an Indonesian personal finance bot.
+Messages may be in Indonesian, English or Javanese.
+
+Intents:
+- "record": the user reports money spent or received ("tadi makan siang sama temen habis 45 ribu")
+- "report": the user wants a summary for a period ("aku mau lihat rekap belanja"); period "today", "week" or "month", always the current one
+- "query": a specific question about past transactions ("kemarin aku habis berapa buat bensin?"), including summaries of an earlier period ("rekap bulan lalu")
+- "undo": cancel what was just recorded
+- "edit": change an existing transaction
+- "delete": remove an existing transaction
+- "settings": manage categories ("category") or change the reply language ("language")
+- "help": asks what the bot can do or how to use it
+- "smalltalk": greetings, thanks, chit-chat unrelated to finances
+
+Return ONLY valid JSON in this exact format:
+{"intent": "record", "period": "" or "today" | "week" | "month", "setting": "" or "category" | "language", "confidence": 0.0-1.0}
+
+Use confidence below 0.5 when unsure.`
+
+// ClassifyIntent returns the intent of message. Only the kind, period,
+// setting and confidence come from the model.
+func (c *ModelIntentClassifier) ClassifyIntent(ctx context.Context, message string) (*domain.Intent, error) {
+	call := startCall(ctx, domain.AICallIntent, c.model)
+	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: c.model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: intentPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: message},
+		},
+		Temperature:    0,
+		ResponseFormat: responseFormat(c.model, "intent", intentSchema),
+	})
+
+	if err != nil {
+		finishCall(c.usage, call, resp.Usage, requestOutcome(err), err)
+		return nil, fmt.Errorf("openai API error: %w", err)
+	}
+
+	intent, err := decodeIntent(resp)
+	finishCall(c.usage, call, resp.Usage, responseOutcome(err), err)
+	return intent, err
+}
+
+func decodeIntent(resp openai.ChatCompletionResponse) (*domain.Intent, error) {
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from AI")
+	}
+
+	var raw struct {
+		Intent     string  `json:"intent"`
+		Period     string  `json:"period"`
+		Setting    string  `json:"setting"`
+		Confidence float64 `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &raw); err != nil {
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("response is not valid JSON (%v)", err)}}
+	}
+
+	kind := strings.ToLower(strings.TrimSpace(raw.Intent))
+	if !intentKinds[kind] {
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("intent %q is not a known intent", raw.Intent)}}
+	}
+	period := strings.ToLower(strings.TrimSpace(raw.Period))
+	if !reportPeriods[period] || period == domain.PeriodItems {
+		period = ""
+	}
+	if kind == domain.IntentReport && period == "" {
+		period = domain.PeriodToday
+	}
+
+	setting := ""
+	if kind == domain.IntentSettings {
+		setting = strings.ToLower(strings.TrimSpace(raw.Setting))
+		// Settings without a known one would guess; show the help instead
+		if !intentSettings[setting] {
+			kind, setting = domain.IntentHelp, ""
+		}
+	}
+
+	return &domain.Intent{Kind: kind, Period: period, Setting: setting, Confidence: normalizeConfidence(raw.Confidence)}, nil
+}
@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+func TestRouteRules(t *testing.T) {
+	tests := []struct {
+		message string
+		kind    string
+		setting string
+		period  string
+	}{
+		{"", domain.IntentHelp, "", ""},
+		{"bantuan", domain.IntentHelp, "", ""},
+		{"undo", domain.IntentUndo, "", ""},
+		{"batal", domain.IntentUndo, "", ""},
+		{"rincian TX#3EB0ABC", domain.IntentReport, "", domain.PeriodItems},
+
+		{"bahasa inggris", domain.IntentSettings, domain.SettingLanguage, ""},
+		{"basa jawa", domain.IntentSettings, domain.SettingLanguage, ""},
+		{"language english", domain.IntentSettings, domain.SettingLanguage, ""},
+		{"ganti bahasa english", domain.IntentSettings, domain.SettingLanguage, ""},
+		{"Ubah Bahasa", domain.IntentSettings, domain.SettingLanguage, ""},
+		{"ganti bahasa ke jawa", domain.IntentSettings, domain.SettingLanguage, ""},
+		{"set language english", domain.IntentSettings, domain.SettingLanguage, ""},
+		{"change language to javanese", domain.IntentSettings, domain.SettingLanguage, ""},
+
+		{"kategori", domain.IntentSettings, domain.SettingCategory, ""},
+		{"ubah kategori Makan jadi Kuliner", domain.IntentSettings, domain.SettingCategory, ""},
+		{"add category Pets", domain.IntentSettings, domain.SettingCategory, ""},
+		// Not a transaction to delete
+		{"hapus kategori kopi", domain.IntentSettings, domain.SettingCategory, ""},
+
+		{"edit TX#3EB0ABC 25rb", domain.IntentEdit, "", ""},
+		{"ubah yang terakhir jadi 30rb", domain.IntentEdit, "", ""},
+		{"change last to 30k", domain.IntentEdit, "", ""},
+		{"hapus TX#3EB0ABC", domain.IntentDelete, "", ""},
+
+		{"beli kopi 20rb", domain.IntentRecord, "", ""},
+		{"catat rekap kantor 50rb", domain.IntentRecord, "", ""},
+		{"rekap", domain.IntentReport, "", domain.PeriodToday},
+		{"rekap minggu ini", domain.IntentReport, "", domain.PeriodWeek},
+		{"laporan bulan ini", domain.IntentReport, "", domain.PeriodMonth},
+		{"weekly summary", domain.IntentReport, "", domain.PeriodWeek},
+		// Reports only cover the current period; earlier ones are questions
+		{"rekap minggu lalu", domain.IntentQuery, "", ""},
+		{"rekap bulan lalu", domain.IntentQuery, "", ""},
+		{"laporan kemarin", domain.IntentQuery, "", ""},
+		{"summary last month", domain.IntentQuery, "", ""},
+		{"rekap sasi wingi", domain.IntentQuery, "", ""},
+		{"berapa total jajan bulan ini?", domain.IntentQuery, "", ""},
+		// Amounts in questions are filters, not transactions
+		{"berapa kali jajan di atas 50rb?", domain.IntentQuery, "", ""},
+		{"pengeluaran yang lebih dari 100rb apa aja", domain.IntentQuery, "", ""},
+		{"how much did I spend over 50k?", domain.IntentQuery, "", ""},
+		{"total belanja 150rb", domain.IntentRecord, "", ""},
+		{"beli kopi 20rb?", domain.IntentRecord, "", ""},
+		{"makasih!", domain.IntentSmalltalk, "", ""},
+	}
+
+	for _, tt := range tests {
+		got := RouteRules(tt.message)
+		if got == nil {
+			t.Errorf("RouteRules(%q) = nil, want %s", tt.message, tt.kind)
+			continue
+		}
+		if got.Kind != tt.kind || got.Setting != tt.setting || got.Period != tt.period {
+			t.Errorf("RouteRules(%q) = %s/%s/%s, want %s/%s/%s", tt.message,
+				got.Kind, got.Setting, got.Period, tt.kind, tt.setting, tt.period)
+		}
+	}
+}
+
+func TestDecodeIntent(t *testing.T) {
+	tests := []struct {
+		content string
+		kind    string
+		setting string
+		period  string
+	}{
+		{`{"intent":"settings","period":"","setting":"language","confidence":0.9}`, domain.IntentSettings, domain.SettingLanguage, ""},
+		{`{"intent":"settings","period":"","setting":"Category","confidence":0.9}`, domain.IntentSettings, domain.SettingCategory, ""},
+		// Without a setting the handler would guess
+		{`{"intent":"settings","period":"","setting":"","confidence":0.9}`, domain.IntentHelp, "", ""},
+		{`{"intent":"report","period":"","setting":"language","confidence":0.9}`, domain.IntentReport, "", domain.PeriodToday},
+	}
+
+	for _, tt := range tests {
+		got, err := decodeIntent(completion(tt.content))
+		if err != nil {
+			t.Errorf("decodeIntent(%s): %v", tt.content, err)
+			continue
+		}
+		if got.Kind != tt.kind || got.Setting != tt.setting || got.Period != tt.period {
+			t.Errorf("decodeIntent(%s) = %s/%s/%s, want %s/%s/%s", tt.content,
+				got.Kind, got.Setting, got.Period, tt.kind, tt.setting, tt.period)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+//go:embed prompts/*.tmpl
+var promptFiles embed.FS
+
+// Prompt names of the built-in parsers
+const (
+	PromptText   = "text"
+	PromptVision = "vision"
+)
+
+// Prompt is a system prompt template embedded from prompts/<name>.tmpl.
+// Version is a hash of the template text, so every edit is a new version.
+type Prompt struct {
+	Name    string
+	Version string
+	tmpl    *template.Template
+}
+
+// LoadPrompt loads the embedded template prompts/<name>.tmpl
+func LoadPrompt(name string) (*Prompt, error) {
+	data, err := promptFiles.ReadFile("prompts/" + name + ".tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("prompt %q not found: %w", name, err)
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt %q: %w", name, err)
+	}
+
+	sum := sha256.Sum256(data)
+	return &Prompt{Name: name, Version: hex.EncodeToString(sum[:4]), tmpl: tmpl}, nil
+}
+
+// mustLoadPrompt loads a built-in prompt; they are embedded, so failing is a
+// bug in the template
+func mustLoadPrompt(name string) *Prompt {
+	p, err := LoadPrompt(name)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// ID identifies the prompt version, e.g. "text@3f2a9c1b"
+func (p *Prompt) ID() string {
+	return p.Name + "@" + p.Version
+}
+
+// Render fills in the template
+func (p *Prompt) Render(data interface{}) (string, error) {
+	var sb strings.Builder
+	if err := p.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", p.ID(), err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// aiVersion is stored as ai_version on transactions: the model and the
+// prompt version that produced them, e.g. "gpt-4o-mini+text@3f2a9c1b"
+func aiVersion(model string, p *Prompt) string {
+	return model + "+" + p.ID()
+}
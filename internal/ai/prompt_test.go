@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// shadowRuns hands recorded shadow runs to the test
+type shadowRuns chan *domain.PromptShadowRun
+
+func (s shadowRuns) Create(ctx context.Context, run *domain.PromptShadowRun) error {
+	s <- run
+	return nil
+}
+
+func TestLoadPrompt(t *testing.T) {
+	for _, name := range []string{PromptText, PromptVision} {
+		p, err := LoadPrompt(name)
+		if err != nil {
+			t.Fatalf("LoadPrompt(%q): %v", name, err)
+		}
+		if !regexp.MustCompile(`^` + name + `@[0-9a-f]{8}$`).MatchString(p.ID()) {
+			t.Errorf("prompt ID = %q, want %s@<8 hex digits>", p.ID(), name)
+		}
+
+		// The version only changes when the template does
+		again, _ := LoadPrompt(name)
+		if again.Version != p.Version {
+			t.Errorf("%s version changed between loads: %s, %s", name, p.Version, again.Version)
+		}
+	}
+
+	if _, err := LoadPrompt("does-not-exist"); err == nil {
+		t.Error("LoadPrompt loaded a missing prompt")
+	}
+}
+
+func TestRenderTextPrompt(t *testing.T) {
+	p := mustLoadPrompt(PromptText)
+
+	got, err := p.Render(textPromptData{Today: "2026-03-18"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if !strings.Contains(got, "Today's date is 2026-03-18") {
+		t.Errorf("rendered prompt doesn't mention today:\n%s", got)
+	}
+
+	// Templates fail loudly instead of rendering "<no value>"
+	if _, err := p.Render(map[string]string{}); err == nil {
+		t.Error("Render succeeded without the template's data")
+	}
+}
+
+func TestAIVersion(t *testing.T) {
+	p := &TextParser{model: "gpt-4o-mini", prompt: mustLoadPrompt(PromptText)}
+	if want := "gpt-4o-mini+" + p.prompt.ID(); p.Version() != want {
+		t.Errorf("Version() = %q, want %q", p.Version(), want)
+	}
+}
+
+func TestAgrees(t *testing.T) {
+	day := time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC)
+	live := []domain.ParsedTransaction{{Type: domain.TypeExpense, Amount: 20000, Category: "Makan", Date: day, Description: "kopi"}}
+
+	tests := []struct {
+		name      string
+		candidate []*domain.ParsedTransaction
+		want      bool
+	}{
+		{"same", []*domain.ParsedTransaction{{Type: domain.TypeExpense, Amount: 20000, Category: "makan", Date: day, Description: "es kopi"}}, true},
+		{"rounding", []*domain.ParsedTransaction{{Type: domain.TypeExpense, Amount: 20000.4, Category: "makan", Date: day.Add(5 * time.Hour)}}, true},
+		{"amount", []*domain.ParsedTransaction{{Type: domain.TypeExpense, Amount: 25000, Category: "makan", Date: day}}, false},
+		{"type", []*domain.ParsedTransaction{{Type: domain.TypeIncome, Amount: 20000, Category: "makan", Date: day}}, false},
+		{"category", []*domain.ParsedTransaction{{Type: domain.TypeExpense, Amount: 20000, Category: "jajan", Date: day}}, false},
+		{"date", []*domain.ParsedTransaction{{Type: domain.TypeExpense, Amount: 20000, Category: "makan", Date: day.AddDate(0, 0, -1)}}, false},
+		{"count", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := agrees(live, tt.candidate); got != tt.want {
+			t.Errorf("%s: agrees = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestShadowParser(t *testing.T) {
+	srv := fakeOpenAI(t,
+		`{"transactions":[{"type":"EXPENSE","amount":20000,"category":"makan","description":"kopi","confidence":0.9}]}`,
+		`{"transactions":[{"type":"EXPENSE","amount":20000,"category":"jajan","description":"kopi","confidence":0.9}]}`,
+	)
+	primary := NewTextParser("test", srv.URL+"/v1", "gpt-4o-mini", time.UTC, nil)
+	runs := make(shadowRuns, 1)
+	p := NewShadowParser(primary, mustLoadPrompt(PromptText), 100, runs)
+
+	ctx := WithCallInfo(context.Background(), 7, "wamid.3")
+	parsed, err := p.Parse(ctx, "kopi 20rb")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	// The live answer is returned untouched by the candidate
+	if len(parsed) != 1 || parsed[0].Category != "makan" || parsed[0].AIVersion != primary.Version() {
+		t.Errorf("parsed %+v, want the live answer tagged %s", parsed, primary.Version())
+	}
+
+	select {
+	case run := <-runs:
+		if run.Agrees || run.CandidateError != "" {
+			t.Errorf("shadow run agrees=%v error=%q, want a disagreement", run.Agrees, run.CandidateError)
+		}
+		if run.UserID == nil || *run.UserID != 7 || run.WAMessageID != "wamid.3" {
+			t.Errorf("shadow run not tagged with user 7 and wamid.3: %+v", run)
+		}
+		if !strings.Contains(string(run.PrimaryOutput), `"makan"`) || !strings.Contains(string(run.CandidateOutput), `"jajan"`) {
+			t.Errorf("outputs = %s vs %s", run.PrimaryOutput, run.CandidateOutput)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no shadow run recorded")
+	}
+}
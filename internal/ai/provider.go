@@ -0,0 +1,41 @@
+package ai
+
+import (
+	"context"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// TransactionParser extracts transactions from a chat message
+type TransactionParser interface {
+	Parse(ctx context.Context, message string) ([]*domain.ParsedTransaction, error)
+}
+
+// QueryPlanner turns a question about the ledger into a query plan
+type QueryPlanner interface {
+	PlanQuery(ctx context.Context, question string) (*domain.LedgerQuery, error)
+}
+
+// ImageParser extracts a transaction from a receipt or transfer screenshot
+type ImageParser interface {
+	ParseImage(ctx context.Context, imageData []byte, mimeType, caption string) (*domain.ParsedTransaction, error)
+}
+
+// Provider names accepted by AI_PROVIDER
+const (
+	ProviderOpenAI = "openai"
+	ProviderFake   = "fake"
+)
+
+// NewOpenAIClient creates a client for OpenAI or any OpenAI-compatible server
+// (Ollama, vLLM, LocalAI) when baseURL is set, e.g. "http://localhost:11434/v1"
+func NewOpenAIClient(apiKey, baseURL string) *openai.Client {
+	config := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+	// Keep Retry-After, which the client drops from its errors, for WithRetry
+	config.HTTPClient = retryAfterDoer{next: config.HTTPClient}
+	return openai.NewClientWithConfig(config)
+}
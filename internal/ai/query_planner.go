@@ -0,0 +1,216 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// maxQueryRangeYears bounds how far back a question can reach
+const maxQueryRangeYears = 5
+
+// maxQueryKeywords and maxQueryKeywordRunes keep description filters small
+const (
+	maxQueryKeywords     = 3
+	maxQueryKeywordRunes = 50
+)
+
+var aggregations = map[string]bool{
+	domain.AggregateSum: true, domain.AggregateCount: true, domain.AggregateAvg: true,
+	domain.AggregateMax: true, domain.AggregateTop: true,
+}
+
+// ModelQueryPlanner asks the model for a query plan. The model only fills in
+// filter values; it never writes SQL.
+type ModelQueryPlanner struct {
+	client   *openai.Client
+	model    string
+	timezone *time.Location
+	usage    UsageStore
+}
+
+// NewQueryPlanner creates a planner; usage may be nil to skip call accounting
+func NewQueryPlanner(apiKey, baseURL, model string, timezone *time.Location, usage UsageStore) *ModelQueryPlanner {
+	return &ModelQueryPlanner{
+		client:   NewOpenAIClient(apiKey, baseURL),
+		model:    model,
+		timezone: timezone,
+		usage:    usage,
+	}
+}
+
+// PlanQuery turns a question like "berapa total jajan kopi bulan lalu?" into
+// a validated LedgerQuery
+func (p *ModelQueryPlanner) PlanQuery(ctx context.Context, question string) (*domain.LedgerQuery, error) {
+	now := SentAt(ctx).In(p.timezone)
+	today := now.Format("2006-01-02")
+
+	systemPrompt := fmt.Sprintf(`You turn questions (Indonesian, English or Javanese) about a user's own income and expense records into a query plan.
+
+IMPORTANT: Today's date is %s (%s).
+
+Return ONLY valid JSON in this exact format:
+{
+  "type": "INCOME", "EXPENSE" or "" for both,
+  "categories": ["category", ...] or [] for all,
+  "keywords": ["word in the description", ...] or [],
+  "start_date": "YYYY-MM-DD",
+  "end_date": "YYYY-MM-DD" (inclusive),
+  "aggregation": "sum" | "count" | "avg" | "max" | "top",
+  "limit": number (for max and top, otherwise 0)
+}
+
+Rules:
+1. "sum" for totals ("berapa total"), "count" for how many times ("berapa kali"),
+   "avg" for averages ("rata-rata"), "max" for the largest transactions ("terbesar", "paling mahal"),
+   "top" for the categories with the largest totals ("kategori paling boros", "top 3 kategori")
+2. Use categories only when the question names a category; put merchants and items
+   ("kopi", "starbucks", "grab") in keywords instead. For English or Javanese questions
+   add the Indonesian word as well, since descriptions may be in either language
+3. Resolve relative periods against today: "minggu ini" = Monday of this week to today,
+   "bulan lalu" = first to last day of last month. Without a period use this month so far
+4. Spending questions are EXPENSE, earning questions are INCOME
+
+Examples:
+- "berapa total jajan kopi bulan lalu?" → EXPENSE, [], ["kopi"], first..last day of last month, "sum", 0
+- "pengeluaran terbesar minggu ini apa?" → EXPENSE, [], [], Monday..today, "max", 1
+- "top 3 kategori pengeluaran bulan ini" → EXPENSE, [], [], 1st..today, "top", 3
+- "berapa kali naik grab tahun ini?" → EXPENSE, [], ["grab"], Jan 1st..today, "count", 0
+- "how much did I spend on coffee last month?" → EXPENSE, [], ["coffee", "kopi"], first..last day of last month, "sum", 0`, today, now.Weekday())
+	systemPrompt += categoryPrompt(ctx)
+
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: question},
+	}
+
+	for attempt := 0; ; attempt++ {
+		call := startCall(ctx, domain.AICallQuery, p.model)
+		resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+			Model:          p.model,
+			Messages:       messages,
+			Temperature:    0,
+			ResponseFormat: responseFormat(p.model, "query_plan", queryPlanSchema),
+		})
+
+		if err != nil {
+			finishCall(p.usage, call, resp.Usage, requestOutcome(err), err)
+			return nil, fmt.Errorf("openai API error: %w", err)
+		}
+
+		plan, err := p.decode(resp, now)
+		finishCall(p.usage, call, resp.Usage, responseOutcome(err), err)
+
+		var invalid *ValidationError
+		if err == nil || !errors.As(err, &invalid) || attempt >= maxRepairAttempts {
+			return plan, err
+		}
+
+		log.Printf("Asking model to repair query plan: %v", err)
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: resp.Choices[0].Message.Content},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: repairPrompt(err)},
+		)
+	}
+}
+
+func (p *ModelQueryPlanner) decode(resp openai.ChatCompletionResponse, now time.Time) (*domain.LedgerQuery, error) {
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from AI")
+	}
+
+	var raw rawQueryPlan
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &raw); err != nil {
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("response is not valid JSON (%v)", err)}}
+	}
+
+	plan, problems := raw.validate(now)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return plan, nil
+}
+
+// rawQueryPlan is a query plan as the model returned it
+type rawQueryPlan struct {
+	Type        string   `json:"type"`
+	Categories  []string `json:"categories"`
+	Keywords    []string `json:"keywords"`
+	StartDate   string   `json:"start_date"`
+	EndDate     string   `json:"end_date"`
+	Aggregation string   `json:"aggregation"`
+	Limit       int      `json:"limit"`
+}
+
+// validate checks every field against the allowed values and returns every
+// problem found. Missing dates default to the day of now.
+func (r *rawQueryPlan) validate(now time.Time) (*domain.LedgerQuery, []string) {
+	var problems []string
+	plan := &domain.LedgerQuery{
+		Aggregation: strings.ToLower(strings.TrimSpace(r.Aggregation)),
+		Limit:       r.Limit,
+	}
+
+	if t := strings.TrimSpace(r.Type); t != "" {
+		txType, ok := typeSynonyms[strings.ToUpper(t)]
+		if !ok {
+			problems = append(problems, fmt.Sprintf(`type %q must be "INCOME", "EXPENSE" or ""`, r.Type))
+		}
+		plan.Type = txType
+	}
+
+	if !aggregations[plan.Aggregation] {
+		problems = append(problems, fmt.Sprintf(`aggregation %q must be one of "sum", "count", "avg", "max", "top"`, r.Aggregation))
+	}
+	if plan.Limit < 1 && (plan.Aggregation == domain.AggregateMax || plan.Aggregation == domain.AggregateTop) {
+		plan.Limit = 1
+		if plan.Aggregation == domain.AggregateTop {
+			plan.Limit = 3
+		}
+	}
+	if plan.Limit > domain.MaxLedgerQueryLimit {
+		plan.Limit = domain.MaxLedgerQueryLimit
+	}
+
+	for _, c := range r.Categories {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			plan.Categories = append(plan.Categories, truncateRunes(c, 100))
+		}
+	}
+	for _, k := range r.Keywords {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" && len(plan.Keywords) < maxQueryKeywords {
+			plan.Keywords = append(plan.Keywords, truncateRunes(k, maxQueryKeywordRunes))
+		}
+	}
+
+	start, err := parseResponseDate(r.StartDate, now)
+	if err != nil {
+		problems = append(problems, "start_"+err.Error())
+	}
+	end, err := parseResponseDate(r.EndDate, now)
+	if err != nil {
+		problems = append(problems, "end_"+err.Error())
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	if end.Before(start) {
+		start, end = end, start
+	}
+	if earliest := end.AddDate(-maxQueryRangeYears, 0, 0); start.Before(earliest) {
+		start = earliest
+	}
+	plan.Start = start
+	// end_date is inclusive; the repository wants an exclusive bound
+	plan.End = end.AddDate(0, 0, 1)
+
+	return plan, nil
+}
@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+func TestQueryPlanValidate(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	day := func(month time.Month, d int) time.Time { return time.Date(2026, month, d, 0, 0, 0, 0, wib) }
+	now := time.Date(2026, 3, 18, 23, 30, 0, 0, wib)
+
+	tests := []struct {
+		name string
+		raw  rawQueryPlan
+		want domain.LedgerQuery
+	}{
+		{"sum with keywords",
+			rawQueryPlan{Type: "EXPENSE", Keywords: []string{" Kopi ", "", "coffee"}, StartDate: "2026-02-01", EndDate: "2026-02-28", Aggregation: "SUM"},
+			domain.LedgerQuery{Type: domain.TypeExpense, Keywords: []string{"kopi", "coffee"}, Start: day(2, 1), End: day(3, 1), Aggregation: domain.AggregateSum}},
+		{"both types and swapped dates",
+			rawQueryPlan{Categories: []string{"Makanan & Minuman"}, StartDate: "2026-03-18", EndDate: "2026-03-16", Aggregation: "count"},
+			domain.LedgerQuery{Categories: []string{"makanan & minuman"}, Start: day(3, 16), End: day(3, 19), Aggregation: domain.AggregateCount}},
+		{"max without limit",
+			rawQueryPlan{Type: "pengeluaran", StartDate: "2026-03-16", EndDate: "2026-03-18", Aggregation: "max"},
+			domain.LedgerQuery{Type: domain.TypeExpense, Start: day(3, 16), End: day(3, 19), Aggregation: domain.AggregateMax, Limit: 1}},
+		{"top without limit",
+			rawQueryPlan{Type: "EXPENSE", StartDate: "2026-03-01", EndDate: "2026-03-18", Aggregation: "top"},
+			domain.LedgerQuery{Type: domain.TypeExpense, Start: day(3, 1), End: day(3, 19), Aggregation: domain.AggregateTop, Limit: 3}},
+		{"limit capped",
+			rawQueryPlan{Type: "EXPENSE", StartDate: "2026-03-01", EndDate: "2026-03-18", Aggregation: "top", Limit: 50},
+			domain.LedgerQuery{Type: domain.TypeExpense, Start: day(3, 1), End: day(3, 19), Aggregation: domain.AggregateTop, Limit: domain.MaxLedgerQueryLimit}},
+		{"range capped",
+			rawQueryPlan{Type: "INCOME", StartDate: "2001-01-01", EndDate: "2026-03-18", Aggregation: "sum"},
+			domain.LedgerQuery{Type: domain.TypeIncome, Start: time.Date(2021, 3, 18, 0, 0, 0, 0, wib), End: day(3, 19), Aggregation: domain.AggregateSum}},
+		{"missing dates default to the day the question was sent",
+			rawQueryPlan{Type: "EXPENSE", Aggregation: "sum"},
+			domain.LedgerQuery{Type: domain.TypeExpense, Start: day(3, 18), End: day(3, 19), Aggregation: domain.AggregateSum}},
+	}
+
+	for _, tt := range tests {
+		got, problems := tt.raw.validate(now)
+		if len(problems) > 0 {
+			t.Errorf("%s: problems %v", tt.name, problems)
+			continue
+		}
+		if got.Type != tt.want.Type || got.Aggregation != tt.want.Aggregation || got.Limit != tt.want.Limit ||
+			!reflect.DeepEqual(got.Categories, tt.want.Categories) || !reflect.DeepEqual(got.Keywords, tt.want.Keywords) ||
+			!got.Start.Equal(tt.want.Start) || !got.End.Equal(tt.want.End) {
+			t.Errorf("%s: plan = %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+}
+
+func TestQueryPlanValidateKeywordLimits(t *testing.T) {
+	raw := rawQueryPlan{
+		Keywords:    []string{"kopi", "grab", "gojek", "starbucks", strings.Repeat("a", 80)},
+		StartDate:   "2026-03-01",
+		EndDate:     "2026-03-18",
+		Aggregation: "sum",
+	}
+	got, problems := raw.validate(time.Now())
+	if len(problems) > 0 {
+		t.Fatalf("problems %v", problems)
+	}
+	if want := []string{"kopi", "grab", "gojek"}; !reflect.DeepEqual(got.Keywords, want) {
+		t.Errorf("keywords = %v, want %v", got.Keywords, want)
+	}
+
+	raw.Keywords = []string{strings.Repeat("a", 80)}
+	if got, _ = raw.validate(time.Now()); len([]rune(got.Keywords[0])) != maxQueryKeywordRunes {
+		t.Errorf("keyword kept %d runes, want %d", len([]rune(got.Keywords[0])), maxQueryKeywordRunes)
+	}
+}
+
+func TestQueryPlanValidateProblems(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     rawQueryPlan
+		problem string
+	}{
+		{"bad type", rawQueryPlan{Type: "TRANSFER", StartDate: "2026-03-01", EndDate: "2026-03-18", Aggregation: "sum"}, `type "TRANSFER"`},
+		{"bad aggregation", rawQueryPlan{StartDate: "2026-03-01", EndDate: "2026-03-18", Aggregation: "median"}, `aggregation "median"`},
+		{"bad start", rawQueryPlan{StartDate: "bulan lalu", EndDate: "2026-03-18", Aggregation: "sum"}, "start_date"},
+		{"bad end", rawQueryPlan{StartDate: "2026-03-01", EndDate: "kemarin", Aggregation: "sum"}, "end_date"},
+	}
+
+	for _, tt := range tests {
+		plan, problems := tt.raw.validate(time.Now())
+		if plan != nil || !strings.Contains(strings.Join(problems, "; "), tt.problem) {
+			t.Errorf("%s: validate = %v, %v, want a problem mentioning %q", tt.name, plan, problems, tt.problem)
+		}
+	}
+}
@@ -2,39 +2,253 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	openai "github.com/sashabaranov/go-openai"
 )
 
 // RetryConfig defines retry behavior for AI calls
 type RetryConfig struct {
+	// Op names the call in logs, e.g. "text" or "vision"
+	Op         string
 	MaxRetries int
-	Delay      time.Duration
+	// Delay is the backoff before the first retry; it doubles for every
+	// further retry up to MaxDelay
+	Delay    time.Duration
+	MaxDelay time.Duration
+	// AttemptTimeout bounds each attempt; zero leaves only ctx's deadline
+	AttemptTimeout time.Duration
+	// Breaker, if set, is the circuit the calls go through; its state is
+	// logged with every failure
+	Breaker *CircuitBreaker
 }
 
-// WithRetry wraps an AI call with retry logic
+// WithRetry wraps an AI call with retry logic. Only transient errors
+// (timeouts, connection failures, 429 and 5xx) are retried, with exponential
+// backoff and jitter, or after the Retry-After the provider asked for.
 func WithRetry[T any](ctx context.Context, cfg RetryConfig, fn func(context.Context) (T, error)) (T, error) {
+	if cfg.MaxDelay < cfg.Delay {
+		cfg.MaxDelay = cfg.Delay
+	}
+	retryMetrics.calls.Add(1)
+
 	var result T
-	var lastErr error
-
-	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
-		if attempt > 0 {
-			// Wait before retry
-			select {
-			case <-ctx.Done():
-				return result, ctx.Err()
-			case <-time.After(cfg.Delay * time.Duration(attempt)):
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retryAfter time.Duration
+		result, err = runAttempt(ctx, cfg, attempt, &retryAfter, fn)
+		if err == nil {
+			retryMetrics.succeeded.Add(1)
+			if attempt > 0 {
+				slog.Info("AI call succeeded after retry", "op", cfg.Op, "attempt", attempt+1)
 			}
+			return result, nil
 		}
 
-		result, lastErr = fn(ctx)
-		if lastErr == nil {
-			return result, nil
+		if ctx.Err() != nil {
+			// The caller gave up; nothing to retry or blame the provider for
+			retryMetrics.cancelled.Add(1)
+			return result, err
+		}
+
+		retryable := IsRetryable(err)
+		logger := slog.With("op", cfg.Op, "attempt", attempt+1, "max_attempts", cfg.MaxRetries+1, "retryable", retryable, "error", err)
+		if cfg.Breaker != nil {
+			logger = logger.With("breaker", cfg.Breaker.State())
+		}
+
+		if !retryable {
+			retryMetrics.permanent.Add(1)
+			logger.Warn("AI call failed")
+			return result, err
+		}
+		if attempt >= cfg.MaxRetries {
+			retryMetrics.exhausted.Add(1)
+			logger.Warn("AI call failed, no retries left")
+			return result, fmt.Errorf("AI call failed after %d retries: %w", cfg.MaxRetries, err)
+		}
+
+		delay := backoffDelay(cfg, attempt)
+		if retryAfter > 0 {
+			// Waiting longer than we would ever back off isn't worth it
+			if retryAfter > cfg.MaxDelay {
+				retryMetrics.exhausted.Add(1)
+				logger.Warn("AI call failed, Retry-After too long", "retry_after_ms", retryAfter.Milliseconds())
+				return result, fmt.Errorf("AI call failed, provider asked to retry after %s: %w", retryAfter, err)
+			}
+			delay = retryAfter
+			retryMetrics.retryAfter.Add(1)
 		}
 
-		// Log retry attempt
-		fmt.Printf("AI call failed (attempt %d/%d): %v\n", attempt+1, cfg.MaxRetries+1, lastErr)
+		retryMetrics.retries.Add(1)
+		logger.Warn("AI call failed, retrying", "delay_ms", delay.Milliseconds(), "retry_after", retryAfter > 0)
+
+		select {
+		case <-ctx.Done():
+			retryMetrics.cancelled.Add(1)
+			return result, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// runAttempt runs one attempt under its own timeout, collecting the
+// provider's Retry-After into retryAfter
+func runAttempt[T any](ctx context.Context, cfg RetryConfig, attempt int, retryAfter *time.Duration, fn func(context.Context) (T, error)) (T, error) {
+	ctx = withAttempt(ctx, attempt)
+	ctx = context.WithValue(ctx, retryAfterKey{}, &retryAfterHint{})
+	if cfg.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.AttemptTimeout)
+		defer cancel()
+	}
+
+	result, err := fn(ctx)
+	*retryAfter = ctx.Value(retryAfterKey{}).(*retryAfterHint).get()
+	return result, err
+}
+
+// backoffDelay returns Delay·2^attempt capped at MaxDelay, with equal jitter
+// so retries from many messages don't arrive in lockstep
+func backoffDelay(cfg RetryConfig, attempt int) time.Duration {
+	delay := cfg.Delay << attempt
+	if delay > cfg.MaxDelay || delay <= 0 {
+		delay = cfg.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// IsRetryable reports whether a failed AI call may succeed when repeated:
+// timeouts, connection failures, rate limits and server errors. Bad requests,
+// exhausted quotas and responses the model got wrong are permanent.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Type == "insufficient_quota" || apiErr.Code == "insufficient_quota" {
+			return false
+		}
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return retryableStatus(reqErr.HTTPStatusCode)
 	}
 
-	return result, fmt.Errorf("AI call failed after %d retries: %w", cfg.MaxRetries, lastErr)
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || unavailableStatus(code)
+}
+
+type retryAfterKey struct{}
+
+// retryAfterHint carries a Retry-After header from the HTTP response, which
+// the OpenAI client drops from its errors, back to WithRetry
+type retryAfterHint struct {
+	mu    sync.Mutex
+	delay time.Duration
+}
+
+func (h *retryAfterHint) set(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.delay = d
+}
+
+func (h *retryAfterHint) get() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.delay
+}
+
+// retryAfterDoer records Retry-After on failed responses for WithRetry
+type retryAfterDoer struct {
+	next openai.HTTPDoer
+}
+
+func (d retryAfterDoer) Do(req *http.Request) (*http.Response, error) {
+	resp, err := d.next.Do(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+	if hint, ok := req.Context().Value(retryAfterKey{}).(*retryAfterHint); ok {
+		if delay := parseRetryAfter(resp.Header, time.Now()); delay > 0 {
+			hint.set(delay)
+		}
+	}
+	return resp, err
+}
+
+// parseRetryAfter reads retry-after-ms (OpenAI) or Retry-After in seconds or
+// as an HTTP date
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// RetryStats counts WithRetry outcomes since startup
+type RetryStats struct {
+	// Calls made through WithRetry, and how they ended
+	Calls     int64 `json:"calls"`
+	Succeeded int64 `json:"succeeded"`
+	// Permanent failures weren't retried; Exhausted ran out of retries;
+	// Cancelled were given up by the caller, e.g. on shutdown
+	Permanent int64 `json:"permanent"`
+	Exhausted int64 `json:"exhausted"`
+	Cancelled int64 `json:"cancelled"`
+	// Retries made, and how many waited for the provider's Retry-After
+	Retries    int64 `json:"retries"`
+	RetryAfter int64 `json:"retry_after"`
+}
+
+var retryMetrics struct {
+	calls, succeeded, permanent, exhausted, cancelled, retries, retryAfter atomic.Int64
+}
+
+// GetRetryStats returns the retry counters
+func GetRetryStats() RetryStats {
+	return RetryStats{
+		Calls:      retryMetrics.calls.Load(),
+		Succeeded:  retryMetrics.succeeded.Load(),
+		Permanent:  retryMetrics.permanent.Load(),
+		Exhausted:  retryMetrics.exhausted.Load(),
+		Cancelled:  retryMetrics.cancelled.Load(),
+		Retries:    retryMetrics.retries.Load(),
+		RetryAfter: retryMetrics.retryAfter.Load(),
+	}
 }
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"deadline", fmt.Errorf("parse: %w", context.DeadlineExceeded), true},
+		{"cancelled", context.Canceled, false},
+		{"circuit open", ErrCircuitOpen, false},
+		{"rate limited", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, true},
+		{"server error", &openai.APIError{HTTPStatusCode: http.StatusBadGateway}, true},
+		{"quota", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Code: "insufficient_quota"}, false},
+		{"bad request", &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, false},
+		{"request 503", &openai.RequestError{HTTPStatusCode: http.StatusServiceUnavailable, Err: errors.New("unavailable")}, true},
+		{"bad output", errors.New("failed to parse AI response"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 18, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header http.Header
+		want   time.Duration
+	}{
+		{http.Header{"Retry-After-Ms": {"1500"}}, 1500 * time.Millisecond},
+		{http.Header{"Retry-After": {"2"}}, 2 * time.Second},
+		{http.Header{"Retry-After": {now.Add(3 * time.Second).Format(http.TimeFormat)}}, 3 * time.Second},
+		{http.Header{"Retry-After": {now.Add(-time.Second).Format(http.TimeFormat)}}, 0},
+		{http.Header{"Retry-After": {"soon"}}, 0},
+		{http.Header{}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%v) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	cfg := RetryConfig{Op: "test", MaxRetries: 2, Delay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	transient := &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable}
+
+	tests := []struct {
+		name     string
+		errs     []error // returned by successive attempts; then success
+		attempts int
+		wantErr  bool
+	}{
+		{"success", nil, 1, false},
+		{"transient then success", []error{transient, transient}, 3, false},
+		{"transient exhausted", []error{transient, transient, transient}, 3, true},
+		{"permanent", []error{&openai.APIError{HTTPStatusCode: http.StatusBadRequest}}, 1, true},
+	}
+
+	for _, tt := range tests {
+		attempts := 0
+		got, err := WithRetry(context.Background(), cfg, func(ctx context.Context) (string, error) {
+			attempts++
+			if attempts <= len(tt.errs) {
+				return "", tt.errs[attempts-1]
+			}
+			return "ok", nil
+		})
+		if attempts != tt.attempts {
+			t.Errorf("%s: %d attempts, want %d", tt.name, attempts, tt.attempts)
+		}
+		if (err != nil) != tt.wantErr || (err == nil && got != "ok") {
+			t.Errorf("%s: WithRetry = %q, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestWithRetryLogsFields(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	cfg := RetryConfig{Op: "text", MaxRetries: 1, Delay: time.Millisecond, Breaker: NewCircuitBreaker(5, time.Minute)}
+	WithRetry(context.Background(), cfg, func(ctx context.Context) (string, error) {
+		return "", &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable}
+	})
+
+	out := buf.String()
+	for _, want := range []string{
+		`msg="AI call failed, retrying" op=text attempt=1 max_attempts=2 retryable=true`,
+		`breaker=closed delay_ms=`,
+		`msg="AI call failed, no retries left" op=text attempt=2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	cfg := RetryConfig{Delay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	for attempt, limit := range []time.Duration{100, 200, 300, 300} {
+		limit *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			if d := backoffDelay(cfg, attempt); d < limit/2 || d > limit {
+				t.Fatalf("backoffDelay(attempt %d) = %v, want between %v and %v", attempt, d, limit/2, limit)
+			}
+		}
+	}
+}
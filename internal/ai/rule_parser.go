@@ -0,0 +1,255 @@
+package ai
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// RuleParserVersion is stored as ai_version for transactions parsed by rules
+const RuleParserVersion = "rules-v1"
+
+// minRuleConfidence is the lowest rule confidence accepted without asking the
+// model. Rules only answer when they would be auto-saved with room to spare;
+// anything vaguer goes to the fallback.
+const minRuleConfidence = 0.8
+
+// RuleParser handles common one-line messages ("beli bensin 50rb",
+// "gaji 5jt") with a keyword grammar and only calls the fallback parser for
+// messages it can't read confidently
+type RuleParser struct {
+	fallback TransactionParser
+	timezone *time.Location
+}
+
+func NewRuleParser(fallback TransactionParser, timezone *time.Location) *RuleParser {
+	return &RuleParser{fallback: fallback, timezone: timezone}
+}
+
+type ruleCategory struct {
+	category string
+	txType   string
+}
+
+var (
+	// "dari" and "dikasih" mark money received: "uang makan dari kantor 100rb"
+	ruleIncomeWords = map[string]bool{
+		"dapat": true, "dapet": true, "terima": true, "nerima": true, "pemasukan": true, "masuk": true, "jual": true,
+		"dari": true, "dikasih": true, "dikirim": true, "ditransfer": true, "from": true,
+		"received": true, "got": true, "earned": true, "sold": true, "entuk": true, "oleh": true,
+	}
+	ruleExpenseWords = map[string]bool{
+		"beli": true, "bayar": true, "belanja": true, "jajan": true, "isi": true, "topup": true,
+		"traktir": true, "pengeluaran": true, "keluar": true,
+		"buy": true, "bought": true, "pay": true, "paid": true, "spent": true, "spend": true,
+		"tuku": true, "mbayar": true, "tumbas": true,
+	}
+
+	// ruleCategories maps keywords to a category and the type it implies
+	ruleCategories = map[string]ruleCategory{
+		"gaji": {"gaji", domain.TypeIncome}, "thr": {"gaji", domain.TypeIncome},
+		"salary": {"gaji", domain.TypeIncome}, "gajian": {"gaji", domain.TypeIncome},
+		"bonus": {"bonus", domain.TypeIncome}, "komisi": {"bonus", domain.TypeIncome},
+		"jual": {"penjualan", domain.TypeIncome}, "penjualan": {"penjualan", domain.TypeIncome},
+
+		"makan": {"makan", domain.TypeExpense}, "sarapan": {"makan", domain.TypeExpense},
+		"kopi": {"makan", domain.TypeExpense}, "ngopi": {"makan", domain.TypeExpense},
+		"nasi": {"makan", domain.TypeExpense}, "bakso": {"makan", domain.TypeExpense},
+		"minum": {"makan", domain.TypeExpense}, "jajan": {"makan", domain.TypeExpense},
+		"snack": {"makan", domain.TypeExpense},
+		"lunch": {"makan", domain.TypeExpense}, "dinner": {"makan", domain.TypeExpense},
+		"breakfast": {"makan", domain.TypeExpense}, "coffee": {"makan", domain.TypeExpense},
+		"food": {"makan", domain.TypeExpense}, "meal": {"makan", domain.TypeExpense},
+		"mangan": {"makan", domain.TypeExpense}, "ngombe": {"makan", domain.TypeExpense},
+
+		"bensin": {"transport", domain.TypeExpense}, "bbm": {"transport", domain.TypeExpense},
+		"pertalite": {"transport", domain.TypeExpense}, "pertamax": {"transport", domain.TypeExpense},
+		"ojek": {"transport", domain.TypeExpense}, "ojol": {"transport", domain.TypeExpense},
+		"gojek": {"transport", domain.TypeExpense}, "grab": {"transport", domain.TypeExpense},
+		"parkir": {"transport", domain.TypeExpense}, "tol": {"transport", domain.TypeExpense},
+		"krl": {"transport", domain.TypeExpense}, "kereta": {"transport", domain.TypeExpense},
+		"taksi": {"transport", domain.TypeExpense}, "taxi": {"transport", domain.TypeExpense},
+		"fuel": {"transport", domain.TypeExpense}, "petrol": {"transport", domain.TypeExpense},
+		"parking": {"transport", domain.TypeExpense}, "toll": {"transport", domain.TypeExpense},
+
+		"listrik": {"tagihan", domain.TypeExpense}, "pln": {"tagihan", domain.TypeExpense},
+		"pdam": {"tagihan", domain.TypeExpense}, "internet": {"tagihan", domain.TypeExpense},
+		"wifi": {"tagihan", domain.TypeExpense}, "pulsa": {"tagihan", domain.TypeExpense},
+		"kuota": {"tagihan", domain.TypeExpense}, "electricity": {"tagihan", domain.TypeExpense},
+
+		"belanja": {"belanja", domain.TypeExpense}, "sembako": {"belanja", domain.TypeExpense},
+		"indomaret": {"belanja", domain.TypeExpense}, "alfamart": {"belanja", domain.TypeExpense},
+		"groceries": {"belanja", domain.TypeExpense}, "grocery": {"belanja", domain.TypeExpense},
+		"shopping": {"belanja", domain.TypeExpense}, "blanja": {"belanja", domain.TypeExpense},
+
+		"kos": {"tempat tinggal", domain.TypeExpense}, "kost": {"tempat tinggal", domain.TypeExpense},
+		"kontrakan": {"tempat tinggal", domain.TypeExpense}, "rent": {"tempat tinggal", domain.TypeExpense},
+
+		"obat": {"kesehatan", domain.TypeExpense}, "dokter": {"kesehatan", domain.TypeExpense},
+		"apotek": {"kesehatan", domain.TypeExpense}, "medicine": {"kesehatan", domain.TypeExpense},
+		"doctor": {"kesehatan", domain.TypeExpense}, "pharmacy": {"kesehatan", domain.TypeExpense},
+
+		"nonton": {"hiburan", domain.TypeExpense}, "bioskop": {"hiburan", domain.TypeExpense},
+		"netflix": {"hiburan", domain.TypeExpense}, "spotify": {"hiburan", domain.TypeExpense},
+		"movie": {"hiburan", domain.TypeExpense}, "cinema": {"hiburan", domain.TypeExpense},
+
+		"sedekah": {"donasi", domain.TypeExpense}, "infaq": {"donasi", domain.TypeExpense},
+		"zakat": {"donasi", domain.TypeExpense}, "donasi": {"donasi", domain.TypeExpense},
+	}
+
+	// Words that carry no meaning for the description
+	ruleFillerWords = map[string]bool{
+		"catat": true, "pemasukan": true, "pengeluaran": true, "rp": true, "rupiah": true,
+	}
+
+	ruleSplitPattern = regexp.MustCompile(`[,;\n]|\s+dan\s+`)
+	// Questions about the ledger mention amounts too: "berapa kali jajan di atas 50rb?"
+	ruleQuestionPattern = regexp.MustCompile(`\?|\b(berapa|brp|apa|apakah|kapan|mana|how|what|pira|piro)\b`)
+	// Date words ResolveDate couldn't place are left to the model
+	ruleDatePattern = regexp.MustCompile(`\b(kemarin|lusa|tadi|lalu|tgl|tanggal|senin|selasa|rabu|kamis|jumat|sabtu|minggu|last|ago|week|monday|tuesday|wednesday|thursday|friday|saturday|sunday)\b|\d{1,2}/\d{1,2}`)
+	// Suffixes the amount tokenizer doesn't know ("5pcs", "2x") are left to the model
+	ruleUnknownSuffix = regexp.MustCompile(`\d+[a-z]`)
+	ruleAmountPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	ruleWordPattern   = regexp.MustCompile(`[a-z]+`)
+)
+
+// Parse uses the rules when they are confident and the fallback otherwise
+func (p *RuleParser) Parse(ctx context.Context, message string) ([]*domain.ParsedTransaction, error) {
+	if parsed, ok := p.ParseRules(message, SentAt(ctx)); ok {
+		return parsed, nil
+	}
+	return p.fallback.Parse(ctx, message)
+}
+
+// ParseRules parses a message sent at now with the keyword grammar only. ok
+// is false when any part of the message is below minRuleConfidence.
+func (p *RuleParser) ParseRules(message string, now time.Time) ([]*domain.ParsedTransaction, bool) {
+	text := strings.ToLower(strings.TrimSpace(message))
+	now = now.In(p.timezone)
+
+	// One date applies to every transaction; "kemarin kopi 20rb, hari ini
+	// bensin 50rb" is left to the model
+	date := now.Truncate(time.Minute)
+	resolved, err := ResolveDate(text, now)
+	if err != nil {
+		return nil, false
+	}
+	if resolved != nil {
+		date = resolved.Time
+		text = resolved.Strip(text)
+	}
+
+	if text == "" || ruleDatePattern.MatchString(text) || ruleQuestionPattern.MatchString(text) {
+		return nil, false
+	}
+
+	// Amounts first, so "1,5jt" isn't split at its comma
+	var parsed []*domain.ParsedTransaction
+	for _, segment := range ruleSplitPattern.Split(NormalizeAmounts(text), -1) {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+
+		pt := parseRuleSegment(segment)
+		if pt == nil || pt.Confidence < minRuleConfidence {
+			return nil, false
+		}
+		pt.Date = date
+		parsed = append(parsed, pt)
+	}
+
+	return parsed, len(parsed) > 0
+}
+
+// parseRuleSegment reads one transaction, or returns nil if the segment
+// doesn't have exactly one plausible amount. Amounts in normalized are
+// already plain numbers.
+func parseRuleSegment(normalized string) *domain.ParsedTransaction {
+	if ruleUnknownSuffix.MatchString(normalized) {
+		return nil
+	}
+
+	// Bare small numbers and times ("jam 19.30") are left to the model too
+	amounts := ruleAmountPattern.FindAllString(normalized, -1)
+	if len(amounts) != 1 {
+		return nil
+	}
+	amount, err := strconv.ParseFloat(amounts[0], 64)
+	// Small bare numbers are usually quantities or typos, not rupiah
+	if err != nil || amount < 100 {
+		return nil
+	}
+
+	var verbType, categoryType, category string
+	var conflict bool
+	var described []string
+	for _, word := range ruleWordPattern.FindAllString(normalized, -1) {
+		switch {
+		case ruleIncomeWords[word]:
+			conflict = conflict || verbType == domain.TypeExpense
+			verbType = domain.TypeIncome
+		case ruleExpenseWords[word]:
+			conflict = conflict || verbType == domain.TypeIncome
+			verbType = domain.TypeExpense
+		}
+
+		if c, ok := ruleCategories[word]; ok && category == "" {
+			category = c.category
+			categoryType = c.txType
+		}
+
+		if !ruleFillerWords[word] {
+			described = append(described, word)
+		}
+	}
+
+	// A transaction verb and a known category together leave little to guess;
+	// either alone is weaker. These are not calibrated probabilities: run
+	// cmd/evalparser with -parser rules to check nothing is auto-saved wrong.
+	confidence := 0.5
+	txType := verbType
+	switch {
+	case verbType != "" && categoryType != "" && verbType != categoryType:
+		// "bayar gaji karyawan": verb and category disagree
+		conflict = true
+	case verbType != "" && category != "":
+		confidence += 0.45
+	case verbType == "" && category != "":
+		// "bensin 50rb": the category implies the type
+		txType = categoryType
+		confidence += 0.35
+	case verbType != "":
+		// "bayar 50rb": no idea what for
+		confidence += 0.2
+	}
+	if conflict || txType == "" {
+		return nil
+	}
+
+	// Long free-form sentences hide details the grammar doesn't read
+	if len(described) > 5 {
+		confidence -= 0.15
+	}
+
+	if category == "" {
+		category = "lainnya"
+	}
+	description := strings.Join(described, " ")
+	if description == "" {
+		description = category
+	}
+
+	return &domain.ParsedTransaction{
+		Type:        txType,
+		Amount:      amount,
+		Category:    category,
+		Description: description,
+		Confidence:  confidence,
+		AIVersion:   RuleParserVersion,
+	}
+}
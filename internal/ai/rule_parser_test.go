@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+func TestParseRules(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+	p := NewRuleParser(nil, loc)
+	now := time.Date(2026, 3, 18, 14, 5, 30, 0, loc)
+
+	type tx struct {
+		txType   string
+		amount   float64
+		category string
+	}
+	tests := []struct {
+		message string
+		want    []tx // nil: left to the model
+	}{
+		{"beli bensin 50rb", []tx{{domain.TypeExpense, 50000, "transport"}}},
+		{"bensin 50rb", []tx{{domain.TypeExpense, 50000, "transport"}}},
+		{"gaji 5jt", []tx{{domain.TypeIncome, 5000000, "gaji"}}},
+		{"Rp 1,5jt bayar kos", []tx{{domain.TypeExpense, 1500000, "tempat tinggal"}}},
+		{"1jt500 bayar kos", []tx{{domain.TypeExpense, 1500000, "tempat tinggal"}}},
+		{"makan siang 25k, parkir 5rb", []tx{{domain.TypeExpense, 25000, "makan"}, {domain.TypeExpense, 5000, "transport"}}},
+		{"paid netflix 54k", []tx{{domain.TypeExpense, 54000, "hiburan"}}},
+		{"tuku kopi 15rb", []tx{{domain.TypeExpense, 15000, "makan"}}},
+
+		// Vague, conflicting or unusual messages go to the model
+		{"bayar 50rb", nil},
+		{"kopi", nil},
+		{"bayar gaji karyawan 3jt", nil},
+		{"beli 2 kopi 40rb", nil},
+		{"beli telur 2kg 30rb", nil},
+		{"kemarin kopi 20rb, hari ini bensin 50rb", nil},
+		{"beli kopi 20rb sama teman kantor tadi habis meeting panjang", nil},
+		// Questions and money received are left to the model
+		{"berapa kali jajan di atas 50rb?", nil},
+		{"jajan 50rb?", nil},
+		{"uang makan dari kantor 100rb", nil},
+		{"dikasih ibu 200rb buat makan", nil},
+	}
+
+	for _, tt := range tests {
+		parsed, ok := p.ParseRules(tt.message, now)
+		if tt.want == nil {
+			if ok {
+				t.Errorf("ParseRules(%q) = %+v, want it left to the model", tt.message, parsed[0])
+			}
+			continue
+		}
+		if !ok || len(parsed) != len(tt.want) {
+			t.Errorf("ParseRules(%q) = %d transactions (ok %v), want %d", tt.message, len(parsed), ok, len(tt.want))
+			continue
+		}
+		for i, w := range tt.want {
+			got := parsed[i]
+			if got.Type != w.txType || got.Amount != w.amount || got.Category != w.category {
+				t.Errorf("ParseRules(%q)[%d] = %s %.0f %s, want %s %.0f %s", tt.message, i,
+					got.Type, got.Amount, got.Category, w.txType, w.amount, w.category)
+			}
+			if got.Confidence < minRuleConfidence || got.AIVersion != RuleParserVersion {
+				t.Errorf("ParseRules(%q)[%d] confidence %v version %q", tt.message, i, got.Confidence, got.AIVersion)
+			}
+		}
+	}
+}
+
+func TestParseRulesDates(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+	p := NewRuleParser(nil, loc)
+	now := time.Date(2026, 3, 18, 14, 5, 30, 0, loc)
+
+	tests := []struct {
+		message string
+		want    time.Time
+	}{
+		{"beli bensin 50rb", time.Date(2026, 3, 18, 14, 5, 0, 0, loc)},
+		{"kemarin beli bensin 50rb", time.Date(2026, 3, 17, 0, 0, 0, 0, loc)},
+		{"2 minggu lalu bayar listrik 300rb", time.Date(2026, 3, 4, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		parsed, ok := p.ParseRules(tt.message, now)
+		if !ok {
+			t.Errorf("ParseRules(%q) left it to the model", tt.message)
+			continue
+		}
+		if !parsed[0].Date.Equal(tt.want) {
+			t.Errorf("ParseRules(%q) date = %v, want %v", tt.message, parsed[0].Date, tt.want)
+		}
+	}
+}
+
+// countingParser counts the messages that reach the model
+type countingParser struct {
+	calls int
+}
+
+func (p *countingParser) Parse(ctx context.Context, message string) ([]*domain.ParsedTransaction, error) {
+	p.calls++
+	return []*domain.ParsedTransaction{{Type: domain.TypeExpense, Amount: 1, Confidence: 0.9}}, nil
+}
+
+func TestRuleParserFallback(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+	fallback := &countingParser{}
+	p := NewRuleParser(fallback, loc)
+	ctx := WithSentAt(context.Background(), time.Date(2026, 3, 18, 14, 0, 0, 0, loc))
+
+	if _, err := p.Parse(ctx, "beli bensin 50rb"); err != nil || fallback.calls != 0 {
+		t.Fatalf("confident message: err %v, %d model calls, want none", err, fallback.calls)
+	}
+	if _, err := p.Parse(ctx, "bayar 50rb"); err != nil || fallback.calls != 1 {
+		t.Fatalf("vague message: err %v, %d model calls, want 1", err, fallback.calls)
+	}
+}
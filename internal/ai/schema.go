@@ -0,0 +1,121 @@
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// transactionSchema describes one transaction; strict structured output
+// requires every property to be listed as required
+const transactionSchema = `{
+	"type": "object",
+	"properties": {
+		"type": {"type": "string", "enum": ["INCOME", "EXPENSE"]},
+		"amount": {"type": "number"},
+		"category": {"type": "string"},
+		"description": {"type": "string"},
+		"date": {"type": "string", "description": "YYYY-MM-DD"},
+		"confidence": {"type": "number"}%s
+	},
+	"required": ["type", "amount", "category", "description", "date", "confidence"%s],
+	"additionalProperties": false
+}`
+
+const itemSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"quantity": {"type": "number"},
+		"unit_price": {"type": "number"},
+		"subtotal": {"type": "number"},
+		"discount": {"type": "number"},
+		"tax": {"type": "number"},
+		"category": {"type": "string"}
+	},
+	"required": ["name", "quantity", "unit_price", "subtotal", "discount", "tax", "category"],
+	"additionalProperties": false
+}`
+
+var (
+	textResponseSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"transactions": {"type": "array", "items": ` + sprintfSchema(transactionSchema, "", "") + `}
+	},
+	"required": ["transactions"],
+	"additionalProperties": false
+}`)
+
+	visionResponseSchema = json.RawMessage(sprintfSchema(transactionSchema,
+		`,
+		"items": {"type": "array", "items": `+itemSchema+`}`,
+		`, "items"`))
+)
+
+func sprintfSchema(schema, extraProperty, extraRequired string) string {
+	return strings.Replace(strings.Replace(schema, "%s", extraProperty, 1), "%s", extraRequired, 1)
+}
+
+// structuredOutputModels are model prefixes that accept a strict JSON schema
+// as response format. Other models, including most self-hosted ones, get
+// plain JSON mode and rely on validation alone.
+var structuredOutputModels = []string{"gpt-4o", "gpt-4.1", "gpt-5", "o1", "o3", "o4"}
+
+func supportsStructuredOutput(model string) bool {
+	for _, prefix := range structuredOutputModels {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseFormat returns a strict JSON schema format when the model supports
+// it, and JSON mode otherwise
+func responseFormat(model, name string, schema json.RawMessage) *openai.ChatCompletionResponseFormat {
+	if !supportsStructuredOutput(model) {
+		return &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		}
+	}
+
+	return &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   name,
+			Schema: schema,
+			Strict: true,
+		},
+	}
+}
+
+// queryPlanSchema describes a ledger question plan
+var queryPlanSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"type": {"type": "string", "enum": ["INCOME", "EXPENSE", ""]},
+		"categories": {"type": "array", "items": {"type": "string"}},
+		"keywords": {"type": "array", "items": {"type": "string"}},
+		"start_date": {"type": "string", "description": "YYYY-MM-DD"},
+		"end_date": {"type": "string", "description": "YYYY-MM-DD, inclusive"},
+		"aggregation": {"type": "string", "enum": ["sum", "count", "avg", "max", "top"]},
+		"limit": {"type": "integer"}
+	},
+	"required": ["type", "categories", "keywords", "start_date", "end_date", "aggregation", "limit"],
+	"additionalProperties": false
+}`)
+
+// intentSchema describes an intent classification
+var intentSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"intent": {"type": "string", "enum": ["record", "report", "query", "undo", "edit", "delete", "settings", "help", "smalltalk"]},
+		"period": {"type": "string", "enum": ["", "today", "week", "month"]},
+		"setting": {"type": "string", "enum": ["", "category", "language"]},
+		"confidence": {"type": "number"}
+	},
+	"required": ["intent", "period", "setting", "confidence"],
+	"additionalProperties": false
+}`)
@@ -0,0 +1,163 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// ShadowStore persists shadow runs of candidate prompts
+type ShadowStore interface {
+	Create(ctx context.Context, run *domain.PromptShadowRun) error
+}
+
+// shadowTimeout bounds a candidate run, which outlives the message it was
+// sampled from
+const shadowTimeout = 60 * time.Second
+
+// shadow samples traffic for a candidate prompt and records how its output
+// compares with the live one
+type shadow struct {
+	percent int
+	store   ShadowStore
+}
+
+func (s shadow) sample() bool {
+	return s.percent > 0 && rand.IntN(100) < s.percent
+}
+
+// run calls the candidate in the background, so the user never waits for
+// it. ctx keeps its values (user, WA message) but not its deadline.
+func (s shadow) run(ctx context.Context, callType, primaryVersion, candidateVersion string, primary interface{}, candidate func(context.Context) (interface{}, error)) {
+	// Snapshot now; the handler goes on to modify the primary result
+	primaryOutput, _ := json.Marshal(primary)
+	var snapshot []domain.ParsedTransaction
+	for _, pt := range asList(primary) {
+		snapshot = append(snapshot, *pt)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shadowTimeout)
+		defer cancel()
+
+		record := &domain.PromptShadowRun{
+			CallType:         callType,
+			PrimaryVersion:   primaryVersion,
+			CandidateVersion: candidateVersion,
+			PrimaryOutput:    primaryOutput,
+		}
+		if info, ok := ctx.Value(callInfoKey{}).(callInfo); ok {
+			userID := info.userID
+			record.UserID = &userID
+			record.WAMessageID = info.waMessageID
+		}
+
+		result, err := candidate(ctx)
+		if err != nil {
+			record.CandidateError = err.Error()
+		} else {
+			record.CandidateOutput, _ = json.Marshal(result)
+			record.Agrees = agrees(snapshot, asList(result))
+		}
+		log.Printf("Shadow %s: %s vs %s agrees=%v error=%q", callType, primaryVersion, candidateVersion, record.Agrees, record.CandidateError)
+
+		if s.store == nil {
+			return
+		}
+		if err := s.store.Create(ctx, record); err != nil {
+			log.Printf("Failed to record shadow run: %v", err)
+		}
+	}()
+}
+
+// agrees compares the fields that end up in the ledger: type, amount,
+// category and date of every transaction
+func agrees(x []domain.ParsedTransaction, y []*domain.ParsedTransaction) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i].Type != y[i].Type ||
+			math.Abs(x[i].Amount-y[i].Amount) >= 0.5 ||
+			!strings.EqualFold(x[i].Category, y[i].Category) ||
+			x[i].Date.Format("2006-01-02") != y[i].Date.Format("2006-01-02") {
+			return false
+		}
+	}
+	return true
+}
+
+func asList(v interface{}) []*domain.ParsedTransaction {
+	switch v := v.(type) {
+	case []*domain.ParsedTransaction:
+		return v
+	case *domain.ParsedTransaction:
+		if v != nil {
+			return []*domain.ParsedTransaction{v}
+		}
+	}
+	return nil
+}
+
+// ShadowParser answers with the live prompt and runs a candidate prompt on a
+// percentage of messages, logging its output for comparison
+type ShadowParser struct {
+	primary   *TextParser
+	candidate *TextParser
+	shadow    shadow
+}
+
+// NewShadowParser runs candidate on percent of the messages primary parses;
+// store may be nil to only log
+func NewShadowParser(primary *TextParser, candidate *Prompt, percent int, store ShadowStore) *ShadowParser {
+	return &ShadowParser{
+		primary:   primary,
+		candidate: primary.WithPrompt(candidate),
+		shadow:    shadow{percent: percent, store: store},
+	}
+}
+
+func (p *ShadowParser) Parse(ctx context.Context, message string) ([]*domain.ParsedTransaction, error) {
+	parsed, err := p.primary.Parse(ctx, message)
+	if err == nil && p.shadow.sample() {
+		p.shadow.run(ctx, domain.AICallText, p.primary.Version(), p.candidate.Version(), parsed,
+			func(ctx context.Context) (interface{}, error) {
+				return p.candidate.Parse(ctx, message)
+			})
+	}
+	return parsed, err
+}
+
+// ShadowImageParser is ShadowParser for receipt images
+type ShadowImageParser struct {
+	primary   *VisionParser
+	candidate *VisionParser
+	shadow    shadow
+}
+
+// NewShadowImageParser runs candidate on percent of the images primary
+// parses; store may be nil to only log
+func NewShadowImageParser(primary *VisionParser, candidate *Prompt, percent int, store ShadowStore) *ShadowImageParser {
+	return &ShadowImageParser{
+		primary:   primary,
+		candidate: primary.WithPrompt(candidate),
+		shadow:    shadow{percent: percent, store: store},
+	}
+}
+
+func (p *ShadowImageParser) ParseImage(ctx context.Context, imageData []byte, mimeType, caption string) (*domain.ParsedTransaction, error) {
+	parsed, err := p.primary.ParseImage(ctx, imageData, mimeType, caption)
+	if err == nil && p.shadow.sample() {
+		p.shadow.run(ctx, domain.AICallVision, p.primary.Version(), p.candidate.Version(), parsed,
+			func(ctx context.Context) (interface{}, error) {
+				return p.candidate.ParseImage(ctx, imageData, mimeType, caption)
+			})
+	}
+	return parsed, err
+}
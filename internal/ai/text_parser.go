@@ -3,9 +3,10 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -16,132 +17,146 @@ import (
 type TextParser struct {
 	client   *openai.Client
 	model    string
+	prompt   *Prompt
 	timezone *time.Location
+	usage    UsageStore
 }
 
-func NewTextParser(apiKey, model string, timezone *time.Location) *TextParser {
+// textPromptData fills in prompts/text.tmpl
+type textPromptData struct {
+	Today        string
+	ResolvedDate *ResolvedDate
+}
+
+// NewTextParser creates a parser; usage may be nil to skip call accounting
+func NewTextParser(apiKey, baseURL, model string, timezone *time.Location, usage UsageStore) *TextParser {
 	return &TextParser{
-		client:   openai.NewClient(apiKey),
+		client:   NewOpenAIClient(apiKey, baseURL),
 		model:    model,
+		prompt:   mustLoadPrompt(PromptText),
 		timezone: timezone,
+		usage:    usage,
 	}
 }
 
-func (p *TextParser) Parse(ctx context.Context, message string) (*domain.ParsedTransaction, error) {
-	// Normalize Indonesian slang
-	normalized := p.normalizeAmount(message)
+// WithPrompt returns a copy of the parser that uses another prompt, e.g. a
+// candidate to run in shadow mode
+func (p *TextParser) WithPrompt(prompt *Prompt) *TextParser {
+	clone := *p
+	clone.prompt = prompt
+	return &clone
+}
 
-	// Get current date in user's timezone
-	today := time.Now().In(p.timezone).Format("2006-01-02")
-
-	systemPrompt := fmt.Sprintf(`You are a financial transaction parser for Indonesian users.
-Parse the message into a structured transaction.
-
-IMPORTANT: Today's date is %s. Use this as the default date if no date is mentioned.
-
-Rules:
-1. Determine if it's INCOME or EXPENSE
-2. Extract the amount (handle "rb" = ribu/1000, "jt" = juta/1000000)
-3. Identify category (e.g., "gaji", "makan", "transport", "belanja")
-4. Extract description
-5. Parse date if mentioned, otherwise use TODAY (%s)
-6. Provide confidence score (0.0-1.0)
-
-Return ONLY valid JSON in this exact format:
-{
-  "type": "INCOME" or "EXPENSE",
-  "amount": number,
-  "category": "string",
-  "description": "string",
-  "date": "YYYY-MM-DD",
-  "confidence": 0.0-1.0
+// Version is stored as ai_version on the transactions the parser produces
+func (p *TextParser) Version() string {
+	return aiVersion(p.model, p.prompt)
 }
 
-Examples:
-- "catat pemasukan 10000 gaji" → INCOME, 10000, "gaji", "gaji", %s, 0.95
-- "beli bensin 50rb" → EXPENSE, 50000, "transport", "beli bensin", %s, 0.9
-- "dapat uang dari jual motor 20 juta" → INCOME, 20000000, "penjualan", "jual motor", %s, 0.85`, today, today, today, today, today)
-
-	resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: p.model,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
-			{Role: openai.ChatMessageRoleUser, Content: normalized},
-		},
-		Temperature: 0.3,
-		ResponseFormat: &openai.ChatCompletionResponseFormat{
-			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
-		},
-	})
+// Parse extracts every transaction mentioned in the message, e.g.
+// "beli kopi 20rb, bensin 50rb" yields two transactions
+func (p *TextParser) Parse(ctx context.Context, message string) ([]*domain.ParsedTransaction, error) {
+	// Spell out amounts as plain numbers ("1,5jt" → "1500000")
+	normalized := NormalizeAmounts(message)
 
+	// Get current date in user's timezone
+	now := SentAt(ctx).In(p.timezone)
+	today := now.Format("2006-01-02")
+
+	// Dates the resolver understands aren't left to the model. Messages with
+	// several dates are, since each transaction may have its own.
+	resolved, err := ResolveDate(message, now)
 	if err != nil {
-		return nil, fmt.Errorf("openai API error: %w", err)
+		log.Printf("Leaving dates to the model: %v", err)
+	}
+
+	systemPrompt, err := p.prompt.Render(textPromptData{Today: today, ResolvedDate: resolved})
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt += categoryPrompt(ctx)
+	systemPrompt += correctionPrompt(ctx)
+
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: normalized},
+	}
+
+	for attempt := 0; ; attempt++ {
+		call := startCall(ctx, domain.AICallText, p.model)
+		call.PromptVersion = p.prompt.ID()
+		resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+			Model:          p.model,
+			Messages:       messages,
+			Temperature:    0.3,
+			ResponseFormat: responseFormat(p.model, "transactions", textResponseSchema),
+		})
+
+		if err != nil {
+			finishCall(p.usage, call, resp.Usage, requestOutcome(err), err)
+			return nil, fmt.Errorf("openai API error: %w", err)
+		}
+
+		parsed, err := p.decode(resp, now)
+		finishCall(p.usage, call, resp.Usage, responseOutcome(err), err)
+		if err == nil {
+			applyResolvedDate(parsed, resolved, now)
+			for _, pt := range parsed {
+				pt.AIVersion = p.Version()
+			}
+		}
+
+		var invalid *ValidationError
+		if err == nil || !errors.As(err, &invalid) || attempt >= maxRepairAttempts {
+			return parsed, err
+		}
+
+		// Feed the problems back so the model can fix its own answer
+		log.Printf("Asking model to repair response: %v", err)
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: resp.Choices[0].Message.Content},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: repairPrompt(err)},
+		)
 	}
+}
 
+// decode turns a completion into validated transactions. Malformed output is
+// reported as a *ValidationError so it can be repaired.
+func (p *TextParser) decode(resp openai.ChatCompletionResponse, now time.Time) ([]*domain.ParsedTransaction, error) {
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no response from AI")
 	}
 
 	content := resp.Choices[0].Message.Content
 
-	// Parse JSON response
 	var result struct {
-		Type        string  `json:"type"`
-		Amount      float64 `json:"amount"`
-		Category    string  `json:"category"`
-		Description string  `json:"description"`
-		Date        string  `json:"date"`
-		Confidence  float64 `json:"confidence"`
+		Transactions []rawTransaction `json:"transactions"`
 	}
 
 	if err := json.Unmarshal([]byte(content), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("response is not valid JSON (%v)", err)}}
 	}
 
-	// Parse date
-	txDate, err := time.ParseInLocation("2006-01-02", result.Date, p.timezone)
-	if err != nil {
-		// Default to today if parsing fails
-		txDate = time.Now().In(p.timezone)
+	if len(result.Transactions) == 0 {
+		return nil, fmt.Errorf("no transaction in AI response")
 	}
 
-	return &domain.ParsedTransaction{
-		Type:        result.Type,
-		Amount:      result.Amount,
-		Category:    result.Category,
-		Description: result.Description,
-		Date:        txDate,
-		Confidence:  result.Confidence,
-	}, nil
-}
-
-// normalizeAmount converts Indonesian slang to numbers
-func (p *TextParser) normalizeAmount(text string) string {
-	// Convert "50rb" → "50000", "20jt" → "20000000"
-
-	// Match patterns like "50rb", "50 rb", "50ribu"
-	reRibu := regexp.MustCompile(`(\d+)\s*(rb|ribu)`)
-	text = reRibu.ReplaceAllStringFunc(text, func(match string) string {
-		parts := reRibu.FindStringSubmatch(match)
-		if len(parts) >= 2 {
-			num, _ := strconv.Atoi(parts[1])
-			return strconv.Itoa(num * 1000)
+	var problems []string
+	parsed := make([]*domain.ParsedTransaction, 0, len(result.Transactions))
+	for i, raw := range result.Transactions {
+		pt, txProblems := raw.validate(now)
+		for _, problem := range txProblems {
+			problems = append(problems, fmt.Sprintf("transactions[%d]: %s", i, problem))
 		}
-		return match
-	})
-
-	// Match patterns like "20jt", "20 jt", "20juta"
-	reJuta := regexp.MustCompile(`(\d+)\s*(jt|juta)`)
-	text = reJuta.ReplaceAllStringFunc(text, func(match string) string {
-		parts := reJuta.FindStringSubmatch(match)
-		if len(parts) >= 2 {
-			num, _ := strconv.Atoi(parts[1])
-			return strconv.Itoa(num * 1000000)
+		if pt != nil {
+			parsed = append(parsed, pt)
 		}
-		return match
-	})
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
 
-	return text
+	return parsed, nil
 }
 
 // ShouldTriggerParsing checks if message should trigger transaction parsing
@@ -156,9 +171,8 @@ func ShouldTriggerParsing(message string) bool {
 		}
 	}
 
-	// Check for amount patterns (numbers with rb/jt or just numbers with context)
-	hasAmount := regexp.MustCompile(`\d+\s*(rb|ribu|jt|juta|rupiah|rp)`).MatchString(message)
-	if hasAmount {
+	// Amounts marked as money ("50rb", "Rp 20.000", "goceng")
+	if HasExplicitAmount(message) {
 		return true
 	}
 
@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Transcriber converts a voice note into text. lang is the user's reply
+// language, a hint for what they are likely to speak.
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio []byte, mimeType, lang string) (string, error)
+}
+
+// transcriptionHints are the spoken language and a vocabulary prompt per reply
+// language. Javanese speakers often switch to Indonesian mid-sentence, so
+// their language is left for the model to detect.
+var transcriptionHints = map[string]struct{ language, prompt string }{
+	domain.LangIndonesian: {"id", "Catatan keuangan: beli, bayar, gaji, transfer, ribu, juta."},
+	domain.LangEnglish:    {"en", "Expense notes: bought, paid, salary, transfer, thousand, million, rupiah, 45k."},
+	domain.LangJavanese:   {"", "Cathetan dhuwit: tuku, bayar, gajian, transfer, ewu, yuta, ribu, juta."},
+}
+
+// OpenAITranscriber uses an OpenAI-compatible /audio/transcriptions endpoint
+// (OpenAI Whisper, or a self-hosted whisper server when baseURL is set)
+type OpenAITranscriber struct {
+	client *openai.Client
+	model  string
+}
+
+func NewOpenAITranscriber(apiKey, baseURL, model string) *OpenAITranscriber {
+	return &OpenAITranscriber{
+		client: NewOpenAIClient(apiKey, baseURL),
+		model:  model,
+	}
+}
+
+func (t *OpenAITranscriber) Transcribe(ctx context.Context, audio []byte, mimeType, lang string) (string, error) {
+	// Unknown languages get no hint, so the model detects the language
+	hint := transcriptionHints[lang]
+	resp, err := t.client.CreateTranscription(ctx, openai.AudioRequest{
+		Model: t.model,
+		// The file name only tells the server which decoder to use
+		FilePath: "voice" + audioExtension(mimeType),
+		Reader:   bytes.NewReader(audio),
+		Language: hint.language,
+		Prompt:   hint.prompt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("transcription API error: %w", err)
+	}
+
+	text := strings.TrimSpace(resp.Text)
+	if text == "" {
+		return "", fmt.Errorf("empty transcription")
+	}
+
+	return text, nil
+}
+
+// audioExtension maps WhatsApp audio mime types to file extensions
+func audioExtension(mimeType string) string {
+	mimeType = strings.ToLower(mimeType)
+	switch {
+	case strings.Contains(mimeType, "ogg"), strings.Contains(mimeType, "opus"):
+		return ".ogg"
+	case strings.Contains(mimeType, "mpeg"), strings.Contains(mimeType, "mp3"):
+		return ".mp3"
+	case strings.Contains(mimeType, "mp4"), strings.Contains(mimeType, "m4a"), strings.Contains(mimeType, "aac"):
+		return ".m4a"
+	case strings.Contains(mimeType, "wav"):
+		return ".wav"
+	case strings.Contains(mimeType, "webm"):
+		return ".webm"
+	}
+	// WhatsApp voice notes are ogg/opus
+	return ".ogg"
+}
+
+// StaticTranscriber returns a fixed transcript, for local runs without a
+// speech-to-text backend
+type StaticTranscriber struct {
+	Text string
+}
+
+func (t *StaticTranscriber) Transcribe(ctx context.Context, audio []byte, mimeType, lang string) (string, error) {
+	if t.Text == "" {
+		return "", fmt.Errorf("empty transcription")
+	}
+	return t.Text, nil
+}
@@ -0,0 +1,70 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+func TestAudioExtension(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		want     string
+	}{
+		{"audio/ogg; codecs=opus", ".ogg"},
+		{"audio/opus", ".ogg"},
+		{"audio/mpeg", ".mp3"},
+		{"audio/mp4", ".m4a"},
+		{"audio/aac", ".m4a"},
+		{"audio/wav", ".wav"},
+		{"audio/webm", ".webm"},
+		{"", ".ogg"},
+	}
+
+	for _, tt := range tests {
+		if got := audioExtension(tt.mimeType); got != tt.want {
+			t.Errorf("audioExtension(%q) = %q, want %q", tt.mimeType, got, tt.want)
+		}
+	}
+}
+
+func TestTranscribeLanguage(t *testing.T) {
+	var language string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		language = r.FormValue("language")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"text": "lunch forty five k"})
+	}))
+	defer srv.Close()
+
+	tr := NewOpenAITranscriber("test", srv.URL+"/v1", "whisper-1")
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{domain.LangIndonesian, "id"},
+		{domain.LangEnglish, "en"},
+		// Left for the model to detect
+		{domain.LangJavanese, ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		language = "unset"
+		if _, err := tr.Transcribe(context.Background(), []byte("OggS"), "audio/ogg", tt.lang); err != nil {
+			t.Fatalf("Transcribe(%q): %v", tt.lang, err)
+		}
+		if language != tt.want {
+			t.Errorf("Transcribe(%q) sent language %q, want %q", tt.lang, language, tt.want)
+		}
+	}
+}
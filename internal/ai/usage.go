@@ -0,0 +1,119 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// UsageStore persists AI call records
+type UsageStore interface {
+	Create(ctx context.Context, call *domain.AICall) error
+}
+
+type callInfoKey struct{}
+type attemptKey struct{}
+
+type callInfo struct {
+	userID      int64
+	waMessageID string
+}
+
+// WithCallInfo tags AI calls made with ctx with the user and WA message
+// they were made for
+func WithCallInfo(ctx context.Context, userID int64, waMessageID string) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, callInfo{userID: userID, waMessageID: waMessageID})
+}
+
+// withAttempt records which retry attempt (0 = first try) ctx belongs to
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// modelPrices are USD per 1M prompt and completion tokens. Models are matched
+// by longest prefix so dated snapshots ("gpt-4o-mini-2024-07-18") are priced
+// too; unknown models, e.g. self-hosted ones, cost nothing.
+var modelPrices = map[string][2]float64{
+	"gpt-4o-mini":   {0.15, 0.60},
+	"gpt-4o":        {2.50, 10.00},
+	"gpt-4.1-nano":  {0.10, 0.40},
+	"gpt-4.1-mini":  {0.40, 1.60},
+	"gpt-4.1":       {2.00, 8.00},
+	"gpt-3.5-turbo": {0.50, 1.50},
+}
+
+// EstimateCost returns the USD cost of a call
+func EstimateCost(model string, promptTokens, completionTokens int) float64 {
+	var price [2]float64
+	var matched string
+	for prefix, p := range modelPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(matched) {
+			matched, price = prefix, p
+		}
+	}
+	return (float64(promptTokens)*price[0] + float64(completionTokens)*price[1]) / 1_000_000
+}
+
+// startCall begins an AI call record, picking up user, message and retry
+// attempt from ctx
+func startCall(ctx context.Context, callType, model string) *domain.AICall {
+	call := &domain.AICall{
+		CallType:  callType,
+		Model:     model,
+		CreatedAt: time.Now(),
+	}
+	if info, ok := ctx.Value(callInfoKey{}).(callInfo); ok {
+		userID := info.userID
+		call.UserID = &userID
+		call.WAMessageID = info.waMessageID
+	}
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		call.RetryCount = attempt
+	}
+	return call
+}
+
+// finishCall fills in tokens, latency and outcome and stores the record.
+// Accounting failures are logged and never fail the parse.
+func finishCall(store UsageStore, call *domain.AICall, usage openai.Usage, outcome string, err error) {
+	if store == nil {
+		return
+	}
+
+	call.LatencyMs = time.Since(call.CreatedAt).Milliseconds()
+	call.PromptTokens = usage.PromptTokens
+	call.CompletionTokens = usage.CompletionTokens
+	call.CostUSD = EstimateCost(call.Model, usage.PromptTokens, usage.CompletionTokens)
+	call.Outcome = outcome
+	if err != nil {
+		call.Error = err.Error()
+	}
+
+	// The parse context may already be cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.Create(ctx, call); err != nil {
+		log.Printf("Failed to record AI call: %v", err)
+	}
+}
+
+// requestOutcome classifies an error from the completion request itself
+func requestOutcome(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return domain.AIOutcomeTimeout
+	}
+	return domain.AIOutcomeAPIError
+}
+
+// responseOutcome classifies the result of decoding a completion
+func responseOutcome(err error) string {
+	if err != nil {
+		return domain.AIOutcomeInvalidResponse
+	}
+	return domain.AIOutcomeSuccess
+}
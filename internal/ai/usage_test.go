@@ -0,0 +1,153 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// memoryUsage records AI calls in memory
+type memoryUsage struct {
+	mu    sync.Mutex
+	calls []*domain.AICall
+}
+
+func (m *memoryUsage) Create(ctx context.Context, call *domain.AICall) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, call)
+	return nil
+}
+
+// fakeOpenAI serves one chat completion per request from contents, each
+// using 100 prompt and 20 completion tokens
+func fakeOpenAI(t *testing.T, contents ...string) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(contents) == 0 {
+			http.Error(w, `{"error":{"message":"no more responses"}}`, http.StatusBadRequest)
+			return
+		}
+		content := contents[0]
+		contents = contents[1:]
+
+		resp := completion(content)
+		resp.Usage = openai.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEstimateCost(t *testing.T) {
+	tests := []struct {
+		model              string
+		prompt, completion int
+		want               float64
+	}{
+		{"gpt-4o-mini", 1_000_000, 0, 0.15},
+		{"gpt-4o-mini-2024-07-18", 0, 1_000_000, 0.60},
+		{"gpt-4o", 1_000_000, 1_000_000, 12.50},
+		{"gpt-4.1-nano", 2_000_000, 0, 0.20},
+		{"llama3", 1_000_000, 1_000_000, 0},
+	}
+
+	for _, tt := range tests {
+		if got := EstimateCost(tt.model, tt.prompt, tt.completion); got != tt.want {
+			t.Errorf("EstimateCost(%q, %d, %d) = %v, want %v", tt.model, tt.prompt, tt.completion, got, tt.want)
+		}
+	}
+}
+
+func TestStartCall(t *testing.T) {
+	call := startCall(context.Background(), domain.AICallText, "gpt-4o-mini")
+	if call.UserID != nil || call.WAMessageID != "" || call.RetryCount != 0 {
+		t.Errorf("untagged call = %+v, want no user, message or retries", call)
+	}
+
+	ctx := withAttempt(WithCallInfo(context.Background(), 42, "wamid.1"), 2)
+	call = startCall(ctx, domain.AICallText, "gpt-4o-mini")
+	if call.UserID == nil || *call.UserID != 42 || call.WAMessageID != "wamid.1" || call.RetryCount != 2 {
+		t.Errorf("tagged call = %+v, want user 42, wamid.1, retry 2", call)
+	}
+}
+
+func TestOutcomes(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"deadline", requestOutcome(context.DeadlineExceeded), domain.AIOutcomeTimeout},
+		{"cancelled", requestOutcome(context.Canceled), domain.AIOutcomeTimeout},
+		{"api error", requestOutcome(&openai.APIError{HTTPStatusCode: 500}), domain.AIOutcomeAPIError},
+		{"bad response", responseOutcome(&ValidationError{Problems: []string{"amount is missing"}}), domain.AIOutcomeInvalidResponse},
+		{"ok", responseOutcome(nil), domain.AIOutcomeSuccess},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: outcome = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestTextParserRecordsUsage(t *testing.T) {
+	srv := fakeOpenAI(t, `{"transactions":[{"type":"EXPENSE","amount":20000,"category":"makan","description":"kopi","confidence":0.9}]}`)
+	usage := &memoryUsage{}
+	p := NewTextParser("test", srv.URL+"/v1", "gpt-4o-mini", time.UTC, usage)
+
+	ctx := WithCallInfo(context.Background(), 7, "wamid.2")
+	if _, err := p.Parse(ctx, "kopi 20rb"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if len(usage.calls) != 1 {
+		t.Fatalf("recorded %d calls, want 1", len(usage.calls))
+	}
+	call := usage.calls[0]
+	if call.CallType != domain.AICallText || call.Outcome != domain.AIOutcomeSuccess {
+		t.Errorf("call = %s/%s, want %s/%s", call.CallType, call.Outcome, domain.AICallText, domain.AIOutcomeSuccess)
+	}
+	if call.UserID == nil || *call.UserID != 7 || call.WAMessageID != "wamid.2" {
+		t.Errorf("call not tagged with user 7 and wamid.2: %+v", call)
+	}
+	if call.PromptTokens != 100 || call.CompletionTokens != 20 {
+		t.Errorf("tokens = %d/%d, want 100/20", call.PromptTokens, call.CompletionTokens)
+	}
+	if want := EstimateCost("gpt-4o-mini", 100, 20); call.CostUSD != want {
+		t.Errorf("cost = %v, want %v", call.CostUSD, want)
+	}
+	if call.PromptVersion == "" {
+		t.Error("prompt version not recorded")
+	}
+}
+
+func TestTextParserRecordsFailedCall(t *testing.T) {
+	srv := fakeOpenAI(t)
+	usage := &memoryUsage{}
+	p := NewTextParser("test", srv.URL+"/v1", "gpt-4o-mini", time.UTC, usage)
+
+	if _, err := p.Parse(context.Background(), "kopi 20rb"); err == nil {
+		t.Fatal("Parse succeeded against a failing API")
+	}
+
+	if len(usage.calls) != 1 {
+		t.Fatalf("recorded %d calls, want 1", len(usage.calls))
+	}
+	if call := usage.calls[0]; call.Outcome != domain.AIOutcomeAPIError || call.Error == "" {
+		t.Errorf("call = %q (%q), want %q with the error", call.Outcome, call.Error, domain.AIOutcomeAPIError)
+	}
+}
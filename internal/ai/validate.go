@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// maxRepairAttempts is how many times an invalid response is sent back to the
+// model together with the validation error
+const maxRepairAttempts = 1
+
+// maxAmount keeps amounts inside the transactions.amount DECIMAL(15,2) column
+const maxAmount = 1e13
+
+var typeSynonyms = map[string]string{
+	"INCOME": domain.TypeIncome, "IN": domain.TypeIncome, "CREDIT": domain.TypeIncome,
+	"MASUK": domain.TypeIncome, "PEMASUKAN": domain.TypeIncome,
+	"EXPENSE": domain.TypeExpense, "OUT": domain.TypeExpense, "DEBIT": domain.TypeExpense,
+	"SPEND": domain.TypeExpense, "SPENDING": domain.TypeExpense,
+	"KELUAR": domain.TypeExpense, "PENGELUARAN": domain.TypeExpense,
+}
+
+// ValidationError lists what was wrong with a model response. The message is
+// written for the model, which gets it back when asked for a repair.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid AI response: " + strings.Join(e.Problems, "; ")
+}
+
+// rawTransaction is a transaction as the model returned it
+type rawTransaction struct {
+	Type        string     `json:"type"`
+	Amount      flexAmount `json:"amount"`
+	Category    string     `json:"category"`
+	Description string     `json:"description"`
+	Date        string     `json:"date"`
+	Confidence  float64    `json:"confidence"`
+}
+
+// flexAmount accepts numbers and amount strings ("50000", "Rp 50.000", "1,5jt")
+type flexAmount struct {
+	value float64
+	raw   string
+	valid bool
+}
+
+func (a *flexAmount) UnmarshalJSON(data []byte) error {
+	a.raw = string(data)
+
+	var number float64
+	if err := json.Unmarshal(data, &number); err == nil {
+		a.value, a.valid = number, true
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		// Objects, arrays and null are reported by validate, not here
+		return nil
+	}
+
+	// Exactly one amount; "50rb atau 60rb" is not a number
+	if tokens := TokenizeAmounts(text); len(tokens) == 1 {
+		a.value, a.valid = tokens[0].Value, true
+	}
+	return nil
+}
+
+// validate normalises the transaction and returns every problem found. A
+// missing date means the day of now.
+func (r *rawTransaction) validate(now time.Time) (*domain.ParsedTransaction, []string) {
+	// Entries the model itself doubts are rejected downstream anyway, so a
+	// missing amount or type there isn't worth a repair round
+	unsure := &domain.ParsedTransaction{Description: r.Description, Confidence: normalizeConfidence(r.Confidence)}
+	if unsure.ShouldReject() {
+		return unsure, nil
+	}
+
+	var problems []string
+
+	txType, ok := typeSynonyms[strings.ToUpper(strings.TrimSpace(r.Type))]
+	if !ok {
+		problems = append(problems, fmt.Sprintf(`type %q must be "INCOME" or "EXPENSE"`, r.Type))
+	}
+
+	// Models sometimes sign expenses as negative numbers
+	amount := math.Abs(r.Amount.value)
+	switch {
+	case r.Amount.raw == "":
+		problems = append(problems, "amount is missing")
+	case !r.Amount.valid:
+		problems = append(problems, fmt.Sprintf("amount %s must be a number", r.Amount.raw))
+	case amount == 0:
+		problems = append(problems, "amount must be greater than 0")
+	case amount >= maxAmount:
+		problems = append(problems, fmt.Sprintf("amount %.0f is too large", amount))
+	}
+
+	date, err := parseResponseDate(r.Date, now)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	category := truncateRunes(strings.ToLower(strings.TrimSpace(r.Category)), 100)
+	if category == "" {
+		category = "lainnya"
+	}
+	description := strings.TrimSpace(r.Description)
+	if description == "" {
+		description = category
+	}
+
+	return &domain.ParsedTransaction{
+		Type:        txType,
+		Amount:      math.Round(amount*100) / 100,
+		Category:    category,
+		Description: description,
+		Date:        date,
+		Confidence:  normalizeConfidence(r.Confidence),
+	}, nil
+}
+
+// parseResponseDate accepts YYYY-MM-DD (and a few near misses) in the
+// timezone of now; an empty date means the day of now
+func parseResponseDate(value string, now time.Time) (time.Time, error) {
+	timezone := now.Location()
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, timezone), nil
+	}
+
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05", "02/01/2006"} {
+		if date, err := time.ParseInLocation(layout, value, timezone); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("date %q must be YYYY-MM-DD", value)
+}
+
+// normalizeConfidence clamps to [0, 1], reading 1-100 as a percentage
+func normalizeConfidence(c float64) float64 {
+	if c > 1 && c <= 100 {
+		c /= 100
+	}
+	return math.Max(0, math.Min(1, c))
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// repairPrompt asks the model to fix its previous answer
+func repairPrompt(err error) string {
+	return fmt.Sprintf("Your previous response was rejected: %v. Return the corrected JSON only, in the exact format requested.", err)
+}
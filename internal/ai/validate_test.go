@@ -0,0 +1,155 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestTextDecodeNormalizes(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	now := time.Date(2026, 3, 18, 14, 0, 0, 0, wib)
+	p := &TextParser{}
+
+	tests := []struct {
+		name    string
+		content string
+		want    domain.ParsedTransaction
+	}{
+		{"amount string", `{"transactions":[{"type":"EXPENSE","amount":"Rp 50.000","category":"Makan","description":"nasi padang","date":"2026-03-17","confidence":0.9}]}`,
+			domain.ParsedTransaction{Type: domain.TypeExpense, Amount: 50000, Category: "makan", Description: "nasi padang", Date: time.Date(2026, 3, 17, 0, 0, 0, 0, wib), Confidence: 0.9}},
+		{"negative amount and type synonym", `{"transactions":[{"type":"pengeluaran","amount":-20000,"category":"","description":"","confidence":0.8}]}`,
+			domain.ParsedTransaction{Type: domain.TypeExpense, Amount: 20000, Category: "lainnya", Description: "lainnya", Date: time.Date(2026, 3, 18, 0, 0, 0, 0, wib), Confidence: 0.8}},
+		{"percentage confidence", `{"transactions":[{"type":"masuk","amount":"1,5jt","category":"gaji","description":"gaji","date":"18/03/2026","confidence":85}]}`,
+			domain.ParsedTransaction{Type: domain.TypeIncome, Amount: 1500000, Category: "gaji", Description: "gaji", Date: time.Date(2026, 3, 18, 0, 0, 0, 0, wib), Confidence: 0.85}},
+	}
+
+	for _, tt := range tests {
+		parsed, err := p.decode(completion(tt.content), now)
+		if err != nil {
+			t.Errorf("%s: decode: %v", tt.name, err)
+			continue
+		}
+		if len(parsed) != 1 {
+			t.Errorf("%s: %d transactions, want 1", tt.name, len(parsed))
+			continue
+		}
+		got := *parsed[0]
+		if got.Type != tt.want.Type || got.Amount != tt.want.Amount || got.Category != tt.want.Category ||
+			got.Description != tt.want.Description || !got.Date.Equal(tt.want.Date) || got.Confidence != tt.want.Confidence {
+			t.Errorf("%s: decoded %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTextDecodeProblems(t *testing.T) {
+	now := time.Date(2026, 3, 18, 14, 0, 0, 0, time.UTC)
+	p := &TextParser{}
+
+	tests := []struct {
+		name    string
+		content string
+		problem string
+	}{
+		{"not json", `Kopi 20rb ya kak`, "not valid JSON"},
+		{"bad type", `{"transactions":[{"type":"TRANSFER","amount":20000,"confidence":0.9}]}`, `type "TRANSFER"`},
+		{"missing amount", `{"transactions":[{"type":"EXPENSE","confidence":0.9}]}`, "amount is missing"},
+		{"two amounts", `{"transactions":[{"type":"EXPENSE","amount":"50rb atau 60rb","confidence":0.9}]}`, "must be a number"},
+		{"zero amount", `{"transactions":[{"type":"EXPENSE","amount":0,"confidence":0.9}]}`, "greater than 0"},
+		{"huge amount", `{"transactions":[{"type":"EXPENSE","amount":1e14,"confidence":0.9}]}`, "too large"},
+		{"bad date", `{"transactions":[{"type":"EXPENSE","amount":20000,"date":"kemarin","confidence":0.9}]}`, "YYYY-MM-DD"},
+		{"second transaction", `{"transactions":[{"type":"EXPENSE","amount":20000,"confidence":0.9},{"type":"EXPENSE","confidence":0.9}]}`, "transactions[1]: amount is missing"},
+	}
+
+	for _, tt := range tests {
+		_, err := p.decode(completion(tt.content), now)
+		var invalid *ValidationError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s: decode error = %v, want a *ValidationError", tt.name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.problem) {
+			t.Errorf("%s: decode error = %q, want it to mention %q", tt.name, err, tt.problem)
+		}
+	}
+}
+
+func TestTextDecodeSkipsDoubtfulEntries(t *testing.T) {
+	now := time.Date(2026, 3, 18, 14, 0, 0, 0, time.UTC)
+	p := &TextParser{}
+
+	// The model doubts the second entry, so its missing amount isn't repaired
+	parsed, err := p.decode(completion(`{"transactions":[
+		{"type":"EXPENSE","amount":20000,"category":"makan","description":"kopi","confidence":0.9},
+		{"type":"","description":"itu","confidence":0.2}]}`), now)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(parsed) != 2 || !parsed[1].ShouldReject() {
+		t.Errorf("decoded %d transactions, want kopi and a rejected second entry", len(parsed))
+	}
+}
+
+func TestTextParserRepairsResponse(t *testing.T) {
+	srv := fakeOpenAI(t,
+		`{"transactions":[{"type":"SPEND","amount":"dua puluh ribu atau tiga puluh ribu","category":"makan","description":"kopi","confidence":0.9}]}`,
+		`{"transactions":[{"type":"EXPENSE","amount":20000,"category":"makan","description":"kopi","confidence":0.9}]}`,
+	)
+	usage := &memoryUsage{}
+	p := NewTextParser("test", srv.URL+"/v1", "gpt-4o-mini", time.UTC, usage)
+
+	parsed, err := p.Parse(context.Background(), "kopi 20rb")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(parsed) != 1 || parsed[0].Amount != 20000 {
+		t.Errorf("parsed %+v, want one transaction of 20000", parsed)
+	}
+
+	if len(usage.calls) != 2 {
+		t.Fatalf("recorded %d calls, want the invalid answer and its repair", len(usage.calls))
+	}
+	if usage.calls[0].Outcome != domain.AIOutcomeInvalidResponse || usage.calls[1].Outcome != domain.AIOutcomeSuccess {
+		t.Errorf("outcomes = %q, %q, want %q then %q", usage.calls[0].Outcome, usage.calls[1].Outcome,
+			domain.AIOutcomeInvalidResponse, domain.AIOutcomeSuccess)
+	}
+}
+
+func TestTextParserGivesUpAfterRepair(t *testing.T) {
+	srv := fakeOpenAI(t, `not json`, `still not json`, `{"transactions":[]}`)
+	p := NewTextParser("test", srv.URL+"/v1", "gpt-4o-mini", time.UTC, nil)
+
+	_, err := p.Parse(context.Background(), "kopi 20rb")
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Errorf("Parse error = %v, want a *ValidationError after %d repair", err, maxRepairAttempts)
+	}
+}
+
+func TestResponseFormat(t *testing.T) {
+	tests := []struct {
+		model string
+		want  openai.ChatCompletionResponseFormatType
+	}{
+		{"gpt-4o-mini", openai.ChatCompletionResponseFormatTypeJSONSchema},
+		{"gpt-4.1-nano", openai.ChatCompletionResponseFormatTypeJSONSchema},
+		{"o3-mini", openai.ChatCompletionResponseFormatTypeJSONSchema},
+		{"gpt-3.5-turbo", openai.ChatCompletionResponseFormatTypeJSONObject},
+		{"llama3.1:8b", openai.ChatCompletionResponseFormatTypeJSONObject},
+	}
+
+	for _, tt := range tests {
+		format := responseFormat(tt.model, "transactions", textResponseSchema)
+		if format.Type != tt.want {
+			t.Errorf("responseFormat(%q) = %q, want %q", tt.model, format.Type, tt.want)
+		}
+		if format.JSONSchema != nil && !format.JSONSchema.Strict {
+			t.Errorf("responseFormat(%q) schema isn't strict", tt.model)
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/nicolaananda/catatuang/internal/domain"
@@ -14,103 +16,163 @@ import (
 type VisionParser struct {
 	client   *openai.Client
 	model    string
+	prompt   *Prompt
 	timezone *time.Location
+	usage    UsageStore
 }
 
-func NewVisionParser(apiKey, model string, timezone *time.Location) *VisionParser {
+// NewVisionParser creates a parser; usage may be nil to skip call accounting
+func NewVisionParser(apiKey, baseURL, model string, timezone *time.Location, usage UsageStore) *VisionParser {
 	return &VisionParser{
-		client:   openai.NewClient(apiKey),
+		client:   NewOpenAIClient(apiKey, baseURL),
 		model:    model,
+		prompt:   mustLoadPrompt(PromptVision),
 		timezone: timezone,
+		usage:    usage,
 	}
 }
 
-func (p *VisionParser) ParseImage(ctx context.Context, imageData []byte) (*domain.ParsedTransaction, error) {
+// WithPrompt returns a copy of the parser that uses another prompt, e.g. a
+// candidate to run in shadow mode
+func (p *VisionParser) WithPrompt(prompt *Prompt) *VisionParser {
+	clone := *p
+	clone.prompt = prompt
+	return &clone
+}
+
+// Version is stored as ai_version on the transactions the parser produces
+func (p *VisionParser) Version() string {
+	return aiVersion(p.model, p.prompt)
+}
+
+// ParseImage extracts a transaction from an image. mimeType must be the real
+// image type (image/jpeg, image/png, image/webp); caption is the optional
+// text the user sent with the image.
+func (p *VisionParser) ParseImage(ctx context.Context, imageData []byte, mimeType, caption string) (*domain.ParsedTransaction, error) {
 	// Encode image to base64
 	base64Image := base64.StdEncoding.EncodeToString(imageData)
 
-	systemPrompt := `You are a receipt/transaction image parser for Indonesian financial transactions.
-Extract transaction information from the image (receipt, bank transfer screenshot, etc).
-
-Return ONLY valid JSON in this exact format:
-{
-  "type": "INCOME" or "EXPENSE",
-  "amount": number,
-  "category": "string",
-  "description": "string (merchant name or transfer description)",
-  "date": "YYYY-MM-DD",
-  "confidence": 0.0-1.0
-}
+	systemPrompt, err := p.prompt.Render(nil)
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt += categoryPrompt(ctx)
 
-Rules:
-1. For receipts → EXPENSE, extract total amount and merchant name
-2. For transfer screenshots → check if incoming (INCOME) or outgoing (EXPENSE)
-3. Extract date from image, use today if not visible
-4. Provide high confidence (>0.8) only if amount and type are clear
-5. If image is unclear or not a transaction, return confidence < 0.4`
-
-	resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: p.model,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role: openai.ChatMessageRoleUser,
-				MultiContent: []openai.ChatMessagePart{
-					{
-						Type: openai.ChatMessagePartTypeText,
-						Text: systemPrompt,
-					},
-					{
-						Type: openai.ChatMessagePartTypeImageURL,
-						ImageURL: &openai.ChatMessageImageURL{
-							URL: fmt.Sprintf("data:image/jpeg;base64,%s", base64Image),
-						},
-					},
-				},
-			},
+	parts := []openai.ChatMessagePart{
+		{
+			Type: openai.ChatMessagePartTypeText,
+			Text: systemPrompt,
 		},
-		Temperature: 0.3,
-		ResponseFormat: &openai.ChatCompletionResponseFormat{
-			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+	}
+	if caption != "" {
+		parts = append(parts, openai.ChatMessagePart{
+			Type: openai.ChatMessagePartTypeText,
+			Text: fmt.Sprintf("User caption: %s", caption),
+		})
+	}
+	parts = append(parts, openai.ChatMessagePart{
+		Type: openai.ChatMessagePartTypeImageURL,
+		ImageURL: &openai.ChatMessageImageURL{
+			URL: fmt.Sprintf("data:%s;base64,%s", mimeType, base64Image),
 		},
 	})
 
-	if err != nil {
-		return nil, fmt.Errorf("openai vision API error: %w", err)
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:         openai.ChatMessageRoleUser,
+			MultiContent: parts,
+		},
 	}
 
+	for attempt := 0; ; attempt++ {
+		call := startCall(ctx, domain.AICallVision, p.model)
+		call.PromptVersion = p.prompt.ID()
+		resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+			Model:          p.model,
+			Messages:       messages,
+			Temperature:    0.3,
+			ResponseFormat: responseFormat(p.model, "receipt", visionResponseSchema),
+		})
+
+		if err != nil {
+			finishCall(p.usage, call, resp.Usage, requestOutcome(err), err)
+			return nil, fmt.Errorf("openai vision API error: %w", err)
+		}
+
+		parsed, err := p.decode(resp, SentAt(ctx).In(p.timezone))
+		finishCall(p.usage, call, resp.Usage, responseOutcome(err), err)
+		if err == nil {
+			parsed.AIVersion = p.Version()
+		}
+
+		var invalid *ValidationError
+		if err == nil || !errors.As(err, &invalid) || attempt >= maxRepairAttempts {
+			return parsed, err
+		}
+
+		// Feed the problems back so the model can fix its own answer
+		log.Printf("Asking model to repair receipt response: %v", err)
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: resp.Choices[0].Message.Content},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: repairPrompt(err)},
+		)
+	}
+}
+
+// decode turns a completion into a validated transaction. Malformed output is
+// reported as a *ValidationError so it can be repaired.
+func (p *VisionParser) decode(resp openai.ChatCompletionResponse, now time.Time) (*domain.ParsedTransaction, error) {
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no response from AI")
 	}
 
 	content := resp.Choices[0].Message.Content
 
-	// Parse JSON response
 	var result struct {
-		Type        string  `json:"type"`
-		Amount      float64 `json:"amount"`
-		Category    string  `json:"category"`
-		Description string  `json:"description"`
-		Date        string  `json:"date"`
-		Confidence  float64 `json:"confidence"`
+		rawTransaction
+		Items []*domain.TransactionItem `json:"items"`
 	}
 
 	if err := json.Unmarshal([]byte(content), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("response is not valid JSON (%v)", err)}}
 	}
 
-	// Parse date
-	txDate, err := time.ParseInLocation("2006-01-02", result.Date, p.timezone)
-	if err != nil {
-		// Default to today if parsing fails
-		txDate = time.Now().In(p.timezone)
+	parsed, problems := result.validate(now)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
 	}
+	parsed.Items = normalizeItems(result.Items)
 
-	return &domain.ParsedTransaction{
-		Type:        result.Type,
-		Amount:      result.Amount,
-		Category:    result.Category,
-		Description: result.Description,
-		Date:        txDate,
-		Confidence:  result.Confidence,
-	}, nil
+	// A total that doesn't match its items means something was misread,
+	// so let the user confirm instead of auto-saving
+	if !parsed.ItemsMatchAmount() && parsed.ShouldAutoSave() {
+		parsed.Confidence = mismatchConfidence
+	}
+
+	return parsed, nil
+}
+
+// mismatchConfidence puts receipts whose items disagree with the total in the
+// confirmation range
+const mismatchConfidence = 0.6
+
+// normalizeItems drops empty rows and fills in missing quantities and subtotals
+func normalizeItems(items []*domain.TransactionItem) []*domain.TransactionItem {
+	var normalized []*domain.TransactionItem
+	for _, item := range items {
+		if item == nil || item.Name == "" {
+			continue
+		}
+		if item.Quantity <= 0 {
+			item.Quantity = 1
+		}
+		if item.Subtotal == 0 {
+			item.Subtotal = item.Quantity * item.UnitPrice
+		}
+		if item.UnitPrice == 0 {
+			item.UnitPrice = item.Subtotal / item.Quantity
+		}
+		normalized = append(normalized, item)
+	}
+	return normalized
 }
@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func completion(content string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: content}}}}
+}
+
+func TestVisionDecodeItems(t *testing.T) {
+	now := time.Date(2026, 3, 18, 14, 0, 0, 0, time.FixedZone("WIB", 7*3600))
+	p := &VisionParser{}
+
+	tests := []struct {
+		name       string
+		content    string
+		items      int
+		confidence float64
+	}{
+		{"items match total", `{"type":"EXPENSE","amount":"Rp 53.000","category":"belanja","description":"Indomaret","confidence":0.9,
+			"items":[{"name":"Susu","quantity":2,"unit_price":15000},{"name":"Roti","subtotal":23000},{"name":""}]}`, 2, 0.9},
+		{"items off by a rounding", `{"type":"EXPENSE","amount":53400,"confidence":0.9,
+			"items":[{"name":"Susu","quantity":2,"unit_price":15000},{"name":"Roti","subtotal":23000}]}`, 2, 0.9},
+		{"items don't match total", `{"type":"EXPENSE","amount":80000,"confidence":0.9,
+			"items":[{"name":"Susu","quantity":2,"unit_price":15000},{"name":"Roti","subtotal":23000}]}`, 2, mismatchConfidence},
+		{"no items", `{"type":"EXPENSE","amount":80000,"confidence":0.9}`, 0, 0.9},
+	}
+
+	for _, tt := range tests {
+		parsed, err := p.decode(completion(tt.content), now)
+		if err != nil {
+			t.Errorf("%s: decode: %v", tt.name, err)
+			continue
+		}
+		if len(parsed.Items) != tt.items || parsed.Confidence != tt.confidence {
+			t.Errorf("%s: %d items with confidence %v, want %d with %v", tt.name, len(parsed.Items), parsed.Confidence, tt.items, tt.confidence)
+		}
+	}
+}
+
+func TestNormalizeItems(t *testing.T) {
+	items := normalizeItems([]*domain.TransactionItem{
+		{Name: "Susu", Quantity: 2, UnitPrice: 15000},
+		{Name: "Roti", Subtotal: 23000},
+		nil,
+		{Name: "", Subtotal: 1000},
+	})
+
+	if len(items) != 2 {
+		t.Fatalf("normalizeItems kept %d items, want 2", len(items))
+	}
+	if items[0].Subtotal != 30000 {
+		t.Errorf("susu subtotal = %v, want 30000", items[0].Subtotal)
+	}
+	if items[1].Quantity != 1 || items[1].UnitPrice != 23000 {
+		t.Errorf("roti = %v x %v, want 1 x 23000", items[1].Quantity, items[1].UnitPrice)
+	}
+}
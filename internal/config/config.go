@@ -17,6 +17,16 @@ type Config struct {
 	OpenAIAPIKey string
 	OpenAIModel  string
 
+	// AI provider: "openai" (OpenAI or any OpenAI-compatible server at
+	// AIBaseURL, e.g. Ollama or vLLM) or "fake" for offline runs
+	AIProvider    string
+	AIBaseURL     string
+	AIVisionModel string
+
+	// Speech-to-text (OpenAI-compatible transcription endpoint)
+	STTBaseURL string
+	STTModel   string
+
 	// GOWA WhatsApp
 	GowaWebhookSecret string
 	GowaAPIURL        string
@@ -31,12 +41,35 @@ type Config struct {
 	AdminMSISDN string
 
 	// App Settings
-	Timezone             string
-	AITimeoutSeconds     int
-	AIMaxRetries         int
+	Timezone         string
+	AITimeoutSeconds int
+	AIMaxRetries     int
+	// Candidate prompts (template names under internal/ai/prompts) run in
+	// shadow mode on AIShadowPercent of traffic; empty disables shadowing
+	AIShadowTextPrompt   string
+	AIShadowVisionPrompt string
+	AIShadowPercent      int
 	StateExpiryMinutes   int
 	UndoWindowSeconds    int
 	FreeTransactionLimit int
+	MaxMediaSizeMB       int
+	InboundWorkers       int
+	InboundMaxAttempts   int
+	// InboundLock is "local" for a single instance or "postgres" to serialise
+	// each sender's messages across instances with advisory locks
+	InboundLock string
+	// Outbound delivery: senders, attempts for transient failures, global
+	// sends per second and minimum gap between messages to one recipient
+	OutboundWorkers             int
+	OutboundMaxAttempts         int
+	OutboundRatePerSecond       int
+	OutboundRecipientIntervalMs int
+	// ShutdownTimeoutSeconds bounds how long SIGTERM waits for in-flight work
+	ShutdownTimeoutSeconds int
+	// The AI circuit breaker opens after AIBreakerThreshold consecutive
+	// failed calls and lets a probe through after AIBreakerCooldownSeconds
+	AIBreakerThreshold       int
+	AIBreakerCooldownSeconds int
 }
 
 func Load() (*Config, error) {
@@ -47,6 +80,11 @@ func Load() (*Config, error) {
 		DatabaseURL:          getEnv("DATABASE_URL", ""),
 		OpenAIAPIKey:         getEnv("OPENAI_API_KEY", ""),
 		OpenAIModel:          getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+		AIProvider:           getEnv("AI_PROVIDER", "openai"),
+		AIBaseURL:            getEnv("AI_BASE_URL", ""),
+		AIVisionModel:        getEnv("AI_VISION_MODEL", ""),
+		STTBaseURL:           getEnv("STT_BASE_URL", ""),
+		STTModel:             getEnv("STT_MODEL", "whisper-1"),
 		GowaWebhookSecret:    getEnv("GOWA_WEBHOOK_SECRET", ""),
 		GowaAPIURL:           getEnv("GOWA_API_URL", ""),
 		GowaAPIToken:         getEnv("GOWA_API_TOKEN", ""),
@@ -57,9 +95,29 @@ func Load() (*Config, error) {
 		Timezone:             getEnv("TIMEZONE", "Asia/Jakarta"),
 		AITimeoutSeconds:     getEnvInt("AI_TIMEOUT_SECONDS", 12),
 		AIMaxRetries:         getEnvInt("AI_MAX_RETRIES", 2),
+		AIShadowTextPrompt:   getEnv("AI_SHADOW_TEXT_PROMPT", ""),
+		AIShadowVisionPrompt: getEnv("AI_SHADOW_VISION_PROMPT", ""),
+		AIShadowPercent:      getEnvInt("AI_SHADOW_PERCENT", 0),
 		StateExpiryMinutes:   getEnvInt("STATE_EXPIRY_MINUTES", 30),
 		UndoWindowSeconds:    getEnvInt("UNDO_WINDOW_SECONDS", 60),
 		FreeTransactionLimit: getEnvInt("FREE_TRANSACTION_LIMIT", 10),
+		MaxMediaSizeMB:       getEnvInt("MAX_MEDIA_SIZE_MB", 5),
+		InboundWorkers:       getEnvInt("INBOUND_WORKERS", 4),
+		InboundMaxAttempts:   getEnvInt("INBOUND_MAX_ATTEMPTS", 5),
+		InboundLock:          getEnv("INBOUND_LOCK", "local"),
+
+		OutboundWorkers:             getEnvInt("OUTBOUND_WORKERS", 2),
+		OutboundMaxAttempts:         getEnvInt("OUTBOUND_MAX_ATTEMPTS", 5),
+		OutboundRatePerSecond:       getEnvInt("OUTBOUND_RATE_PER_SECOND", 5),
+		OutboundRecipientIntervalMs: getEnvInt("OUTBOUND_RECIPIENT_INTERVAL_MS", 1000),
+		ShutdownTimeoutSeconds:      getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
+		AIBreakerThreshold:          getEnvInt("AI_BREAKER_THRESHOLD", 5),
+		AIBreakerCooldownSeconds:    getEnvInt("AI_BREAKER_COOLDOWN_SECONDS", 30),
+	}
+
+	// Self-hosted servers often serve a separate multimodal model
+	if cfg.AIVisionModel == "" {
+		cfg.AIVisionModel = cfg.OpenAIModel
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -73,8 +131,18 @@ func (c *Config) Validate() error {
 	if c.DatabaseURL == "" {
 		return fmt.Errorf("DATABASE_URL is required")
 	}
-	if c.OpenAIAPIKey == "" {
-		return fmt.Errorf("OPENAI_API_KEY is required")
+	switch c.AIProvider {
+	case "openai":
+		// Self-hosted OpenAI-compatible servers usually don't check the key
+		if c.OpenAIAPIKey == "" && c.AIBaseURL == "" {
+			return fmt.Errorf("OPENAI_API_KEY is required")
+		}
+	case "fake":
+	default:
+		return fmt.Errorf("AI_PROVIDER must be openai or fake, got %q", c.AIProvider)
+	}
+	if c.AIShadowPercent < 0 || c.AIShadowPercent > 100 {
+		return fmt.Errorf("AI_SHADOW_PERCENT must be between 0 and 100, got %d", c.AIShadowPercent)
 	}
 	if c.GowaWebhookSecret == "" {
 		return fmt.Errorf("GOWA_WEBHOOK_SECRET is required")
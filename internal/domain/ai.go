@@ -10,6 +10,60 @@ type ParsedTransaction struct {
 	Description string    `json:"description"`
 	Date        time.Time `json:"date"`
 	Confidence  float64   `json:"confidence"`
+
+	// CategoryID is the canonical category Category was mapped to, if any
+	CategoryID int64 `json:"category_id,omitempty"`
+
+	// AIVersion is stored as ai_version: the model and prompt version, or the
+	// rule parser version for the fast path. Empty falls back to the model.
+	AIVersion string `json:"ai_version,omitempty"`
+
+	// Items holds receipt line items, if the source was an itemised receipt
+	Items []*TransactionItem `json:"items,omitempty"`
+}
+
+// ItemsTotal sums the line totals of all items
+func (pt *ParsedTransaction) ItemsTotal() float64 {
+	var total float64
+	for _, item := range pt.Items {
+		total += item.LineTotal()
+	}
+	return total
+}
+
+// ItemsMatchAmount checks that line items add up to the transaction amount.
+// Rounding on receipts is tolerated up to 1% or Rp500, whichever is larger.
+func (pt *ParsedTransaction) ItemsMatchAmount() bool {
+	if len(pt.Items) == 0 {
+		return true
+	}
+
+	tolerance := pt.Amount * 0.01
+	if tolerance < 500 {
+		tolerance = 500
+	}
+
+	diff := pt.ItemsTotal() - pt.Amount
+	return diff <= tolerance && diff >= -tolerance
+}
+
+// FutureDateTolerance is how far ahead of now a transaction may be dated
+// before the user has to confirm it
+const FutureDateTolerance = 24 * time.Hour
+
+// IsFarFuture checks if the date is further ahead than FutureDateTolerance
+func (pt *ParsedTransaction) IsFarFuture(now time.Time) bool {
+	return pt.Date.After(now.Add(FutureDateTolerance))
+}
+
+// AnyFarFuture checks if any parsed transaction is dated too far ahead
+func AnyFarFuture(parsed []*ParsedTransaction, now time.Time) bool {
+	for _, pt := range parsed {
+		if pt.IsFarFuture(now) {
+			return true
+		}
+	}
+	return false
 }
 
 // NeedsConfirmation checks if confidence is in the medium range
@@ -26,3 +80,16 @@ func (pt *ParsedTransaction) ShouldReject() bool {
 func (pt *ParsedTransaction) ShouldAutoSave() bool {
 	return pt.Confidence >= 0.7
 }
+
+// LeastConfident returns the parsed transaction with the lowest confidence.
+// A multi-transaction message is saved, confirmed or rejected as a whole
+// based on its weakest entry.
+func LeastConfident(parsed []*ParsedTransaction) *ParsedTransaction {
+	var lowest *ParsedTransaction
+	for _, pt := range parsed {
+		if lowest == nil || pt.Confidence < lowest.Confidence {
+			lowest = pt
+		}
+	}
+	return lowest
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// AI call types
+const (
+	AICallText   = "text"
+	AICallVision = "vision"
+	AICallQuery  = "query"
+	AICallIntent = "intent"
+)
+
+// AI call outcomes
+const (
+	AIOutcomeSuccess         = "success"
+	AIOutcomeAPIError        = "api_error"
+	AIOutcomeTimeout         = "timeout"
+	AIOutcomeInvalidResponse = "invalid_response"
+)
+
+// AICall records one model request for usage and cost accounting
+type AICall struct {
+	ID               int64     `json:"id"`
+	UserID           *int64    `json:"user_id,omitempty"`
+	WAMessageID      string    `json:"wa_message_id,omitempty"`
+	CallType         string    `json:"call_type"`
+	Model            string    `json:"model"`
+	PromptVersion    string    `json:"prompt_version,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	LatencyMs        int64     `json:"latency_ms"`
+	RetryCount       int       `json:"retry_count"`
+	Outcome          string    `json:"outcome"`
+	Error            string    `json:"error,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// AIUsageSummary aggregates AI calls by day, plan or user
+type AIUsageSummary struct {
+	Key              string  `json:"key"`
+	Calls            int     `json:"calls"`
+	Failures         int     `json:"failures"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+}
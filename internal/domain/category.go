@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Category is a canonical transaction category. UserID is nil for the
+// default taxonomy; ParentID is nil for top-level categories.
+type Category struct {
+	ID        int64     `json:"id"`
+	UserID    *int64    `json:"user_id,omitempty"`
+	ParentID  *int64    `json:"parent_id,omitempty"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"` // INCOME or EXPENSE
+	Synonyms  []string  `json:"synonyms,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CategoryMemory is a category a user chose for a merchant or keyword by
+// correcting a transaction, e.g. "kopi kenangan" → "Makanan & Minuman"
+type CategoryMemory struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Keyword   string    `json:"keyword"`
+	Category  string    `json:"category"`
+	Type      string    `json:"type"`
+	Hits      int       `json:"hits"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -12,6 +12,7 @@ const (
 	StateActive               = "ACTIVE"
 	StateAwaitingConfirm      = "AWAITING_CONFIRM_RECORD"
 	StateEditingTransaction   = "EDITING_TRANSACTION"
+	StateAwaitingDelete       = "AWAITING_CONFIRM_DELETE"
 	StateError                = "ERROR_STATE"
 )
 
@@ -32,8 +33,9 @@ func (cs *ConversationState) IsExpired() bool {
 
 // ConfirmContext for AWAITING_CONFIRM_RECORD state
 type ConfirmContext struct {
-	ParsedTransaction *ParsedTransaction `json:"parsed_transaction"`
-	OriginalMessage   string             `json:"original_message"`
+	ParsedTransactions []*ParsedTransaction `json:"parsed_transactions"`
+	OriginalMessage    string               `json:"original_message"`
+	MessageID          string               `json:"message_id"`
 }
 
 // EditContext for EDITING_TRANSACTION state
@@ -41,3 +43,8 @@ type EditContext struct {
 	TransactionID int64  `json:"transaction_id"`
 	Field         string `json:"field"`
 }
+
+// DeleteContext for AWAITING_CONFIRM_DELETE state
+type DeleteContext struct {
+	TransactionID int64 `json:"transaction_id"`
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Deferred message kinds
+const (
+	DeferredText  = "text"
+	DeferredImage = "image"
+	DeferredAudio = "audio"
+)
+
+// DeferredMessage is a transaction message received while the AI provider was
+// unavailable, parsed later by a background job. Statuses are the inbound job
+// statuses.
+type DeferredMessage struct {
+	ID          int64  `json:"id"`
+	UserID      int64  `json:"user_id"`
+	WAMessageID string `json:"wa_message_id"`
+	MSISDN      string `json:"msisdn"`
+	Kind        string `json:"kind"`
+	// Text is the message or image caption
+	Text string `json:"text"`
+	// Media is the receipt image or voice note
+	Media     []byte    `json:"-"`
+	MimeType  string    `json:"mime_type,omitempty"`
+	SentAt    time.Time `json:"sent_at"`
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+	RunAt     time.Time `json:"run_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
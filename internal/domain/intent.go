@@ -0,0 +1,46 @@
+package domain
+
+// Intent kinds a chat message can have
+const (
+	IntentRecord    = "record"    // record one or more transactions
+	IntentReport    = "report"    // summary for a period or a receipt breakdown
+	IntentQuery     = "query"     // question about past transactions
+	IntentUndo      = "undo"      // undo the last message's transactions
+	IntentEdit      = "edit"      // edit a transaction
+	IntentDelete    = "delete"    // delete a transaction
+	IntentSettings  = "settings"  // manage categories and preferences
+	IntentHelp      = "help"      // list what the bot can do
+	IntentSmalltalk = "smalltalk" // greetings, thanks and chit-chat
+)
+
+// Report periods
+const (
+	PeriodToday = "today"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+	// PeriodItems asks for the item breakdown of a receipt instead of a summary
+	PeriodItems = "items"
+)
+
+// Settings an IntentSettings message manages
+const (
+	SettingCategory = "category"
+	SettingLanguage = "language"
+)
+
+// Intent sources
+const (
+	IntentSourceRule  = "rule"
+	IntentSourceModel = "model"
+)
+
+// Intent is a classified chat message with the slots its handler needs.
+// Slots that don't apply to the kind are empty.
+type Intent struct {
+	Kind       string  `json:"kind"`
+	Period     string  `json:"period,omitempty"`  // report
+	TxID       string  `json:"tx_id,omitempty"`   // edit, delete, report items; empty = last transaction
+	Setting    string  `json:"setting,omitempty"` // settings, e.g. SettingCategory
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+}
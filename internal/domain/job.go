@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Inbound job statuses
+const (
+	JobPending    = "PENDING"
+	JobProcessing = "PROCESSING"
+	JobDone       = "DONE"
+	JobDead       = "DEAD"
+)
+
+// InboundJob is a webhook message persisted for processing by the worker pool
+type InboundJob struct {
+	ID          int64           `json:"id"`
+	WAMessageID string          `json:"wa_message_id"`
+	MSISDN      string          `json:"msisdn,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	MessageAt   time.Time       `json:"message_at"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package domain
+
+// Reply languages
+const (
+	LangIndonesian = "id"
+	LangEnglish    = "en"
+	LangJavanese   = "jv"
+)
+
+// DefaultLanguage is used until a user's language is known
+const DefaultLanguage = LangIndonesian
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Outbound message statuses. DELIVERED and READ come from GOWA receipts.
+const (
+	OutboundQueued    = "QUEUED"
+	OutboundSending   = "SENDING"
+	OutboundSent      = "SENT"
+	OutboundDelivered = "DELIVERED"
+	OutboundRead      = "READ"
+	OutboundFailed    = "FAILED"
+)
+
+// OutboundMessage is a WhatsApp reply waiting for or tracked after delivery
+type OutboundMessage struct {
+	ID            int64      `json:"id"`
+	Recipient     string     `json:"recipient"`
+	Body          string     `json:"body"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	GowaMessageID string     `json:"gowa_message_id,omitempty"`
+	RunAt         time.Time  `json:"run_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	ReadAt        *time.Time `json:"read_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
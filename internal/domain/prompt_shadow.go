@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PromptShadowRun compares a candidate prompt with the live one on the same
+// input. The candidate's output is only logged, never shown or saved.
+type PromptShadowRun struct {
+	ID               int64           `json:"id"`
+	UserID           *int64          `json:"user_id,omitempty"`
+	WAMessageID      string          `json:"wa_message_id,omitempty"`
+	CallType         string          `json:"call_type"`
+	PrimaryVersion   string          `json:"primary_version"`
+	CandidateVersion string          `json:"candidate_version"`
+	PrimaryOutput    json.RawMessage `json:"primary_output,omitempty"`
+	CandidateOutput  json.RawMessage `json:"candidate_output,omitempty"`
+	CandidateError   string          `json:"candidate_error,omitempty"`
+	Agrees           bool            `json:"agrees"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
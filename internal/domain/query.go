@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// Ledger query aggregations
+const (
+	AggregateSum   = "sum"   // total amount
+	AggregateCount = "count" // number of transactions
+	AggregateAvg   = "avg"   // average amount
+	AggregateMax   = "max"   // largest transactions, up to Limit
+	AggregateTop   = "top"   // categories with the largest totals, up to Limit
+)
+
+// MaxLedgerQueryLimit caps the rows returned by max and top queries
+const MaxLedgerQueryLimit = 10
+
+// LedgerQuery is a constrained plan for answering a question about the
+// user's transactions. It only holds filter values; the SQL is built by
+// TransactionRepository.Query.
+type LedgerQuery struct {
+	Type        string    `json:"type,omitempty"` // INCOME, EXPENSE or empty for both
+	Categories  []string  `json:"categories,omitempty"`
+	Keywords    []string  `json:"keywords,omitempty"` // matched against the description
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"` // exclusive
+	Aggregation string    `json:"aggregation"`
+	Limit       int       `json:"limit,omitempty"`
+}
+
+// LedgerGroup is one row of a top-N breakdown
+type LedgerGroup struct {
+	Key   string  `json:"key"`
+	Total float64 `json:"total"`
+	Count int     `json:"count"`
+}
+
+// LedgerResult is the answer to a LedgerQuery. Value holds the sum or
+// average, Count the number of matching transactions.
+type LedgerResult struct {
+	Value        float64        `json:"value"`
+	Count        int            `json:"count"`
+	Transactions []*Transaction `json:"transactions,omitempty"`
+	Groups       []*LedgerGroup `json:"groups,omitempty"`
+}
@@ -19,6 +19,7 @@ type Transaction struct {
 	Type            string    `json:"type"`
 	Amount          float64   `json:"amount"`
 	Category        string    `json:"category,omitempty"`
+	CategoryID      *int64    `json:"category_id,omitempty"`
 	Description     string    `json:"description,omitempty"`
 	TransactionDate time.Time `json:"transaction_date"`
 	WAMessageID     string    `json:"wa_message_id,omitempty"`
@@ -27,6 +28,31 @@ type Transaction struct {
 	IsDeleted       bool      `json:"is_deleted"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+
+	Items []*TransactionItem `json:"items,omitempty"`
+}
+
+// TransactionItem is a line item of an itemised receipt
+type TransactionItem struct {
+	ID            int64     `json:"id,omitempty"`
+	TransactionID int64     `json:"transaction_id,omitempty"`
+	Name          string    `json:"name"`
+	Quantity      float64   `json:"quantity"`
+	UnitPrice     float64   `json:"unit_price"`
+	Subtotal      float64   `json:"subtotal"`
+	Discount      float64   `json:"discount"`
+	Tax           float64   `json:"tax"`
+	Category      string    `json:"category,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty"`
+}
+
+// LineTotal returns what the item actually cost after discount and tax
+func (i *TransactionItem) LineTotal() float64 {
+	subtotal := i.Subtotal
+	if subtotal == 0 {
+		subtotal = i.Quantity * i.UnitPrice
+	}
+	return subtotal - i.Discount + i.Tax
 }
 
 // GenerateTxID generates a unique transaction ID using WA message ID
@@ -43,3 +69,18 @@ func GenerateTxID(waMessageID string) string {
 
 	return fmt.Sprintf("TX#%s-%d", suffix, timestamp)
 }
+
+// GenerateTxIDs generates n unique transaction IDs for transactions recorded
+// from the same WA message. A single transaction keeps the GenerateTxID format.
+func GenerateTxIDs(waMessageID string, n int) []string {
+	base := GenerateTxID(waMessageID)
+	if n == 1 {
+		return []string{base}
+	}
+
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s-%d", base, i+1)
+	}
+	return ids
+}
@@ -17,12 +17,18 @@ type User struct {
 	FreeTxCount  int        `json:"free_tx_count"`
 	PremiumUntil *time.Time `json:"premium_until,omitempty"`
 	IsBlocked    bool       `json:"is_blocked"`
+	Language     string     `json:"language"` // reply language, e.g. LangEnglish
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 // CanRecord checks if user can record a new transaction
 func (u *User) CanRecord(freeLimit int) bool {
+	return u.CanRecordCount(freeLimit, 1)
+}
+
+// CanRecordCount checks if user can record count new transactions at once
+func (u *User) CanRecordCount(freeLimit, count int) bool {
 	if u.IsBlocked {
 		return false
 	}
@@ -36,7 +42,7 @@ func (u *User) CanRecord(freeLimit int) bool {
 	}
 	
 	// Free user or expired premium
-	return u.FreeTxCount < freeLimit
+	return u.FreeTxCount+count <= freeLimit
 }
 
 // IsPremium checks if user has active premium
@@ -8,18 +8,21 @@ import (
 	"strings"
 	"time"
 
+	"github.com/nicolaananda/catatuang/internal/ai"
 	"github.com/nicolaananda/catatuang/internal/service"
 )
 
 type AdminHandler struct {
-	userService *service.UserService
-	txService   *service.TransactionService
+	userService  *service.UserService
+	txService    *service.TransactionService
+	usageService *service.UsageService
 }
 
-func NewAdminHandler(userService *service.UserService, txService *service.TransactionService) *AdminHandler {
+func NewAdminHandler(userService *service.UserService, txService *service.TransactionService, usageService *service.UsageService) *AdminHandler {
 	return &AdminHandler{
-		userService: userService,
-		txService:   txService,
+		userService:  userService,
+		txService:    txService,
+		usageService: usageService,
 	}
 }
 
@@ -169,3 +172,46 @@ func (h *AdminHandler) GetUserTransactions(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(transactions)
 }
+
+// GetAIUsageDaily returns AI calls, tokens and cost per day.
+// Optional ?days=N limits the window (default 30).
+func (h *AdminHandler) GetAIUsageDaily(w http.ResponseWriter, r *http.Request) {
+	h.writeAIUsage(w, r, service.UsageByDay)
+}
+
+// GetAIUsageByPlan returns AI calls, tokens and cost per user plan
+func (h *AdminHandler) GetAIUsageByPlan(w http.ResponseWriter, r *http.Request) {
+	h.writeAIUsage(w, r, service.UsageByPlan)
+}
+
+// GetAIUsageByUser returns AI calls, tokens and cost per user, most expensive first
+func (h *AdminHandler) GetAIUsageByUser(w http.ResponseWriter, r *http.Request) {
+	h.writeAIUsage(w, r, service.UsageByUser)
+}
+
+// GetAIRetries returns how AI calls fared through retries since startup
+func (h *AdminHandler) GetAIRetries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ai.GetRetryStats())
+}
+
+func (h *AdminHandler) writeAIUsage(w http.ResponseWriter, r *http.Request, groupBy string) {
+	days := 30
+	if value := r.URL.Query().Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 366 {
+			http.Error(w, "days must be between 1 and 366", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	usage, err := h.usageService.GetAIUsage(r.Context(), groupBy, days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
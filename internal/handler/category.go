@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/nicolaananda/catatuang/internal/ai"
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/i18n"
+	"github.com/nicolaananda/catatuang/internal/service"
+	"github.com/nicolaananda/catatuang/internal/whatsapp"
+)
+
+// Category command prefixes, matched against the lowercased message
+var (
+	addCategoryPrefixes    = []string{"tambah kategori ", "buat kategori ", "add category "}
+	renameCategoryPrefixes = []string{"ubah kategori ", "ganti kategori ", "rename kategori ", "rename category "}
+	mergeCategoryPrefixes  = []string{"gabung kategori ", "gabungkan kategori ", "merge category "}
+)
+
+// cutCategoryPrefix strips the first matching prefix and returns the rest
+// with its original casing, plus the index of the prefix group that matched
+func cutCategoryPrefix(text string, groups ...[]string) (string, int, bool) {
+	lower := strings.ToLower(text)
+	for i, prefixes := range groups {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(lower, prefix) {
+				return strings.TrimSpace(text[len(prefix):]), i, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// splitCategoryArgs splits "a jadi b" on the first separator found
+func splitCategoryArgs(args string, separators ...string) (string, string, bool) {
+	lower := strings.ToLower(args)
+	for _, sep := range separators {
+		if idx := strings.Index(lower, sep); idx >= 0 {
+			from := strings.TrimSpace(args[:idx])
+			to := strings.TrimSpace(args[idx+len(sep):])
+			return from, to, from != "" && to != ""
+		}
+	}
+	return "", "", false
+}
+
+func (h *WebhookHandler) handleCategoryCommand(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
+	text := strings.TrimSpace(msg.GetText())
+	args, command, ok := cutCategoryPrefix(text, addCategoryPrefixes, renameCategoryPrefixes, mergeCategoryPrefixes)
+	if !ok {
+		h.listCategories(ctx, user, msg)
+		return
+	}
+
+	var reply string
+	var err error
+	switch command {
+	case 0:
+		reply, err = h.addCategory(ctx, user, args)
+	case 1:
+		from, to, ok := splitCategoryArgs(args, " jadi ", " menjadi ", " ke ", " to ")
+		if !ok {
+			h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "category.rename_example"))
+			return
+		}
+		err = h.categories.RenameCategory(ctx, user.ID, from, to)
+		reply = i18n.T(user.Language, "category.renamed", i18n.Args{"From": from, "To": to})
+	case 2:
+		from, to, ok := splitCategoryArgs(args, " ke ", " dengan ", " jadi ", " into ", " to ")
+		if !ok {
+			h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "category.merge_example"))
+			return
+		}
+		err = h.categories.MergeCategories(ctx, user.ID, from, to)
+		reply = i18n.T(user.Language, "category.merged", i18n.Args{"From": from, "To": to})
+	}
+
+	if err != nil {
+		h.sendMessage(msg.GetFrom(), categoryErrorMessage(user.Language, err))
+		return
+	}
+	h.sendMessage(msg.GetFrom(), reply)
+}
+
+func (h *WebhookHandler) listCategories(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
+	categories, err := h.categories.Categories(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to list categories: %v", err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "category.list_failed"))
+		return
+	}
+
+	h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "category.list", i18n.Args{
+		"List": service.FormatCategories(user.Language, categories), "Usage": i18n.T(user.Language, "category.usage"),
+	}))
+}
+
+// addCategory handles "<nama> [pemasukan|pengeluaran] [di <induk>]"
+func (h *WebhookHandler) addCategory(ctx context.Context, user *domain.User, args string) (string, error) {
+	name, parent := args, ""
+	if n, p, ok := splitCategoryArgs(args, " di ", " dalam ", " in ", " under "); ok {
+		name, parent = n, p
+	}
+
+	txType := ""
+	fields := strings.Fields(name)
+	if len(fields) > 1 {
+		switch strings.ToLower(fields[len(fields)-1]) {
+		case "pemasukan", "income":
+			txType = domain.TypeIncome
+		case "pengeluaran", "expense":
+			txType = domain.TypeExpense
+		}
+		if txType != "" {
+			name = strings.Join(fields[:len(fields)-1], " ")
+		}
+	}
+
+	c, err := h.categories.AddCategory(ctx, user.ID, name, txType, parent)
+	if err != nil {
+		return "", err
+	}
+
+	return i18n.T(user.Language, "category.added", i18n.Args{"Emoji": typeEmoji(c.Type), "Name": c.Name, "Parent": parent}), nil
+}
+
+func categoryErrorMessage(lang string, err error) string {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "category not found"):
+		return i18n.T(lang, "category.not_found", i18n.Args{"Name": strings.TrimPrefix(msg, "category not found: ")})
+	case strings.Contains(msg, "already exists"):
+		return i18n.T(lang, "category.exists", i18n.Args{"Name": strings.TrimPrefix(msg, "category already exists: ")})
+	case strings.Contains(msg, "type mismatch"):
+		return i18n.T(lang, "category.type_mismatch")
+	case strings.Contains(msg, "into itself"):
+		return i18n.T(lang, "category.same")
+	case strings.Contains(msg, "name is empty"):
+		return i18n.T(lang, "category.usage")
+	}
+
+	log.Printf("Category command failed: %v", err)
+	return i18n.T(lang, "category.failed")
+}
+
+// maxCorrectionExamples caps the few-shot corrections sent with each prompt
+const maxCorrectionExamples = 10
+
+// withCategories restricts the parsers to the user's categories and adds the
+// user's past corrections as examples. Without them the parser falls back to
+// free-text categories, which Canonicalize maps later.
+func (h *WebhookHandler) withCategories(ctx context.Context, user *domain.User) context.Context {
+	income, expense, err := h.categories.PromptCategories(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to load categories for user %d: %v", user.ID, err)
+	} else {
+		ctx = ai.WithCategories(ctx, income, expense)
+	}
+
+	memories, err := h.categories.CorrectionExamples(ctx, user.ID, maxCorrectionExamples)
+	if err != nil {
+		log.Printf("Failed to load category corrections for user %d: %v", user.ID, err)
+		return ctx
+	}
+	examples := make([]ai.CorrectionExample, len(memories))
+	for i, m := range memories {
+		examples[i] = ai.CorrectionExample{Keyword: m.Keyword, Category: m.Category}
+	}
+	return ai.WithCorrections(ctx, examples)
+}
+
+// applyCategoryMemory overrides parsed categories with the user's past
+// corrections. Only called on fresh parses, so it never undoes a category the
+// user just chose while confirming.
+func (h *WebhookHandler) applyCategoryMemory(ctx context.Context, user *domain.User, parsed []*domain.ParsedTransaction) {
+	if err := h.categories.ApplyMemory(ctx, user.ID, parsed); err != nil {
+		log.Printf("Failed to apply category memory for user %d: %v", user.ID, err)
+	}
+}
+
+// canonicalizeCategories maps parsed categories to the user's taxonomy. On
+// failure the parsed free-text categories are kept.
+func (h *WebhookHandler) canonicalizeCategories(ctx context.Context, user *domain.User, parsed []*domain.ParsedTransaction) {
+	if err := h.categories.Canonicalize(ctx, user.ID, parsed); err != nil {
+		log.Printf("Failed to canonicalize categories for user %d: %v", user.ID, err)
+	}
+}
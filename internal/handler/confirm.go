@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/ai"
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// Confirmation answers
+const (
+	confirmUnknown = iota
+	confirmYes
+	confirmNo
+)
+
+var (
+	yesWords = map[string]bool{
+		"ya": true, "y": true, "iya": true, "yes": true, "ok": true,
+		"oke": true, "okay": true, "yup": true, "simpan": true, "betul": true, "benar": true,
+		"yep": true, "yeah": true, "sure": true, "iyo": true, "nggih": true, "inggih": true,
+	}
+	noWords = map[string]bool{
+		"tidak": true, "tdk": true, "gak": true, "ga": true, "nggak": true, "engga": true,
+		"enggak": true, "no": true, "batal": true, "jangan": true, "cancel": true,
+		"nope": true, "nah": true, "ora": true, "mboten": true,
+	}
+	// Filler words between the answer and a correction, e.g. "ya tapi 45rb"
+	correctionFillers = map[string]bool{
+		"tapi": true, "tp": true, "tpi": true, "harusnya": true, "seharusnya": true, "jadi": true,
+		"but": true,
+	}
+)
+
+// splitConfirmReply splits a reply into the yes/no answer and any trailing correction
+func splitConfirmReply(text string) (int, string) {
+	words := strings.Fields(strings.ToLower(strings.TrimSpace(text)))
+	if len(words) == 0 {
+		return confirmUnknown, ""
+	}
+
+	first := strings.TrimRight(words[0], ",.!")
+	answer := confirmUnknown
+	if yesWords[first] {
+		answer = confirmYes
+	} else if noWords[first] {
+		answer = confirmNo
+	} else {
+		return confirmUnknown, ""
+	}
+
+	rest := words[1:]
+	for len(rest) > 0 && correctionFillers[strings.Trim(rest[0], ",.")] {
+		rest = rest[1:]
+	}
+
+	return answer, strings.TrimSpace(strings.Join(rest, " "))
+}
+
+// applyCorrection updates a pending transaction from a correction like "45rb",
+// "pemasukan", "kategori makan", "category food" or "kemarin". Returns false if nothing was
+// recognised.
+func applyCorrection(parsed *domain.ParsedTransaction, correction string) bool {
+	correction = strings.ToLower(correction)
+	applied := false
+
+	// Dates first, so "tgl 5" isn't read as Rp5
+	if resolved, err := ai.ResolveDate(correction, time.Now().In(parsed.Date.Location())); err == nil && resolved != nil {
+		parsed.Date = resolved.Time
+		correction = resolved.Strip(correction)
+		applied = true
+	}
+
+	if amount, ok := ai.ExtractAmount(correction); ok {
+		parsed.Amount = amount
+		applied = true
+	}
+
+	if strings.Contains(correction, "pemasukan") || strings.Contains(correction, "income") {
+		parsed.Type = domain.TypeIncome
+		applied = true
+	} else if strings.Contains(correction, "pengeluaran") || strings.Contains(correction, "expense") {
+		parsed.Type = domain.TypeExpense
+		applied = true
+	}
+
+	for _, prefix := range []string{"kategori ", "category "} {
+		idx := strings.Index(correction, prefix)
+		if idx < 0 {
+			continue
+		}
+		if fields := strings.Fields(correction[idx+len(prefix):]); len(fields) > 0 {
+			parsed.Category = fields[0]
+			applied = true
+		}
+	}
+
+	return applied
+}
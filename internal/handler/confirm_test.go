@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+func TestSplitConfirmReply(t *testing.T) {
+	tests := []struct {
+		text       string
+		answer     int
+		correction string
+	}{
+		{"ya", confirmYes, ""},
+		{"Iya!", confirmYes, ""},
+		{"ya tapi 45rb", confirmYes, "45rb"},
+		{"ok, harusnya kategori makan", confirmYes, "kategori makan"},
+		{"yes but 30k", confirmYes, "30k"},
+		{"nggih", confirmYes, ""},
+		{"tidak", confirmNo, ""},
+		{"gak jadi", confirmNo, ""},
+		{"ora", confirmNo, ""},
+		{"beli kopi 20rb", confirmUnknown, ""},
+		{"", confirmUnknown, ""},
+	}
+
+	for _, tt := range tests {
+		answer, correction := splitConfirmReply(tt.text)
+		if answer != tt.answer || correction != tt.correction {
+			t.Errorf("splitConfirmReply(%q) = %d, %q, want %d, %q", tt.text, answer, correction, tt.answer, tt.correction)
+		}
+	}
+}
+
+func TestApplyCorrection(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	original := func() *domain.ParsedTransaction {
+		return &domain.ParsedTransaction{
+			Type:     domain.TypeExpense,
+			Amount:   40000,
+			Category: "belanja",
+			Date:     today,
+		}
+	}
+
+	tests := []struct {
+		correction string
+		applied    bool
+		check      func(p *domain.ParsedTransaction) bool
+	}{
+		{"45rb", true, func(p *domain.ParsedTransaction) bool { return p.Amount == 45000 }},
+		{"pemasukan", true, func(p *domain.ParsedTransaction) bool { return p.Type == domain.TypeIncome }},
+		{"kategori makan", true, func(p *domain.ParsedTransaction) bool { return p.Category == "makan" && p.Amount == 40000 }},
+		{"category food 50k", true, func(p *domain.ParsedTransaction) bool { return p.Category == "food" && p.Amount == 50000 }},
+		{"kemarin", true, func(p *domain.ParsedTransaction) bool {
+			return p.Date.Equal(today.AddDate(0, 0, -1)) && p.Amount == 40000
+		}},
+		// The day of month is a date, not Rp1
+		{"tgl 1", true, func(p *domain.ParsedTransaction) bool { return p.Date.Day() == 1 && p.Amount == 40000 }},
+		{"makasih", false, func(p *domain.ParsedTransaction) bool {
+			return p.Amount == 40000 && p.Type == domain.TypeExpense && p.Category == "belanja" && p.Date.Equal(today)
+		}},
+	}
+
+	for _, tt := range tests {
+		p := original()
+		if applied := applyCorrection(p, tt.correction); applied != tt.applied {
+			t.Errorf("applyCorrection(%q) = %v, want %v", tt.correction, applied, tt.applied)
+		}
+		if !tt.check(p) {
+			t.Errorf("applyCorrection(%q) left %+v", tt.correction, *p)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/ai"
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/i18n"
+	"github.com/nicolaananda/catatuang/internal/whatsapp"
+)
+
+const (
+	// deferredRetryDelay is how long a deferred message waits after the model
+	// was still unavailable or the user was busy confirming something else
+	deferredRetryDelay = time.Minute
+	// deferredMaxAge is when a deferred message is given up on
+	deferredMaxAge = 24 * time.Hour
+	// deferredTimeout bounds parsing one message; a message left PROCESSING
+	// for deferredLease by a crashed instance is picked up again
+	deferredTimeout = 2 * time.Minute
+	deferredLease   = 5 * time.Minute
+)
+
+// deferMessage keeps a transaction message the model couldn't parse because
+// it was unavailable, and tells the user it will be recorded later
+func (h *WebhookHandler) deferMessage(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage, deferred *domain.DeferredMessage, echo string) {
+	deferred.UserID = user.ID
+	deferred.WAMessageID = msg.GetMessageID()
+	deferred.MSISDN = msg.GetMSISDN()
+	deferred.SentAt = ai.SentAt(ctx)
+
+	if err := h.deferred.Create(ctx, deferred); err != nil {
+		log.Printf("Failed to defer message %s: %v", msg.GetMessageID(), err)
+		h.sendMessage(msg.GetFrom(), echo+i18n.T(user.Language, "tx.unavailable"))
+		return
+	}
+
+	h.sendMessage(msg.GetFrom(), echo+i18n.T(user.Language, "tx.deferred"))
+}
+
+// ProcessDeferred parses messages deferred during an outage, one at a time,
+// until none is due or the model is unavailable again
+func (h *WebhookHandler) ProcessDeferred(ctx context.Context) {
+	for ctx.Err() == nil && h.breaker.State() != ai.CircuitOpen {
+		deferred, err := h.deferred.ClaimNext(ctx, deferredLease)
+		if err != nil {
+			log.Printf("Failed to claim deferred message: %v", err)
+			return
+		}
+		if deferred == nil {
+			return
+		}
+
+		if !h.processDeferred(ctx, deferred) {
+			return
+		}
+	}
+}
+
+// processDeferred handles one deferred message and reports whether the next
+// one should be tried
+func (h *WebhookHandler) processDeferred(ctx context.Context, deferred *domain.DeferredMessage) bool {
+	ctx, cancel := context.WithTimeout(ctx, deferredTimeout)
+	defer cancel()
+
+	// Bookkeeping gets its own context so a timed-out parse can still be recorded
+	bgCtx := context.Background()
+	lang := domain.DefaultLanguage
+
+	retry := func(reason string) {
+		if time.Since(deferred.CreatedAt) > deferredMaxAge {
+			log.Printf("Giving up on deferred message %s: %s", deferred.WAMessageID, reason)
+			if err := h.deferred.MarkDead(bgCtx, deferred.ID, reason); err != nil {
+				log.Printf("Failed to mark deferred message %d dead: %v", deferred.ID, err)
+			}
+			h.sendMessage(deferred.MSISDN, h.deferredEcho(lang, deferred)+i18n.T(lang, "tx.deferred_dead"))
+			return
+		}
+		if err := h.deferred.MarkRetry(bgCtx, deferred.ID, reason, time.Now().Add(deferredRetryDelay)); err != nil {
+			log.Printf("Failed to reschedule deferred message %d: %v", deferred.ID, err)
+		}
+	}
+
+	// Run under the sender's lock, so the reply can't interleave with a job
+	// from the same user
+	unlock, err := h.inbound.Lock(ctx, deferred.MSISDN)
+	if err != nil {
+		log.Printf("Failed to lock sender of deferred message %d: %v", deferred.ID, err)
+		retry("failed to lock sender")
+		return false
+	}
+	defer unlock()
+
+	user, err := h.userService.GetUserByID(ctx, deferred.UserID)
+	if err != nil || user == nil {
+		log.Printf("Failed to get user %d for deferred message: %v", deferred.UserID, err)
+		retry("user not found")
+		return true
+	}
+	lang = user.Language
+
+	// A confirmation would replace whatever the user is in the middle of
+	state, err := h.stateMachine.GetState(ctx, user.ID)
+	if err != nil || state.State != domain.StateActive {
+		retry("user is busy")
+		return true
+	}
+
+	ctx = ai.WithCallInfo(ctx, user.ID, deferred.WAMessageID)
+	ctx = ai.WithSentAt(ctx, deferred.SentAt)
+	ctx = h.withCategories(ctx, user)
+	echo := h.deferredEcho(lang, deferred)
+
+	// The job retries the message itself a minute later
+	retryConfig := h.retryConfig(deferred.Kind)
+	retryConfig.MaxRetries = 0
+
+	switch deferred.Kind {
+	case domain.DeferredImage:
+		parsed, err := ai.WithRetry(ctx, retryConfig, func(ctx context.Context) (*domain.ParsedTransaction, error) {
+			return h.visionParser.ParseImage(ctx, deferred.Media, deferred.MimeType, deferred.Text)
+		})
+		if ai.IsUnavailable(err) {
+			retry(err.Error())
+			return false
+		}
+		h.handleParsedImage(ctx, user, deferred.MSISDN, deferred.WAMessageID, parsed, err, deferred.Text, echo)
+	default:
+		text := deferred.Text
+		if deferred.Kind == domain.DeferredAudio {
+			transcript, err := h.transcriber.Transcribe(ctx, deferred.Media, deferred.MimeType, lang)
+			if ai.IsUnavailable(err) {
+				retry(err.Error())
+				return false
+			}
+			if err != nil {
+				log.Printf("Transcription of deferred message %d failed: %v", deferred.ID, err)
+				h.sendMessage(deferred.MSISDN, echo+i18n.T(lang, "voice.transcribe_failed"))
+				break
+			}
+			text = transcript
+			echo += fmt.Sprintf("🎤 \"%s\"\n\n", transcript)
+		}
+
+		parsed, err := ai.WithRetry(ctx, retryConfig, func(ctx context.Context) ([]*domain.ParsedTransaction, error) {
+			return h.textParser.Parse(ctx, text)
+		})
+		if ai.IsUnavailable(err) {
+			retry(err.Error())
+			return false
+		}
+		h.handleParsedText(ctx, user, deferred.MSISDN, deferred.WAMessageID, parsed, err, text, echo)
+	}
+
+	if err := h.deferred.MarkDone(bgCtx, deferred.ID); err != nil {
+		log.Printf("Failed to mark deferred message %d done: %v", deferred.ID, err)
+	}
+	return true
+}
+
+// deferredEcho reminds the user which earlier message a late reply is about
+func (h *WebhookHandler) deferredEcho(lang string, deferred *domain.DeferredMessage) string {
+	sent := deferred.SentAt
+	if loc, err := h.cfg.GetLocation(); err == nil {
+		sent = sent.In(loc)
+	}
+	switch deferred.Kind {
+	case domain.DeferredImage:
+		return i18n.T(lang, "tx.deferred_image", i18n.Args{"SentAt": sent.Format("02/01 15:04")})
+	case domain.DeferredAudio:
+		return i18n.T(lang, "tx.deferred_audio", i18n.Args{"SentAt": sent.Format("02/01 15:04")})
+	}
+	return i18n.T(lang, "tx.deferred_text", i18n.Args{"Text": deferred.Text, "SentAt": sent.Format("02/01 15:04")})
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/ai"
+)
+
+func TestDeferredRoundTrip(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("failed to load timezone: %v", err)
+	}
+
+	// The provider just timed out, so the breaker is open
+	cooldown := 200 * time.Millisecond
+	breaker := ai.NewCircuitBreaker(1, cooldown)
+	breaker.Record(context.DeadlineExceeded)
+	if breaker.State() != ai.CircuitOpen {
+		t.Fatalf("breaker state = %s, want open", breaker.State())
+	}
+
+	b := newTestBot(t, testParsers{
+		text:    ai.NewBreakerParser(ai.NewFakeParser(loc), breaker),
+		breaker: breaker,
+	})
+
+	expectReply(t, b.send("beli kopi 20rb"), "akan diproses")
+	if got := b.transactions(); len(got) != 0 {
+		t.Fatalf("saved while the model was down: %q", got)
+	}
+
+	var msisdn, status string
+	if err := b.db.QueryRow(`SELECT msisdn, status FROM deferred_messages WHERE user_id = $1`, b.user.ID).Scan(&msisdn, &status); err != nil {
+		t.Fatalf("failed to read deferred message: %v", err)
+	}
+	if msisdn != testMSISDN || status != "PENDING" {
+		t.Fatalf("deferred message = %s %s, want %s PENDING", msisdn, status, testMSISDN)
+	}
+
+	time.Sleep(cooldown)
+	ctx := context.Background()
+
+	// A job of the same sender is running: the deferred message waits for it
+	unlock, err := b.h.inbound.Lock(ctx, testMSISDN)
+	if err != nil {
+		t.Fatalf("failed to lock sender: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		b.h.ProcessDeferred(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("ProcessDeferred ran while the sender was locked")
+	case <-time.After(200 * time.Millisecond):
+	}
+	if got := b.transactions(); len(got) != 0 {
+		t.Fatalf("saved while the sender was locked: %q", got)
+	}
+
+	unlock()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("ProcessDeferred didn't finish after the sender was unlocked")
+	}
+
+	if got := b.transactions(); len(got) != 1 || !strings.HasSuffix(got[0], " 20000") {
+		t.Fatalf("transactions = %q", got)
+	}
+	replies := b.replies()
+	if len(replies) != 1 || !strings.Contains(replies[0], "beli kopi 20rb") || !strings.Contains(replies[0], "Transaksi tersimpan") {
+		t.Fatalf("replies = %q, want the saved transaction with the original message", replies)
+	}
+
+	if err := b.db.QueryRow(`SELECT status FROM deferred_messages WHERE user_id = $1`, b.user.ID).Scan(&status); err != nil {
+		t.Fatalf("failed to read deferred message: %v", err)
+	}
+	if status != "DONE" {
+		t.Fatalf("deferred message status = %s, want DONE", status)
+	}
+}
+
+func TestDeferredVoiceNote(t *testing.T) {
+	cooldown := 200 * time.Millisecond
+	breaker := ai.NewCircuitBreaker(1, cooldown)
+	breaker.Record(context.DeadlineExceeded)
+
+	b := newTestBot(t, testParsers{
+		transcriber: ai.NewBreakerTranscriber(&ai.StaticTranscriber{Text: "beli kopi 20rb"}, breaker),
+		breaker:     breaker,
+	})
+	b.serveMedia("/statics/media/voice.ogg", "audio/ogg", []byte("OggS voice"))
+
+	// The speech-to-text service is down: the voice note is kept, not rejected
+	expectReply(t, b.sendMedia("audio", map[string]interface{}{
+		"media_path": "statics/media/voice.ogg",
+		"mime_type":  "audio/ogg; codecs=opus",
+	}), "akan diproses")
+
+	var kind string
+	var size int
+	if err := b.db.QueryRow(`SELECT kind, LENGTH(media) FROM deferred_messages WHERE user_id = $1`, b.user.ID).Scan(&kind, &size); err != nil {
+		t.Fatalf("failed to read deferred message: %v", err)
+	}
+	if kind != "audio" || size != len("OggS voice") {
+		t.Fatalf("deferred message = %s with %d bytes, want the audio", kind, size)
+	}
+
+	time.Sleep(cooldown)
+	b.h.ProcessDeferred(context.Background())
+
+	if got := b.transactions(); len(got) != 1 || !strings.HasSuffix(got[0], " 20000") {
+		t.Fatalf("transactions = %q", got)
+	}
+	replies := b.replies()
+	if len(replies) != 1 || !strings.Contains(replies[0], "Voice note yang kamu kirim") ||
+		!strings.Contains(replies[0], "🎤 \"beli kopi 20rb\"") || !strings.Contains(replies[0], "Transaksi tersimpan") {
+		t.Fatalf("replies = %q, want the saved transaction with the transcript", replies)
+	}
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/ai"
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/i18n"
+	"github.com/nicolaananda/catatuang/internal/whatsapp"
+)
+
+// Editable transaction fields
+const (
+	fieldAmount      = "amount"
+	fieldCategory    = "category"
+	fieldDescription = "description"
+	fieldType        = "type"
+	fieldDate        = "date"
+)
+
+var editFieldAliases = map[string]string{
+	"1": fieldAmount, "nominal": fieldAmount, "jumlah": fieldAmount, "amount": fieldAmount,
+	"2": fieldCategory, "kategori": fieldCategory, "category": fieldCategory,
+	"3": fieldDescription, "keterangan": fieldDescription, "deskripsi": fieldDescription, "description": fieldDescription,
+	"4": fieldType, "jenis": fieldType, "tipe": fieldType, "type": fieldType,
+	"5": fieldDate, "tanggal": fieldDate, "tgl": fieldDate, "date": fieldDate,
+}
+
+// handleEditCommand starts editing the transaction with txID, or the last
+// one when txID is empty
+func (h *WebhookHandler) handleEditCommand(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage, txID string) {
+	tx, err := h.txService.GetUserTransaction(ctx, user.ID, txID)
+	if err != nil {
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "edit.not_found"))
+		return
+	}
+
+	editCtx := &domain.EditContext{TransactionID: tx.ID}
+	if err := h.stateMachine.SetState(ctx, user.ID, domain.StateEditingTransaction, editCtx, h.cfg.StateExpiryMinutes); err != nil {
+		log.Printf("Failed to set edit state: %v", err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "system.error"))
+		return
+	}
+
+	h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "edit.menu", i18n.Args{"Summary": formatTransactionSummary(user.Language, tx)}))
+}
+
+// handleDeleteCommand asks before deleting the transaction with txID, or the
+// last one when txID is empty
+func (h *WebhookHandler) handleDeleteCommand(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage, txID string) {
+	tx, err := h.txService.GetUserTransaction(ctx, user.ID, txID)
+	if err != nil {
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "delete.not_found"))
+		return
+	}
+
+	deleteCtx := &domain.DeleteContext{TransactionID: tx.ID}
+	if err := h.stateMachine.SetState(ctx, user.ID, domain.StateAwaitingDelete, deleteCtx, h.cfg.StateExpiryMinutes); err != nil {
+		log.Printf("Failed to set delete state: %v", err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "system.error"))
+		return
+	}
+
+	h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "delete.confirm", i18n.Args{"Summary": formatTransactionSummary(user.Language, tx)}))
+}
+
+// handleDeleteConfirmation deletes the transaction once the user answers yes
+func (h *WebhookHandler) handleDeleteConfirmation(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage, state *domain.ConversationState) {
+	var deleteCtx domain.DeleteContext
+	if err := json.Unmarshal(state.Context, &deleteCtx); err != nil {
+		log.Printf("Invalid delete context for user %d: %v", user.ID, err)
+		h.stateMachine.ClearState(ctx, user.ID)
+		h.handleActiveState(ctx, user, msg)
+		return
+	}
+
+	answer, _ := splitConfirmReply(msg.GetText())
+	h.stateMachine.ClearState(ctx, user.ID)
+
+	switch answer {
+	case confirmNo:
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "delete.cancelled"))
+		return
+	case confirmUnknown:
+		// Anything else keeps the transaction and is handled as a new message
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "delete.cancelled"))
+		h.handleActiveState(ctx, user, msg)
+		return
+	}
+
+	tx, err := h.txService.GetTransactionByID(ctx, deleteCtx.TransactionID)
+	if err != nil || tx == nil || tx.UserID != user.ID || tx.IsDeleted {
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "delete.not_found"))
+		return
+	}
+
+	if err := h.txService.DeleteTransaction(ctx, tx.TxID); err != nil {
+		log.Printf("Failed to delete transaction: %v", err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "delete.failed"))
+		return
+	}
+
+	h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "delete.done", i18n.Args{"Summary": formatTransactionSummary(user.Language, tx)}))
+}
+
+func (h *WebhookHandler) handleEditingState(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage, state *domain.ConversationState) {
+	var editCtx domain.EditContext
+	if err := json.Unmarshal(state.Context, &editCtx); err != nil {
+		log.Printf("Invalid edit context for user %d: %v", user.ID, err)
+		h.stateMachine.ClearState(ctx, user.ID)
+		h.handleActiveState(ctx, user, msg)
+		return
+	}
+
+	text := strings.TrimSpace(msg.GetText())
+	lower := strings.ToLower(text)
+	if lower == "batal" || lower == "selesai" || lower == "cancel" || lower == "done" {
+		h.stateMachine.ClearState(ctx, user.ID)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "edit.cancelled"))
+		return
+	}
+
+	tx, err := h.txService.GetTransactionByID(ctx, editCtx.TransactionID)
+	if err != nil || tx == nil || tx.UserID != user.ID || tx.IsDeleted {
+		h.stateMachine.ClearState(ctx, user.ID)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "edit.gone"))
+		return
+	}
+
+	// First reply picks the field, optionally with the new value ("nominal 45rb")
+	value := text
+	if editCtx.Field == "" {
+		parts := strings.SplitN(text, " ", 2)
+		field, ok := editFieldAliases[strings.ToLower(parts[0])]
+		if !ok {
+			h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "edit.pick_field"))
+			return
+		}
+		editCtx.Field = field
+
+		if len(parts) < 2 || strings.TrimSpace(parts[1]) == "" {
+			if err := h.stateMachine.SetState(ctx, user.ID, domain.StateEditingTransaction, &editCtx, h.cfg.StateExpiryMinutes); err != nil {
+				log.Printf("Failed to set edit state: %v", err)
+			}
+			h.sendMessage(msg.GetFrom(), editValuePrompt(user.Language, field))
+			return
+		}
+		value = strings.TrimSpace(parts[1])
+	}
+
+	loc, _ := h.cfg.GetLocation()
+	update, err := parseEditValue(editCtx.Field, value, loc)
+	if err != nil {
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "edit.invalid."+editCtx.Field)+"\n\n"+editValuePrompt(user.Language, editCtx.Field))
+		return
+	}
+
+	updates := map[string]interface{}{editCtx.Field: update}
+	if editCtx.Field == fieldCategory {
+		// Map the reply onto the user's taxonomy, like parsed categories
+		if c, err := h.categories.Resolve(ctx, user.ID, update.(string), tx.Type); err == nil {
+			updates[fieldCategory] = c.Name
+			updates["category_id"] = c.ID
+		} else {
+			log.Printf("Failed to resolve category %q: %v", update, err)
+		}
+	}
+
+	updated, err := h.txService.EditTransaction(ctx, tx.TxID, updates)
+	if err != nil {
+		log.Printf("Failed to edit transaction: %v", err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "edit.failed"))
+		return
+	}
+
+	h.stateMachine.ClearState(ctx, user.ID)
+	h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "edit.done", i18n.Args{"Summary": formatTransactionSummary(user.Language, updated)}))
+}
+
+func editValuePrompt(lang, field string) string {
+	switch field {
+	case fieldAmount, fieldCategory, fieldDescription, fieldType, fieldDate:
+		return i18n.T(lang, "edit.ask."+field)
+	}
+	return i18n.T(lang, "edit.ask.value")
+}
+
+// parseEditValue converts a chat reply into the value EditTransaction expects.
+// Errors are shown to the user as the "edit.invalid.<field>" message.
+func parseEditValue(field, value string, loc *time.Location) (interface{}, error) {
+	lower := strings.ToLower(value)
+
+	switch field {
+	case fieldAmount:
+		amount, ok := ai.ExtractAmount(lower)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount")
+		}
+		return amount, nil
+	case fieldCategory:
+		return lower, nil
+	case fieldDescription:
+		return value, nil
+	case fieldType:
+		switch {
+		case strings.Contains(lower, "pemasukan") || strings.Contains(lower, "masuk") || strings.Contains(lower, "mlebu") || strings.Contains(lower, "income"):
+			return domain.TypeIncome, nil
+		case strings.Contains(lower, "pengeluaran") || strings.Contains(lower, "keluar") || strings.Contains(lower, "metu") || strings.Contains(lower, "expense"):
+			return domain.TypeExpense, nil
+		}
+		return nil, fmt.Errorf("invalid type")
+	case fieldDate:
+		return parseEditDate(lower, loc)
+	}
+
+	return nil, fmt.Errorf("unknown field %q", field)
+}
+
+// parseEditDate accepts anything ai.ResolveDate understands: hari ini,
+// kemarin, senin lalu, tgl 5, DD/MM, DD/MM/YYYY and YYYY-MM-DD
+func parseEditDate(value string, loc *time.Location) (time.Time, error) {
+	resolved, err := ai.ResolveDate(value, time.Now().In(loc))
+	if err != nil || resolved == nil {
+		return time.Time{}, fmt.Errorf("invalid date")
+	}
+	return resolved.Time, nil
+}
+
+func formatTransactionSummary(lang string, tx *domain.Transaction) string {
+	return i18n.T(lang, "tx.summary", i18n.Args{
+		"TxID": tx.TxID, "Type": typeLabel(lang, tx.Type), "Amount": tx.Amount, "Description": tx.Description,
+		"Category": tx.Category, "Date": tx.TransactionDate.Format("2006-01-02"),
+	})
+}
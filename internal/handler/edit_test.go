@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+func TestParseEditValue(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*3600)
+
+	tests := []struct {
+		field   string
+		value   string
+		want    interface{}
+		wantErr bool
+	}{
+		{fieldAmount, "45rb", 45000.0, false},
+		{fieldAmount, "Rp 1.250.000", 1250000.0, false},
+		{fieldAmount, "banyak", nil, true},
+		{fieldCategory, "Makan", "makan", false},
+		{fieldDescription, "Kopi Kenangan", "Kopi Kenangan", false},
+		{fieldType, "pemasukan", domain.TypeIncome, false},
+		{fieldType, "uang keluar", domain.TypeExpense, false},
+		{fieldType, "income", domain.TypeIncome, false},
+		{fieldType, "entah", nil, true},
+		{fieldDate, "2026-01-05", time.Date(2026, 1, 5, 0, 0, 0, 0, loc), false},
+		{fieldDate, "besok lusa kemarin", nil, true},
+		{fieldDate, "nanti", nil, true},
+		{"tip", "5rb", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseEditValue(tt.field, tt.value, loc)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEditValue(%s, %q) error = %v, want error %v", tt.field, tt.value, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if want, ok := tt.want.(time.Time); ok {
+			if got, _ := got.(time.Time); !got.Equal(want) {
+				t.Errorf("parseEditValue(%s, %q) = %v, want %v", tt.field, tt.value, got, want)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseEditValue(%s, %q) = %v, want %v", tt.field, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEditConversation(t *testing.T) {
+	b := newTestBot(t, testParsers{})
+
+	expectReply(t, b.send("beli kopi 20rb"), "Transaksi tersimpan")
+
+	// Pick the field, then give the value
+	expectReply(t, b.send("ubah yang terakhir"), "Mau ubah apa?")
+	expectReply(t, b.send("warna"), "Pilih field")
+	expectReply(t, b.send("nominal"), "Nominal barunya berapa?")
+	expectReply(t, b.send("banyak"), "Nominal tidak valid")
+	expectReply(t, b.send("45rb"), "Transaksi diperbarui")
+	if got := b.transactions(); len(got) != 1 || !strings.HasSuffix(got[0], " 45000") {
+		t.Fatalf("transactions after edit = %q", got)
+	}
+
+	// Field and value in one reply
+	expectReply(t, b.send("edit"), "Mau ubah apa?")
+	expectReply(t, b.send("keterangan kopi susu"), "Transaksi diperbarui")
+	if got := b.transactions(); len(got) != 1 || got[0] != "kopi susu 45000" {
+		t.Fatalf("transactions after edit = %q", got)
+	}
+
+	// Leaving the edit changes nothing, and later messages are handled normally
+	expectReply(t, b.send("edit"), "Mau ubah apa?")
+	expectReply(t, b.send("batal"), "Edit dibatalkan")
+	// Deleting asks first
+	expectReply(t, b.send("hapus"), "Hapus transaksi ini?")
+	expectReply(t, b.send("tidak"), "Transaksi tidak dihapus")
+	if got := b.transactions(); len(got) != 1 {
+		t.Fatalf("transactions after a cancelled delete = %q", got)
+	}
+	expectReply(t, b.send("hapus yang terakhir"), "Hapus transaksi ini?")
+	expectReply(t, b.send("ya"), "Transaksi dihapus")
+	if got := b.transactions(); len(got) != 0 {
+		t.Fatalf("transactions after delete = %q", got)
+	}
+	expectReply(t, b.send("edit"), "Transaksi tidak ditemukan")
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"regexp"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/i18n"
+	"github.com/nicolaananda/catatuang/internal/whatsapp"
+)
+
+// languageCommandPattern captures the language named in "bahasa inggris",
+// "ganti bahasa ke jawa" or "change language to english"
+var languageCommandPattern = regexp.MustCompile(`(?i)^\s*(?:(?:ganti|ubah|set|change|switch)\s+)?(?:bahasa|basa|language|lang)\b\s*(?:(?:ke|to)\s+)?(.*)$`)
+
+// handleLanguageCommand handles "bahasa inggris", "language english" and
+// "basa jawa". Without a known language it shows the current one and the
+// choices.
+func (h *WebhookHandler) handleLanguageCommand(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
+	lang, ok := "", false
+	if m := languageCommandPattern.FindStringSubmatch(msg.GetText()); m != nil {
+		lang, ok = i18n.ParseLanguage(m[1])
+	}
+	if !ok {
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "language.menu", i18n.Args{"Current": i18n.Name(user.Language)}))
+		return
+	}
+
+	if err := h.userService.SetLanguage(ctx, user, lang); err != nil {
+		log.Printf("Failed to set language for user %d: %v", user.ID, err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "language.failed"))
+		return
+	}
+
+	h.sendMessage(msg.GetFrom(), i18n.T(lang, "language.changed"))
+}
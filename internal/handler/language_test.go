@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/i18n"
+)
+
+func TestLanguageCommandPattern(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"bahasa inggris", domain.LangEnglish},
+		{"basa jawa", domain.LangJavanese},
+		{"language english", domain.LangEnglish},
+		{"ganti bahasa english", domain.LangEnglish},
+		{"Ganti Bahasa ke Jawa", domain.LangJavanese},
+		{"change language to indonesian", domain.LangIndonesian},
+		{"set lang id", domain.LangIndonesian},
+		{"ubah bahasa", ""},
+		{"bahasa", ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if m := languageCommandPattern.FindStringSubmatch(tt.message); m != nil {
+			got, _ = i18n.ParseLanguage(m[1])
+		}
+		if got != tt.want {
+			t.Errorf("language of %q = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
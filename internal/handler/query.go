@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"log"
+
+	"github.com/nicolaananda/catatuang/internal/ai"
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/i18n"
+	"github.com/nicolaananda/catatuang/internal/whatsapp"
+)
+
+// handleLedgerQuestion answers questions about past transactions, e.g.
+// "berapa total jajan kopi bulan lalu?"
+func (h *WebhookHandler) handleLedgerQuestion(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
+	ctx = h.withCategories(ctx, user)
+
+	plan, err := ai.WithRetry(ctx, h.retryConfig(domain.AICallQuery), func(ctx context.Context) (*domain.LedgerQuery, error) {
+		return h.queryPlanner.PlanQuery(ctx, msg.GetText())
+	})
+	if ai.IsUnavailable(err) {
+		log.Printf("Query planning unavailable: %v", err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "query.unavailable"))
+		return
+	}
+	if err != nil {
+		log.Printf("Query planning failed: %v", err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "query.unclear"))
+		return
+	}
+
+	plan.Categories, err = h.categories.ExpandQueryCategories(ctx, user.ID, plan.Categories, plan.Type)
+	if err != nil {
+		log.Printf("Failed to expand query categories: %v", err)
+	}
+
+	result, err := h.reportService.QueryLedger(ctx, user.ID, plan)
+	if err != nil {
+		log.Printf("Ledger query failed: %v", err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "query.failed"))
+		return
+	}
+
+	h.sendMessage(msg.GetFrom(), h.reportService.FormatLedgerAnswer(user.Language, plan, result))
+}
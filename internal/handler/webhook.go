@@ -18,6 +18,8 @@ import (
 	"github.com/nicolaananda/catatuang/internal/ai"
 	"github.com/nicolaananda/catatuang/internal/config"
 	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/i18n"
+	"github.com/nicolaananda/catatuang/internal/queue"
 	"github.com/nicolaananda/catatuang/internal/repository"
 	"github.com/nicolaananda/catatuang/internal/service"
 	"github.com/nicolaananda/catatuang/internal/statemachine"
@@ -28,28 +30,44 @@ type WebhookHandler struct {
 	cfg           *config.Config
 	db            *sql.DB
 	waClient      *whatsapp.Client
-	textParser    *ai.TextParser
-	visionParser  *ai.VisionParser
+	textParser    ai.TransactionParser
+	visionParser  ai.ImageParser
+	transcriber   ai.Transcriber
+	queryPlanner  ai.QueryPlanner
+	intents       *ai.IntentRouter
 	userService   *service.UserService
 	txService     *service.TransactionService
 	reportService *service.ReportService
+	categories    *service.CategoryService
 	stateMachine  *statemachine.StateMachine
 	dedupRepo     *repository.DedupRepository
 	auditRepo     *repository.AuditRepository
+	inbound       *queue.InboundQueue
+	outbound      *queue.OutboundQueue
+	deferred      *repository.DeferredMessageRepository
+	breaker       *ai.CircuitBreaker
 }
 
 func NewWebhookHandler(
 	cfg *config.Config,
 	db *sql.DB,
 	waClient *whatsapp.Client,
-	textParser *ai.TextParser,
-	visionParser *ai.VisionParser,
+	textParser ai.TransactionParser,
+	visionParser ai.ImageParser,
+	transcriber ai.Transcriber,
+	queryPlanner ai.QueryPlanner,
+	intents *ai.IntentRouter,
 	userService *service.UserService,
 	txService *service.TransactionService,
 	reportService *service.ReportService,
+	categories *service.CategoryService,
 	stateMachine *statemachine.StateMachine,
 	dedupRepo *repository.DedupRepository,
 	auditRepo *repository.AuditRepository,
+	inbound *queue.InboundQueue,
+	outbound *queue.OutboundQueue,
+	deferred *repository.DeferredMessageRepository,
+	breaker *ai.CircuitBreaker,
 ) *WebhookHandler {
 	return &WebhookHandler{
 		cfg:           cfg,
@@ -57,12 +75,20 @@ func NewWebhookHandler(
 		waClient:      waClient,
 		textParser:    textParser,
 		visionParser:  visionParser,
+		transcriber:   transcriber,
+		queryPlanner:  queryPlanner,
+		intents:       intents,
 		userService:   userService,
 		txService:     txService,
 		reportService: reportService,
+		categories:    categories,
 		stateMachine:  stateMachine,
 		dedupRepo:     dedupRepo,
 		auditRepo:     auditRepo,
+		inbound:       inbound,
+		outbound:      outbound,
+		deferred:      deferred,
+		breaker:       breaker,
 	}
 }
 
@@ -119,6 +145,15 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Delivery and read receipts for messages we sent
+	var receipt whatsapp.ReceiptEvent
+	if err := json.Unmarshal(body, &receipt); err == nil && receipt.IsReceipt() {
+		h.handleReceipt(r.Context(), &receipt)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+		return
+	}
+
 	// Filter: only process actual messages, ignore acks and other events
 	// GOWA sends different event types, we only want chat messages
 	if msg.Message.ID == "" || msg.SenderID == "" {
@@ -128,89 +163,137 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Process message asynchronously
-	go h.processMessage(context.Background(), &msg)
+	// Persist before acknowledging so the message survives crashes and deploys;
+	// on failure GOWA gets a 500 and can redeliver
+	if err := h.inbound.Enqueue(r.Context(), msg.GetMessageID(), msg.GetMSISDN(), msg.GetTimestamp(), body); err != nil {
+		log.Printf("Failed to enqueue message %s: %v", msg.GetMessageID(), err)
+		http.Error(w, "Failed to queue message", http.StatusInternalServerError)
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
 
-func (h *WebhookHandler) processMessage(ctx context.Context, msg *whatsapp.IncomingMessage) {
-	// Check deduplication
-	processed, err := h.dedupRepo.IsProcessed(ctx, msg.GetMessageID())
-	if err != nil {
-		log.Printf("Failed to check dedup: %v", err)
-		return
+// ProcessJob handles one queued inbound message. Returned errors are retried
+// by the inbound queue, so they must only come from steps that ran before the
+// user got any reply.
+func (h *WebhookHandler) ProcessJob(ctx context.Context, job *domain.InboundJob) error {
+	var msg whatsapp.IncomingMessage
+	if err := json.Unmarshal(job.Payload, &msg); err != nil {
+		return fmt.Errorf("failed to unmarshal job payload: %w", err)
 	}
-	if processed {
-		log.Printf("Message already processed: %s", msg.GetMessageID())
-		return
+
+	if err := h.processMessage(ctx, &msg); err != nil {
+		return err
 	}
 
 	// Mark as processed
 	if err := h.dedupRepo.MarkProcessed(ctx, msg.GetMessageID()); err != nil {
 		log.Printf("Failed to mark processed: %v", err)
-		return
+	}
+
+	return nil
+}
+
+// JobDead tells the user their message could not be processed
+func (h *WebhookHandler) JobDead(ctx context.Context, job *domain.InboundJob) {
+	log.Printf("Message %s moved to dead letter: %s", job.WAMessageID, job.LastError)
+	h.sendMessage(job.MSISDN, i18n.T(h.languageOf(ctx, job.MSISDN), "job.dead"))
+}
+
+// languageOf returns the reply language of the user at msisdn, or the default
+// language for unknown numbers
+func (h *WebhookHandler) languageOf(ctx context.Context, msisdn string) string {
+	user, err := h.userService.GetUserByMSISDN(ctx, msisdn)
+	if err != nil || user == nil {
+		return domain.DefaultLanguage
+	}
+	return user.Language
+}
+
+func (h *WebhookHandler) processMessage(ctx context.Context, msg *whatsapp.IncomingMessage) error {
+	// Check deduplication; a job may be re-run after it finished but before
+	// its status was saved
+	processed, err := h.dedupRepo.IsProcessed(ctx, msg.GetMessageID())
+	if err != nil {
+		return fmt.Errorf("failed to check dedup: %w", err)
+	}
+	if processed {
+		log.Printf("Message already processed: %s", msg.GetMessageID())
+		return nil
 	}
 
 	// Get or create user (use MSISDN without @s.whatsapp.net suffix)
 	user, isNew, err := h.userService.GetOrCreateUser(ctx, msg.GetMSISDN())
 	if err != nil {
-		log.Printf("Failed to get/create user: %v", err)
-		h.sendMessage(msg.GetFrom(), "Maaf, terjadi kesalahan sistem 😔")
-		return
+		return fmt.Errorf("failed to get/create user: %w", err)
+	}
+
+	// Attribute AI calls made for this message to the user
+	ctx = ai.WithCallInfo(ctx, user.ID, msg.GetMessageID())
+	// "kemarin" means the day before the message was sent, even when it is
+	// processed late
+	if sentAt := msg.GetTimestamp(); !sentAt.IsZero() {
+		ctx = ai.WithSentAt(ctx, sentAt)
 	}
 
 	// Check if user is blocked
 	if user.IsBlocked {
-		h.sendMessage(msg.GetFrom(), "Akun Anda diblokir. Hubungi admin untuk informasi lebih lanjut.")
-		return
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "user.blocked"))
+		return nil
 	}
 
 	// Handle admin commands
 	if msg.GetFrom() == h.cfg.AdminMSISDN {
 		if h.handleAdminCommand(ctx, msg) {
-			return
+			return nil
 		}
 	}
 
 	// Handle new user onboarding
 	if isNew {
 		h.handleOnboarding(ctx, user, msg)
-		return
+		return nil
 	}
 
 	// Get conversation state
 	state, err := h.stateMachine.GetState(ctx, user.ID)
 	if err != nil {
-		log.Printf("Failed to get state: %v", err)
-		state = &domain.ConversationState{State: domain.StateActive}
+		return fmt.Errorf("failed to get state: %w", err)
 	}
 
 	// Route based on state
 	switch state.State {
 	case domain.StateOnboardingSelectPlan:
 		h.handlePlanSelection(ctx, user, msg)
+	case domain.StateAwaitingConfirm:
+		h.handleConfirmation(ctx, user, msg, state)
+	case domain.StateEditingTransaction:
+		h.handleEditingState(ctx, user, msg, state)
+	case domain.StateAwaitingDelete:
+		h.handleDeleteConfirmation(ctx, user, msg, state)
 	case domain.StateActive:
 		h.handleActiveState(ctx, user, msg)
 	default:
 		h.handleActiveState(ctx, user, msg)
 	}
+
+	return nil
 }
 
 func (h *WebhookHandler) handleOnboarding(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
+	// Reply in the language of the first message; "bahasa ..." changes it later
+	if lang, ok := i18n.Detect(msg.GetText()); ok && lang != user.Language {
+		if err := h.userService.SetLanguage(ctx, user, lang); err != nil {
+			log.Printf("Failed to set language for user %d: %v", user.ID, err)
+		}
+	}
+
 	// Set state to onboarding
 	h.stateMachine.SetState(ctx, user.ID, domain.StateOnboardingSelectPlan, nil, h.cfg.StateExpiryMinutes)
 
-	onboardingMsg := `Halo! Aku bot pencatat keuangan 📒
-
-Pilih paket:
-1️⃣ Free (10 transaksi)
-2️⃣ Premium Rp10rb/bulan (hubungi admin 081389592985)
-
-Ketik *1* atau *2* untuk memilih.`
-
-	h.sendMessage(msg.GetFrom(), onboardingMsg)
+	h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "onboarding.welcome"))
 }
 
 func (h *WebhookHandler) handlePlanSelection(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
@@ -220,181 +303,442 @@ func (h *WebhookHandler) handlePlanSelection(ctx context.Context, user *domain.U
 		user.Plan = domain.PlanFree
 		h.userService.GetOrCreateUser(ctx, user.MSISDN) // This will update
 		h.stateMachine.ClearState(ctx, user.ID)
-		h.sendMessage(msg.GetFrom(), "✅ Paket Free aktif! Kamu bisa mencatat hingga 10 transaksi.\n\nContoh penggunaan:\n• catat pemasukan 100000 gaji\n• beli bensin 50rb\n• atau kirim foto struk!")
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "onboarding.free"))
 	} else if text == "2" {
 		user.Plan = domain.PlanPendingPremium
 		h.userService.GetOrCreateUser(ctx, user.MSISDN)
 		h.stateMachine.ClearState(ctx, user.ID)
-		h.sendMessage(msg.GetFrom(), "📞 Silakan hubungi admin di 081389592985 untuk upgrade ke Premium.\n\nSementara itu, kamu bisa pakai paket Free (10 transaksi).")
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "onboarding.premium"))
 	} else {
-		h.sendMessage(msg.GetFrom(), "Pilihan tidak valid. Ketik *1* untuk Free atau *2* untuk Premium.")
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "onboarding.invalid"))
 	}
 }
 
 func (h *WebhookHandler) handleActiveState(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
-	text := strings.ToLower(strings.TrimSpace(msg.GetText()))
-
-	// Check for report requests
-	if strings.Contains(text, "rekap") || strings.Contains(text, "laporan") {
-		h.handleReportRequest(ctx, user, msg)
+	// Handle image
+	if msg.IsImage() {
+		h.handleImageTransaction(ctx, user, msg)
 		return
 	}
 
-	// Check for undo
-	if text == "undo" || text == "batal" {
-		h.handleUndo(ctx, user, msg)
+	// Handle voice note
+	if msg.IsAudio() {
+		h.handleVoiceTransaction(ctx, user, msg)
 		return
 	}
 
-	// Handle image
-	if msg.IsImage() {
-		h.handleImageTransaction(ctx, user, msg)
+	// Other attachments (PDF, etc.) can't be read yet
+	if msg.GetMedia() != nil {
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "media.unsupported"))
 		return
 	}
 
-	// Handle text transaction
-	if msg.IsText() && ai.ShouldTriggerParsing(text) {
+	intent := h.intents.Route(ctx, msg.GetText())
+	log.Printf("Intent for %s: %s (%s, %.2f)", msg.GetMessageID(), intent.Kind, intent.Source, intent.Confidence)
+
+	switch intent.Kind {
+	case domain.IntentRecord:
 		h.handleTextTransaction(ctx, user, msg)
+	case domain.IntentReport:
+		if intent.Period == domain.PeriodItems {
+			h.handleItemBreakdown(ctx, user, msg, intent.TxID)
+		} else {
+			h.handleReportRequest(ctx, user, msg, intent.Period)
+		}
+	case domain.IntentQuery:
+		h.handleLedgerQuestion(ctx, user, msg)
+	case domain.IntentUndo:
+		h.handleUndo(ctx, user, msg)
+	case domain.IntentEdit:
+		h.handleEditCommand(ctx, user, msg, intent.TxID)
+	case domain.IntentDelete:
+		h.handleDeleteCommand(ctx, user, msg, intent.TxID)
+	case domain.IntentSettings:
+		if intent.Setting == domain.SettingLanguage {
+			h.handleLanguageCommand(ctx, user, msg)
+		} else {
+			h.handleCategoryCommand(ctx, user, msg)
+		}
+	case domain.IntentSmalltalk:
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "smalltalk"))
+	default:
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "help"))
+	}
+}
+
+func (h *WebhookHandler) handleTextTransaction(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
+	h.recordFromText(ctx, user, msg, msg.GetText(), "")
+}
+
+func (h *WebhookHandler) handleVoiceTransaction(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
+	maxBytes := int64(h.cfg.MaxMediaSizeMB) * 1024 * 1024
+
+	audio, err := h.waClient.DownloadMedia(ctx, msg.Audio.Location(), maxBytes)
+	if err != nil {
+		log.Printf("Failed to download audio %s: %v", msg.Audio.Location(), err)
+		if strings.Contains(err.Error(), "too large") {
+			h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "voice.too_large", i18n.Args{"MaxMB": h.cfg.MaxMediaSizeMB}))
+		} else {
+			h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "voice.download_failed"))
+		}
 		return
 	}
 
-	// Default help message
-	h.sendMessage(msg.GetFrom(), `Aku bisa bantu kamu:
-• Catat transaksi: "catat pemasukan 100rb gaji"
-• Kirim foto struk
-• Lihat rekap: "rekap hari ini", "rekap bulan ini"
-• Undo transaksi terakhir: "undo"`)
+	transcript, err := h.transcriber.Transcribe(ctx, audio, msg.Audio.MimeType, user.Language)
+
+	// The speech-to-text service is down: keep the voice note and transcribe
+	// it once it's back
+	if ai.IsUnavailable(err) {
+		log.Printf("Transcription unavailable, deferring %s: %v", msg.GetMessageID(), err)
+		h.deferMessage(ctx, user, msg, &domain.DeferredMessage{Kind: domain.DeferredAudio, Media: audio, MimeType: msg.Audio.MimeType}, "")
+		return
+	}
+	if err != nil {
+		log.Printf("Transcription failed: %v", err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "voice.transcribe_failed"))
+		return
+	}
+
+	// Echo what was heard so the user can spot transcription mistakes
+	h.recordFromText(ctx, user, msg, transcript, fmt.Sprintf("🎤 \"%s\"\n\n", transcript))
 }
 
-func (h *WebhookHandler) handleTextTransaction(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
+// retryConfig is the retry policy for model calls made while the user waits
+func (h *WebhookHandler) retryConfig(op string) ai.RetryConfig {
+	return ai.RetryConfig{
+		Op:             op,
+		MaxRetries:     h.cfg.AIMaxRetries,
+		Delay:          time.Second,
+		MaxDelay:       10 * time.Second,
+		AttemptTimeout: time.Duration(h.cfg.AITimeoutSeconds) * time.Second,
+		Breaker:        h.breaker,
+	}
+}
+
+// recordFromText parses text into transactions and saves, confirms or rejects
+// them. echo is prepended to the reply, e.g. the transcript of a voice note.
+func (h *WebhookHandler) recordFromText(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage, text, echo string) {
+	ctx = h.withCategories(ctx, user)
+
 	// Parse with AI
-	parsed, err := ai.WithRetry(ctx, ai.RetryConfig{
-		MaxRetries: h.cfg.AIMaxRetries,
-		Delay:      2 * time.Second,
-	}, func(ctx context.Context) (*domain.ParsedTransaction, error) {
-		return h.textParser.Parse(ctx, msg.GetText())
+	parsed, err := ai.WithRetry(ctx, h.retryConfig(domain.AICallText), func(ctx context.Context) ([]*domain.ParsedTransaction, error) {
+		return h.textParser.Parse(ctx, text)
 	})
 
+	// The model is down: keep the message and parse it once it's back
+	if ai.IsUnavailable(err) {
+		log.Printf("AI unavailable, deferring %s: %v", msg.GetMessageID(), err)
+		h.deferMessage(ctx, user, msg, &domain.DeferredMessage{Kind: domain.DeferredText, Text: text}, echo)
+		return
+	}
+
+	h.handleParsedText(ctx, user, msg.GetFrom(), msg.GetMessageID(), parsed, err, text, echo)
+}
+
+// handleParsedText saves, confirms or rejects transactions parsed from text
+// and replies to the user at to
+func (h *WebhookHandler) handleParsedText(ctx context.Context, user *domain.User, to, waMessageID string, parsed []*domain.ParsedTransaction, err error, text, echo string) {
 	if err != nil {
 		log.Printf("AI parsing failed: %v", err)
-		h.sendMessage(msg.GetFrom(), "Maaf, aku belum bisa memahami pesan ini 😅\n\nContoh: catat pemasukan 100000 gaji")
+		h.sendMessage(to, echo+i18n.T(user.Language, "parse.failed"))
 		return
 	}
+	h.applyCategoryMemory(ctx, user, parsed)
 
-	// Check confidence
-	if parsed.ShouldReject() {
-		h.sendMessage(msg.GetFrom(), "Aku kurang yakin dengan transaksi ini 🤔\n\nCoba tulis lebih jelas, contoh:\n• catat pemasukan 100000 gaji\n• beli bensin 50rb")
+	// Check confidence; the least confident entry decides for the whole message
+	lowest := domain.LeastConfident(parsed)
+	if lowest.ShouldReject() {
+		h.sendMessage(to, echo+i18n.T(user.Language, "parse.unsure"))
 		return
 	}
 
-	if parsed.NeedsConfirmation() {
-		// TODO: Implement confirmation flow
-		h.sendMessage(msg.GetFrom(), fmt.Sprintf("Konfirmasi transaksi:\n%s Rp%.0f - %s\n\nKetik *ya* untuk simpan atau *tidak* untuk batal.",
-			parsed.Type, parsed.Amount, parsed.Description))
+	// Dates far ahead are usually a misread ("tgl 5" meant last month)
+	if lowest.NeedsConfirmation() || domain.AnyFarFuture(parsed, time.Now()) {
+		h.askConfirmation(ctx, user, to, waMessageID, parsed, text, echo)
 		return
 	}
 
 	// Auto-save (high confidence)
-	tx, err := h.txService.RecordTransaction(ctx, user, parsed, msg.GetMessageID(), h.cfg.OpenAIModel, h.cfg.FreeTransactionLimit)
+	h.saveTransactions(ctx, user, to, parsed, waMessageID, echo)
+}
+
+// saveTransactions records parsed transactions and replies with the result,
+// prefixed with echo
+func (h *WebhookHandler) saveTransactions(ctx context.Context, user *domain.User, to string, parsed []*domain.ParsedTransaction, waMessageID, echo string) {
+	// Corrections made while confirming may have changed the category
+	h.canonicalizeCategories(ctx, user, parsed)
+
+	txs, err := h.txService.RecordTransactions(ctx, user, parsed, waMessageID, h.cfg.OpenAIModel, h.cfg.FreeTransactionLimit)
 	if err != nil {
 		if strings.Contains(err.Error(), "free limit") {
-			h.sendMessage(msg.GetFrom(), "❌ Limit free sudah habis (10 transaksi).\n\nUpgrade ke Premium? Hubungi admin 081389592985")
+			h.sendMessage(to, i18n.T(user.Language, "tx.limit"))
 		} else {
 			log.Printf("Failed to record transaction: %v", err)
-			h.sendMessage(msg.GetFrom(), "Maaf, gagal menyimpan transaksi 😔")
+			h.sendMessage(to, i18n.T(user.Language, "tx.save_failed"))
+		}
+		return
+	}
+
+	if len(txs) == 1 {
+		tx := txs[0]
+		itemsNote := ""
+		if len(tx.Items) > 0 {
+			itemsNote = i18n.T(user.Language, "tx.items_note", i18n.Args{"Count": len(tx.Items)})
 		}
+		h.sendMessage(to, echo+i18n.T(user.Language, "tx.saved", i18n.Args{
+			"Emoji": typeEmoji(tx.Type), "Type": typeLabel(user.Language, tx.Type), "Amount": tx.Amount,
+			"Description": tx.Description, "TxID": tx.TxID, "ItemsNote": itemsNote,
+		}))
 		return
 	}
 
-	emoji := "💰"
-	if parsed.Type == domain.TypeExpense {
-		emoji = "💸"
+	var sb strings.Builder
+	sb.WriteString(echo)
+	sb.WriteString(i18n.T(user.Language, "tx.saved_many", i18n.Args{"Count": len(txs)}))
+	for _, tx := range txs {
+		sb.WriteString(fmt.Sprintf("\n%s %s\nRp %.0f - %s\nID: %s\n", typeEmoji(tx.Type), typeLabel(user.Language, tx.Type), tx.Amount, tx.Description, tx.TxID))
+	}
+	sb.WriteString(i18n.T(user.Language, "tx.undo_all"))
+
+	h.sendMessage(to, sb.String())
+}
+
+func typeEmoji(txType string) string {
+	if txType == domain.TypeExpense {
+		return "💸"
+	}
+	return "💰"
+}
+
+// typeLabel names a transaction type in lang
+func typeLabel(lang, txType string) string {
+	return i18n.T(lang, "type."+txType)
+}
+
+// formatParsedList lists pending transactions for confirmation messages
+func formatParsedList(lang string, parsed []*domain.ParsedTransaction) string {
+	var sb strings.Builder
+	for i, p := range parsed {
+		if len(parsed) > 1 {
+			sb.WriteString(fmt.Sprintf("%d. ", i+1))
+		}
+		sb.WriteString(fmt.Sprintf("%s Rp%.0f - %s (%s)\n", typeLabel(lang, p.Type), p.Amount, p.Description, p.Category))
+		if p.IsFarFuture(time.Now()) {
+			sb.WriteString(i18n.T(lang, "confirm.far_future", i18n.Args{"Date": p.Date.Format("02/01/2006 15:04")}))
+		}
+		if len(p.Items) > 0 && !p.ItemsMatchAmount() {
+			sb.WriteString(i18n.T(lang, "confirm.items_mismatch", i18n.Args{"Amount": p.Amount, "Count": len(p.Items), "ItemsTotal": p.ItemsTotal()}))
+		}
+	}
+	return sb.String()
+}
+
+// askConfirmation stores medium-confidence parses and asks the user at to to
+// confirm them. original is the text the transactions were parsed from.
+func (h *WebhookHandler) askConfirmation(ctx context.Context, user *domain.User, to, waMessageID string, parsed []*domain.ParsedTransaction, original, echo string) {
+	h.canonicalizeCategories(ctx, user, parsed)
+
+	confirmCtx := &domain.ConfirmContext{
+		ParsedTransactions: parsed,
+		OriginalMessage:    original,
+		MessageID:          waMessageID,
+	}
+
+	if err := h.stateMachine.SetState(ctx, user.ID, domain.StateAwaitingConfirm, confirmCtx, h.cfg.StateExpiryMinutes); err != nil {
+		log.Printf("Failed to set confirm state: %v", err)
+		h.sendMessage(to, i18n.T(user.Language, "system.error"))
+		return
+	}
+
+	hint := i18n.T(user.Language, "confirm.hint")
+	if len(parsed) > 1 {
+		hint = ""
+	}
+
+	h.sendMessage(to, echo+i18n.T(user.Language, "confirm.ask", i18n.Args{"List": formatParsedList(user.Language, parsed), "Hint": hint}))
+}
+
+func (h *WebhookHandler) handleConfirmation(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage, state *domain.ConversationState) {
+	var confirmCtx domain.ConfirmContext
+	if err := json.Unmarshal(state.Context, &confirmCtx); err != nil || len(confirmCtx.ParsedTransactions) == 0 {
+		log.Printf("Invalid confirm context for user %d: %v", user.ID, err)
+		h.stateMachine.ClearState(ctx, user.ID)
+		h.handleActiveState(ctx, user, msg)
+		return
 	}
 
-	h.sendMessage(msg.GetFrom(), fmt.Sprintf("✅ Transaksi tersimpan!\n\n%s %s\nRp %.0f - %s\n\nID: %s\nKetik *undo* dalam 60 detik untuk membatalkan.",
-		emoji, parsed.Type, parsed.Amount, parsed.Description, tx.TxID))
+	answer, correction := splitConfirmReply(msg.GetText())
+
+	switch answer {
+	case confirmYes:
+		parsed := confirmCtx.ParsedTransactions
+		if correction != "" {
+			// Corrections are ambiguous when several transactions are pending
+			if len(parsed) > 1 || !applyCorrection(parsed[0], correction) {
+				// Keep the pending record so the user can try again
+				h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "confirm.correction_unclear"))
+				return
+			}
+		}
+		h.stateMachine.ClearState(ctx, user.ID)
+		h.saveTransactions(ctx, user, msg.GetFrom(), parsed, confirmCtx.MessageID, "")
+	case confirmNo:
+		h.stateMachine.ClearState(ctx, user.ID)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "confirm.cancelled"))
+	default:
+		// Anything else drops the pending record and is handled as a new message
+		h.stateMachine.ClearState(ctx, user.ID)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "confirm.dropped"))
+		h.handleActiveState(ctx, user, msg)
+	}
+}
+
+// ExpirePendingConfirmations notifies users whose unconfirmed transactions timed out
+func (h *WebhookHandler) ExpirePendingConfirmations(ctx context.Context) {
+	states, err := h.stateMachine.TakeExpired(ctx, domain.StateAwaitingConfirm)
+	if err != nil {
+		log.Printf("Failed to expire confirmations: %v", err)
+		return
+	}
+
+	for _, state := range states {
+		user, err := h.userService.GetUserByID(ctx, state.UserID)
+		if err != nil || user == nil {
+			log.Printf("Failed to get user %d for expired confirmation: %v", state.UserID, err)
+			continue
+		}
+
+		summary := ""
+		var confirmCtx domain.ConfirmContext
+		if json.Unmarshal(state.Context, &confirmCtx) == nil && len(confirmCtx.ParsedTransactions) > 0 {
+			summary = "\n\n" + strings.TrimSpace(formatParsedList(user.Language, confirmCtx.ParsedTransactions))
+		}
+
+		h.sendMessage(user.MSISDN, i18n.T(user.Language, "confirm.expired", i18n.Args{"Summary": summary}))
+	}
 }
 
 func (h *WebhookHandler) handleImageTransaction(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
-	// TODO: Implement image handling for GOWA format
-	// GOWA sends media in different format than expected
-	h.sendMessage(msg.GetFrom(), "Maaf, fitur gambar sedang dalam pengembangan 🚧")
+	media := msg.GetMedia()
+	maxBytes := int64(h.cfg.MaxMediaSizeMB) * 1024 * 1024
+
+	imageData, err := h.waClient.DownloadMedia(ctx, media.Location(), maxBytes)
+	if err != nil {
+		log.Printf("Failed to download media %s: %v", media.Location(), err)
+		if strings.Contains(err.Error(), "too large") {
+			h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "image.too_large", i18n.Args{"MaxMB": h.cfg.MaxMediaSizeMB}))
+		} else {
+			h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "image.download_failed"))
+		}
+		return
+	}
+
+	// Trust the payload mime type, but sniff it when GOWA leaves it out
+	mimeType := media.MimeType
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(imageData)
+	}
+
+	ctx = h.withCategories(ctx, user)
 
 	// Parse with vision AI
-	parsed, err := ai.WithRetry(ctx, ai.RetryConfig{
-		MaxRetries: h.cfg.AIMaxRetries,
-		Delay:      2 * time.Second,
-	}, func(ctx context.Context) (*domain.ParsedTransaction, error) {
-		// Assuming imageData is available from msg.GetImage() or similar
-		// This part of the code was incomplete/incorrect in the original
-		// For now, we'll assume imageData is a placeholder.
-		// In a real scenario, you'd extract the image data from the incoming message.
-		var imageData []byte // Placeholder for actual image data extraction
-		return h.visionParser.ParseImage(ctx, imageData)
+	parsed, err := ai.WithRetry(ctx, h.retryConfig(domain.AICallVision), func(ctx context.Context) (*domain.ParsedTransaction, error) {
+		return h.visionParser.ParseImage(ctx, imageData, mimeType, msg.GetCaption())
 	})
 
-	if err != nil || parsed.ShouldReject() {
-		h.sendMessage(msg.GetFrom(), "Aku belum bisa membaca gambar ini 😅\n\nBisa kirim ulang atau ketik manual?")
+	if ai.IsUnavailable(err) {
+		log.Printf("AI unavailable, deferring %s: %v", msg.GetMessageID(), err)
+		h.deferMessage(ctx, user, msg, &domain.DeferredMessage{
+			Kind: domain.DeferredImage, Text: msg.GetCaption(), Media: imageData, MimeType: mimeType,
+		}, "")
 		return
 	}
 
-	// Record transaction
-	tx, err := h.txService.RecordTransaction(ctx, user, parsed, msg.GetMessageID(), h.cfg.OpenAIModel, h.cfg.FreeTransactionLimit)
-	if err != nil {
-		if strings.Contains(err.Error(), "free limit") {
-			h.sendMessage(msg.GetFrom(), "❌ Limit free sudah habis (10 transaksi).")
-		} else {
-			h.sendMessage(msg.GetFrom(), "Gagal menyimpan transaksi 😔")
+	h.handleParsedImage(ctx, user, msg.GetFrom(), msg.GetMessageID(), parsed, err, msg.GetCaption(), "")
+}
+
+// handleParsedImage saves, confirms or rejects a transaction read from an
+// image and replies to the user at to
+func (h *WebhookHandler) handleParsedImage(ctx context.Context, user *domain.User, to, waMessageID string, parsed *domain.ParsedTransaction, err error, caption, echo string) {
+	if err != nil || parsed.ShouldReject() {
+		if err != nil {
+			log.Printf("Vision parsing failed: %v", err)
 		}
+		h.sendMessage(to, echo+i18n.T(user.Language, "image.unreadable"))
 		return
 	}
+	h.applyCategoryMemory(ctx, user, []*domain.ParsedTransaction{parsed})
 
-	h.sendMessage(msg.GetFrom(), fmt.Sprintf("✅ Transaksi dari gambar tersimpan!\n\nRp %.0f - %s\nID: %s",
-		parsed.Amount, parsed.Description, tx.TxID))
+	if parsed.NeedsConfirmation() || parsed.IsFarFuture(time.Now()) {
+		h.askConfirmation(ctx, user, to, waMessageID, []*domain.ParsedTransaction{parsed}, caption, echo)
+		return
+	}
+
+	h.saveTransactions(ctx, user, to, []*domain.ParsedTransaction{parsed}, waMessageID, echo)
 }
 
 func (h *WebhookHandler) handleUndo(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
-	err := h.txService.UndoTransaction(ctx, user.ID, h.cfg.UndoWindowSeconds)
+	undone, err := h.txService.UndoTransaction(ctx, user.ID, h.cfg.UndoWindowSeconds)
 	if err != nil {
 		if strings.Contains(err.Error(), "no transaction") {
-			h.sendMessage(msg.GetFrom(), "Tidak ada transaksi untuk dibatalkan.")
+			h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "undo.none"))
 		} else if strings.Contains(err.Error(), "window expired") {
-			h.sendMessage(msg.GetFrom(), "Waktu undo sudah habis (60 detik).")
+			h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "undo.expired"))
 		} else {
-			h.sendMessage(msg.GetFrom(), "Gagal membatalkan transaksi 😔")
+			h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "undo.failed"))
 		}
 		return
 	}
 
-	h.sendMessage(msg.GetFrom(), "✅ Transaksi terakhir dibatalkan!")
+	if len(undone) > 1 {
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "undo.done_many", i18n.Args{"Count": len(undone)}))
+		return
+	}
+
+	h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "undo.done"))
 }
 
-func (h *WebhookHandler) handleReportRequest(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage) {
-	text := strings.ToLower(msg.GetText())
+func (h *WebhookHandler) handleReportRequest(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage, period string) {
 	loc, _ := h.cfg.GetLocation()
 
 	var report string
 	var err error
 
-	if strings.Contains(text, "hari ini") || strings.Contains(text, "harian") {
-		report, err = h.reportService.GetDailyReport(ctx, user.ID, loc)
-	} else if strings.Contains(text, "minggu") {
-		report, err = h.reportService.GetWeeklyReport(ctx, user.ID, loc)
-	} else if strings.Contains(text, "bulan") {
-		report, err = h.reportService.GetMonthlyReport(ctx, user.ID, loc)
-	} else {
-		report, err = h.reportService.GetDailyReport(ctx, user.ID, loc)
+	switch period {
+	case domain.PeriodWeek:
+		report, err = h.reportService.GetWeeklyReport(ctx, user.ID, loc, user.Language)
+	case domain.PeriodMonth:
+		report, err = h.reportService.GetMonthlyReport(ctx, user.ID, loc, user.Language)
+	default:
+		report, err = h.reportService.GetDailyReport(ctx, user.ID, loc, user.Language)
 	}
 
 	if err != nil {
 		log.Printf("Failed to generate report: %v", err)
-		h.sendMessage(msg.GetFrom(), "Gagal membuat rekap 😔")
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "report.failed"))
 		return
 	}
 
 	h.sendMessage(msg.GetFrom(), report)
 }
 
+func (h *WebhookHandler) handleItemBreakdown(ctx context.Context, user *domain.User, msg *whatsapp.IncomingMessage, txID string) {
+	tx, err := h.txService.GetUserTransaction(ctx, user.ID, txID)
+	if err != nil {
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "items.not_found"))
+		return
+	}
+
+	items, err := h.txService.GetTransactionItems(ctx, tx.ID)
+	if err != nil {
+		log.Printf("Failed to get transaction items: %v", err)
+		h.sendMessage(msg.GetFrom(), i18n.T(user.Language, "items.failed"))
+		return
+	}
+
+	h.sendMessage(msg.GetFrom(), h.reportService.FormatItemBreakdown(user.Language, tx, items))
+}
+
 func (h *WebhookHandler) handleAdminCommand(ctx context.Context, msg *whatsapp.IncomingMessage) bool {
 	text := strings.TrimSpace(msg.GetText())
 
@@ -422,7 +766,7 @@ func (h *WebhookHandler) handleAdminCommand(ctx context.Context, msg *whatsapp.I
 						"months":     1,
 					})
 					h.sendMessage(msg.GetFrom(), fmt.Sprintf("✅ %s upgraded to Premium", msisdn))
-					h.sendMessage(msisdn, "🎉 Akun kamu sudah di-upgrade ke Premium! Unlimited transaksi.")
+					h.sendMessage(msisdn, i18n.T(h.languageOf(ctx, msisdn), "premium.upgraded"))
 				}
 				return true
 			}
@@ -452,14 +796,34 @@ func (h *WebhookHandler) handleAdminCommand(ctx context.Context, msg *whatsapp.I
 	return false
 }
 
+// handleReceipt matches GOWA acks back to outbound messages
+func (h *WebhookHandler) handleReceipt(ctx context.Context, receipt *whatsapp.ReceiptEvent) {
+	var status string
+	switch {
+	case receipt.IsRead():
+		status = domain.OutboundRead
+	case receipt.IsDelivered():
+		status = domain.OutboundDelivered
+	default:
+		return
+	}
+
+	for _, id := range receipt.Payload.IDs {
+		if err := h.outbound.RecordReceipt(ctx, id, status); err != nil {
+			log.Printf("Failed to record receipt for %s: %v", id, err)
+		}
+	}
+}
+
+// sendMessage queues a reply; the outbound queue handles rate limits and retries
 func (h *WebhookHandler) sendMessage(to, message string) {
 	// Skip if phone number is empty (happens with ack events)
 	if to == "" {
 		return
 	}
 
-	if err := h.waClient.SendMessage(to, message); err != nil {
-		log.Printf("Failed to send message to %s: %v", to, err)
+	if err := h.outbound.Enqueue(context.Background(), to, message); err != nil {
+		log.Printf("Failed to queue message to %s: %v", to, err)
 	}
 }
 
@@ -0,0 +1,419 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/ai"
+	"github.com/nicolaananda/catatuang/internal/config"
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/queue"
+	"github.com/nicolaananda/catatuang/internal/repository"
+	"github.com/nicolaananda/catatuang/internal/service"
+	"github.com/nicolaananda/catatuang/internal/statemachine"
+	"github.com/nicolaananda/catatuang/internal/testutil"
+	"github.com/nicolaananda/catatuang/internal/whatsapp"
+)
+
+const (
+	testMSISDN        = "6281234567890"
+	testWebhookSecret = "secret"
+)
+
+// testBot runs webhook payloads of one registered user through the handler
+// against a test database, offline: parsing is done by ai.FakeParser and
+// replies stay in the outbound queue
+type testBot struct {
+	t       *testing.T
+	db      *sql.DB
+	h       *WebhookHandler
+	inbound *repository.InboundJobRepository
+	user    *domain.User
+	seq     int
+	lastOut int64
+}
+
+// testParsers are the AI backends of a testBot; nil fields use FakeParser,
+// or a transcriber that always hears "beli kopi 20rb"
+type testParsers struct {
+	text        ai.TransactionParser
+	vision      ai.ImageParser
+	transcriber ai.Transcriber
+	breaker     *ai.CircuitBreaker
+}
+
+func newTestBot(t *testing.T, parsers testParsers) *testBot {
+	t.Helper()
+	db := testutil.DB(t)
+
+	cfg := &config.Config{
+		OpenAIModel:          "fake",
+		GowaWebhookSecret:    testWebhookSecret,
+		Timezone:             "Asia/Jakarta",
+		AITimeoutSeconds:     5,
+		StateExpiryMinutes:   10,
+		UndoWindowSeconds:    60,
+		FreeTransactionLimit: 10,
+		MaxMediaSizeMB:       5,
+	}
+	loc, err := cfg.GetLocation()
+	if err != nil {
+		t.Fatalf("failed to load timezone: %v", err)
+	}
+
+	fake := ai.NewFakeParser(loc)
+	if parsers.text == nil {
+		parsers.text = fake
+	}
+	if parsers.vision == nil {
+		parsers.vision = fake
+	}
+	if parsers.transcriber == nil {
+		parsers.transcriber = &ai.StaticTranscriber{Text: "beli kopi 20rb"}
+	}
+	if parsers.breaker == nil {
+		parsers.breaker = ai.NewCircuitBreaker(5, time.Minute)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	txRepo := repository.NewTransactionRepository(db)
+	itemRepo := repository.NewTransactionItemRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	inboundRepo := repository.NewInboundJobRepository(db)
+
+	userService := service.NewUserService(userRepo)
+	categoryService := service.NewCategoryService(repository.NewCategoryRepository(db), repository.NewCategoryMemoryRepository(db), db)
+	txService := service.NewTransactionService(txRepo, itemRepo, userRepo, auditRepo, categoryService, db)
+
+	// Neither queue is started: jobs are run by send and replies are read
+	// back from outbound_messages
+	waClient := whatsapp.NewClient("http://gowa.invalid", "", "")
+	inbound := queue.NewInboundQueue(inboundRepo, queue.NewLocalLocker(), 1, 3)
+	outbound := queue.NewOutboundQueue(repository.NewOutboundMessageRepository(db), waClient, 1, 1, 1, 0)
+
+	h := NewWebhookHandler(
+		cfg,
+		db,
+		waClient,
+		parsers.text,
+		parsers.vision,
+		parsers.transcriber,
+		fake,
+		ai.NewIntentRouter(nil),
+		userService,
+		txService,
+		service.NewReportService(txRepo, itemRepo),
+		categoryService,
+		statemachine.NewStateMachine(db),
+		repository.NewDedupRepository(db),
+		auditRepo,
+		inbound,
+		outbound,
+		repository.NewDeferredMessageRepository(db),
+		parsers.breaker,
+	)
+
+	// Registered users skip onboarding
+	user, _, err := userService.GetOrCreateUser(context.Background(), testMSISDN)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	return &testBot{t: t, db: db, h: h, inbound: inboundRepo, user: user}
+}
+
+// payload builds a GOWA webhook body for a message from the test user;
+// media adds fields such as "image" or "audio"
+func (b *testBot) payload(text string, media map[string]interface{}) []byte {
+	b.seq++
+	fields := map[string]interface{}{
+		"chat_id":   testMSISDN + "@s.whatsapp.net",
+		"from":      testMSISDN + "@s.whatsapp.net",
+		"sender_id": testMSISDN,
+		"pushname":  "Budi",
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"message": map[string]string{
+			"text": text,
+			"id":   fmt.Sprintf("3EB0TEST%04d", b.seq),
+		},
+	}
+	for k, v := range media {
+		fields[k] = v
+	}
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		b.t.Fatalf("failed to build payload: %v", err)
+	}
+	return body
+}
+
+// serveMedia points the WhatsApp client at a GOWA stand-in that serves
+// content at path
+func (b *testBot) serveMedia(path, mimeType string, content []byte) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", mimeType)
+		w.Write(content)
+	}))
+	b.t.Cleanup(srv.Close)
+	b.h.waClient = whatsapp.NewClient(srv.URL, "", "")
+}
+
+// send posts text to the webhook, runs the queued job and returns the replies
+func (b *testBot) send(text string) []string {
+	b.t.Helper()
+	return b.post(text, b.payload(text, nil))
+}
+
+// sendMedia posts a media message, e.g. an image with a caption
+func (b *testBot) sendMedia(kind string, media map[string]interface{}) []string {
+	b.t.Helper()
+	return b.post(kind, b.payload("", map[string]interface{}{kind: media}))
+}
+
+// post delivers a webhook body, runs the queued job and returns the replies;
+// label names the message in failures
+func (b *testBot) post(label string, body []byte) []string {
+	b.t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(string(body)))
+	req.Header.Set("X-Webhook-Secret", testWebhookSecret)
+	rec := httptest.NewRecorder()
+	b.h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		b.t.Fatalf("webhook returned %d: %s", rec.Code, rec.Body.String())
+	}
+
+	ctx := context.Background()
+	job, err := b.inbound.ClaimNext(ctx)
+	if err != nil || job == nil {
+		b.t.Fatalf("failed to claim job for %q: %v", label, err)
+	}
+	if job.MSISDN != testMSISDN {
+		b.t.Errorf("job msisdn = %q, want %q", job.MSISDN, testMSISDN)
+	}
+	if err := b.h.ProcessJob(ctx, job); err != nil {
+		b.t.Fatalf("ProcessJob(%q): %v", label, err)
+	}
+	if err := b.inbound.MarkDone(ctx, job.ID); err != nil {
+		b.t.Fatalf("failed to mark job done: %v", err)
+	}
+
+	return b.replies()
+}
+
+// replies returns the messages queued to the test user since the last call
+func (b *testBot) replies() []string {
+	b.t.Helper()
+
+	rows, err := b.db.Query(`SELECT id, body FROM outbound_messages WHERE recipient LIKE $1 AND id > $2 ORDER BY id`, testMSISDN+"%", b.lastOut)
+	if err != nil {
+		b.t.Fatalf("failed to read replies: %v", err)
+	}
+	defer rows.Close()
+
+	var bodies []string
+	for rows.Next() {
+		var body string
+		if err := rows.Scan(&b.lastOut, &body); err != nil {
+			b.t.Fatalf("failed to scan reply: %v", err)
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies
+}
+
+// transactions returns the descriptions and amounts of the user's live transactions
+func (b *testBot) transactions() []string {
+	b.t.Helper()
+
+	rows, err := b.db.Query(`SELECT COALESCE(description, ''), amount FROM transactions WHERE user_id = $1 AND NOT is_deleted ORDER BY id`, b.user.ID)
+	if err != nil {
+		b.t.Fatalf("failed to read transactions: %v", err)
+	}
+	defer rows.Close()
+
+	var txs []string
+	for rows.Next() {
+		var description string
+		var amount float64
+		if err := rows.Scan(&description, &amount); err != nil {
+			b.t.Fatalf("failed to scan transaction: %v", err)
+		}
+		txs = append(txs, fmt.Sprintf("%s %.0f", description, amount))
+	}
+	return txs
+}
+
+// expectReply fails unless exactly one reply was sent and it contains want
+func expectReply(t *testing.T, replies []string, want string) {
+	t.Helper()
+	if len(replies) != 1 || !strings.Contains(replies[0], want) {
+		t.Fatalf("replies = %q, want one containing %q", replies, want)
+	}
+}
+
+func TestRecordConfirmUndo(t *testing.T) {
+	b := newTestBot(t, testParsers{})
+
+	// High confidence: saved straight away
+	expectReply(t, b.send("beli kopi 20rb"), "Transaksi tersimpan")
+	if got := b.transactions(); len(got) != 1 || !strings.Contains(got[0], "kopi") || !strings.HasSuffix(got[0], " 20000") {
+		t.Fatalf("transactions = %q", got)
+	}
+
+	expectReply(t, b.send("undo"), "Transaksi terakhir dibatalkan")
+	if got := b.transactions(); len(got) != 0 {
+		t.Fatalf("transactions after undo = %q", got)
+	}
+
+	// A date two days ahead is confirmed first
+	expectReply(t, b.send("lusa bayar parkir 5rb"), "Konfirmasi")
+	if got := b.transactions(); len(got) != 0 {
+		t.Fatalf("saved before confirmation: %q", got)
+	}
+
+	expectReply(t, b.send("ya tapi 7rb"), "Transaksi tersimpan")
+	if got := b.transactions(); len(got) != 1 || !strings.HasSuffix(got[0], " 7000") {
+		t.Fatalf("transactions after confirm = %q", got)
+	}
+
+	expectReply(t, b.send("undo"), "Transaksi terakhir dibatalkan")
+	if got := b.transactions(); len(got) != 0 {
+		t.Fatalf("transactions after undo = %q", got)
+	}
+
+	expectReply(t, b.send("undo"), "Tidak ada transaksi")
+}
+
+func TestConfirmationDeclined(t *testing.T) {
+	b := newTestBot(t, testParsers{})
+
+	expectReply(t, b.send("lusa bayar parkir 5rb"), "Konfirmasi")
+	expectReply(t, b.send("tidak"), "Transaksi tidak disimpan")
+	if got := b.transactions(); len(got) != 0 {
+		t.Fatalf("transactions = %q", got)
+	}
+}
+
+func TestExpiredConfirmationSurvivesClearState(t *testing.T) {
+	b := newTestBot(t, testParsers{})
+	ctx := context.Background()
+
+	expectReply(t, b.send("lusa bayar parkir 5rb"), "Konfirmasi")
+	if _, err := b.db.Exec(`UPDATE conversation_states SET expires_at = NOW() - INTERVAL '1 minute' WHERE user_id = $1`, b.user.ID); err != nil {
+		t.Fatalf("failed to expire state: %v", err)
+	}
+
+	// Another flow ends its state before the sweep runs
+	if err := b.h.stateMachine.ClearState(ctx, b.user.ID); err != nil {
+		t.Fatalf("ClearState: %v", err)
+	}
+
+	b.h.ExpirePendingConfirmations(ctx)
+	replies := b.replies()
+	if len(replies) != 1 || !strings.Contains(replies[0], "Waktu konfirmasi habis") || !strings.Contains(replies[0], "Rp5000") {
+		t.Fatalf("replies = %q, want the expiry notice with the dropped record", replies)
+	}
+}
+
+func TestMultipleTransactions(t *testing.T) {
+	b := newTestBot(t, testParsers{})
+
+	expectReply(t, b.send("beli kopi 20rb, parkir 5rb dan isi bensin 50rb"), "3 transaksi tersimpan")
+	got := b.transactions()
+	if len(got) != 3 {
+		t.Fatalf("transactions = %q, want 3", got)
+	}
+	for i, amount := range []string{" 20000", " 5000", " 50000"} {
+		if !strings.HasSuffix(got[i], amount) {
+			t.Errorf("transaction %d = %q, want amount%s", i, got[i], amount)
+		}
+	}
+
+	// Undo takes back the whole message
+	expectReply(t, b.send("undo"), "3 transaksi terakhir dibatalkan")
+	if got := b.transactions(); len(got) != 0 {
+		t.Fatalf("transactions after undo = %q", got)
+	}
+}
+
+func TestMultipleTransactionsOverFreeLimit(t *testing.T) {
+	b := newTestBot(t, testParsers{})
+
+	expectReply(t, b.send("a 1rb, b 1rb, c 1rb, d 1rb, e 1rb, f 1rb, g 1rb, h 1rb, i 1rb"), "9 transaksi tersimpan")
+
+	// Two more would pass the limit of 10: neither is saved
+	expectReply(t, b.send("kopi 20rb, parkir 5rb"), "Limit free sudah habis")
+	if got := b.transactions(); len(got) != 9 {
+		t.Fatalf("%d transactions after going over the limit, want 9", len(got))
+	}
+}
+
+func TestImageTransaction(t *testing.T) {
+	b := newTestBot(t, testParsers{})
+	b.serveMedia("/statics/media/struk.jpg", "image/jpeg", []byte("\xff\xd8\xff\xe0 struk"))
+
+	// FakeParser reads the amount from the caption
+	expectReply(t, b.sendMedia("image", map[string]interface{}{
+		"media_path": "statics/media/struk.jpg",
+		"mime_type":  "image/jpeg",
+		"caption":    "struk belanja 120rb",
+	}), "Transaksi tersimpan")
+	if got := b.transactions(); len(got) != 1 || !strings.HasSuffix(got[0], " 120000") {
+		t.Fatalf("transactions = %q", got)
+	}
+
+	// Nothing readable: nothing saved
+	expectReply(t, b.sendMedia("image", map[string]interface{}{
+		"media_path": "statics/media/struk.jpg",
+		"mime_type":  "image/jpeg",
+	}), "belum bisa membaca gambar")
+
+	// Media outside the GOWA host is never fetched
+	expectReply(t, b.sendMedia("image", map[string]interface{}{
+		"url":       "http://169.254.169.254/latest/meta-data",
+		"mime_type": "image/jpeg",
+		"caption":   "struk 50rb",
+	}), "Gagal mengambil gambar")
+
+	if got := b.transactions(); len(got) != 1 {
+		t.Fatalf("transactions = %q, want only the first receipt", got)
+	}
+}
+
+func TestVoiceTransaction(t *testing.T) {
+	b := newTestBot(t, testParsers{})
+	b.serveMedia("/statics/media/voice.ogg", "audio/ogg", []byte("OggS voice"))
+	voice := map[string]interface{}{
+		"media_path": "statics/media/voice.ogg",
+		"mime_type":  "audio/ogg; codecs=opus",
+	}
+
+	// The transcript is echoed above the result
+	replies := b.sendMedia("audio", voice)
+	expectReply(t, replies, "Transaksi tersimpan")
+	if !strings.HasPrefix(replies[0], "🎤 \"beli kopi 20rb\"") {
+		t.Errorf("reply doesn't echo the transcript: %q", replies[0])
+	}
+	if got := b.transactions(); len(got) != 1 || !strings.HasSuffix(got[0], " 20000") {
+		t.Fatalf("transactions = %q", got)
+	}
+
+	b.h.transcriber = &ai.StaticTranscriber{}
+	expectReply(t, b.sendMedia("audio", voice), "belum bisa mendengar")
+	if got := b.transactions(); len(got) != 1 {
+		t.Fatalf("transactions = %q", got)
+	}
+}
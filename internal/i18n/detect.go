@@ -0,0 +1,76 @@
+package i18n
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+var detectWordPattern = regexp.MustCompile(`[a-z']+`)
+
+// detectWords are words typical of one language in chat messages. Words
+// shared between the languages ("aku", "ok", "hi", "bayar") are left out, and
+// so are amount suffixes like "rb" and "jt", which English speakers in
+// Indonesia write too ("lunch 45rb").
+var detectWords = map[string]map[string]bool{
+	domain.LangIndonesian: setOf(
+		"halo", "selamat", "saya", "mau", "beli", "makan", "minum", "kemarin", "kemaren",
+		"hari", "ini", "itu", "yang", "dan", "untuk", "buat", "dengan", "sama", "gaji", "catat",
+		"terima", "kasih", "makasih", "tidak", "nggak", "gak", "sudah", "udah", "berapa", "tolong",
+		"gimana", "bagaimana", "dong", "aja", "ribu", "juta", "pemasukan", "pengeluaran",
+		"tadi", "siang", "bisa", "kak", "kopi", "bensin", "jajan", "nasi", "parkir",
+	),
+	domain.LangEnglish: setOf(
+		"hello", "hey", "good", "morning", "the", "my", "for", "and", "i", "i'm", "im", "what", "how",
+		"much", "many", "spent", "spend", "paid", "pay", "bought", "buy", "lunch", "dinner",
+		"breakfast", "coffee", "groceries", "salary", "today", "yesterday", "please", "want",
+		"record", "is", "this", "with", "thank", "you", "got", "received", "income", "expense",
+		"expenses",
+	),
+	domain.LangJavanese: setOf(
+		"sugeng", "enjing", "piye", "kepiye", "pripun", "tuku", "mangan", "ngombe", "wingi",
+		"sesuk", "dino", "dina", "iki", "kuwi", "kowe", "sampeyan", "panjenengan", "nggih",
+		"inggih", "matur", "nuwun", "suwun", "ora", "mboten", "wis", "sampun", "karo", "lan",
+		"sing", "duwit", "dhuwit", "ewu", "sewu", "gajian", "pira", "piro", "arep", "badhe",
+		"tumbas", "nedha", "opo",
+	),
+}
+
+func setOf(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// Detect guesses the language of a chat message from its words. It returns
+// false when no language clearly wins, e.g. for "hi" or "50rb".
+func Detect(text string) (string, bool) {
+	// Letters only, so "45k" yields "k" and bare numbers are skipped
+	words := detectWordPattern.FindAllString(strings.ToLower(text), -1)
+
+	scores := make(map[string]int)
+	for _, word := range words {
+		for lang, vocabulary := range detectWords {
+			if vocabulary[word] {
+				scores[lang]++
+			}
+		}
+	}
+
+	best, bestScore, tie := "", 0, false
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = lang, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	if bestScore == 0 || tie {
+		return "", false
+	}
+	return best, true
+}
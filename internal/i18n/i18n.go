@@ -0,0 +1,141 @@
+// Package i18n holds the bot's replies in every supported language.
+//
+// Messages are text/template strings. Besides the Args they are rendered
+// with, templates can call:
+//   - amount: formats a rupiah value without decimals, e.g. {{amount .Amount}}
+//   - plural: picks a form by count with the language's plural rule, e.g.
+//     {{plural .Count "item" "items"}}
+package i18n
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// Args are the values a message is rendered with
+type Args map[string]interface{}
+
+type language struct {
+	name     string
+	plural   func(n int) int // index into the forms passed to plural
+	messages map[string]string
+	tmpl     map[string]*template.Template
+}
+
+var languages = map[string]*language{
+	domain.LangIndonesian: {name: "Bahasa Indonesia", plural: pluralNone, messages: messagesID},
+	domain.LangEnglish:    {name: "English", plural: pluralOneOther, messages: messagesEN},
+	domain.LangJavanese:   {name: "Basa Jawa", plural: pluralNone, messages: messagesJV},
+}
+
+// pluralNone is for languages that don't inflect nouns for number
+func pluralNone(n int) int {
+	return 0
+}
+
+func pluralOneOther(n int) int {
+	if n == 1 || n == -1 {
+		return 0
+	}
+	return 1
+}
+
+// Messages are parsed at startup so a broken template fails fast
+func init() {
+	for code, lang := range languages {
+		funcs := template.FuncMap{
+			"amount": formatAmount,
+			"plural": pluralFunc(lang.plural),
+		}
+		lang.tmpl = make(map[string]*template.Template, len(lang.messages))
+		for key, text := range lang.messages {
+			lang.tmpl[key] = template.Must(template.New(code + ":" + key).Funcs(funcs).Parse(text))
+		}
+	}
+}
+
+func formatAmount(v float64) string {
+	return fmt.Sprintf("%.0f", v)
+}
+
+func pluralFunc(rule func(int) int) func(int, ...string) string {
+	return func(n int, forms ...string) string {
+		if len(forms) == 0 {
+			return ""
+		}
+		i := rule(n)
+		if i >= len(forms) {
+			i = len(forms) - 1
+		}
+		return forms[i]
+	}
+}
+
+// T renders the message key in lang. Keys missing from lang fall back to the
+// default language; unknown keys render as the key itself.
+func T(lang, key string, args ...Args) string {
+	tmpl := lookup(lang, key)
+	if tmpl == nil {
+		log.Printf("Missing message %q", key)
+		return key
+	}
+
+	var data Args
+	if len(args) > 0 {
+		data = args[0]
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Printf("Failed to render message %q: %v", key, err)
+		return key
+	}
+	return buf.String()
+}
+
+func lookup(lang, key string) *template.Template {
+	if l, ok := languages[lang]; ok {
+		if tmpl, ok := l.tmpl[key]; ok {
+			return tmpl
+		}
+	}
+	return languages[domain.DefaultLanguage].tmpl[key]
+}
+
+// Supported reports whether replies can be written in lang
+func Supported(lang string) bool {
+	_, ok := languages[lang]
+	return ok
+}
+
+// Name returns a language's own name, e.g. "Basa Jawa"
+func Name(lang string) string {
+	if l, ok := languages[lang]; ok {
+		return l.name
+	}
+	return languages[domain.DefaultLanguage].name
+}
+
+// languageNames maps what users call a language, in any of the supported
+// languages, to its code
+var languageNames = map[string]string{
+	"id": domain.LangIndonesian, "indonesia": domain.LangIndonesian, "indonesian": domain.LangIndonesian,
+	"indo": domain.LangIndonesian, "bahasa indonesia": domain.LangIndonesian,
+
+	"en": domain.LangEnglish, "eng": domain.LangEnglish, "english": domain.LangEnglish,
+	"inggris": domain.LangEnglish, "bahasa inggris": domain.LangEnglish, "basa inggris": domain.LangEnglish,
+
+	"jv": domain.LangJavanese, "jawa": domain.LangJavanese, "jowo": domain.LangJavanese,
+	"javanese": domain.LangJavanese, "bahasa jawa": domain.LangJavanese, "basa jawa": domain.LangJavanese,
+}
+
+// ParseLanguage reads a language name like "english", "inggris" or "jawa"
+func ParseLanguage(text string) (string, bool) {
+	lang, ok := languageNames[strings.ToLower(strings.Join(strings.Fields(text), " "))]
+	return lang, ok
+}
@@ -0,0 +1,142 @@
+package i18n
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// sampleArgs has a value for every field any message uses
+var sampleArgs = Args{
+	"Amount": 45000.0, "ItemsTotal": 44000.0, "Total": 120000.0, "Value": 75000.0,
+	"Count": 2, "Rank": 1, "MaxMB": 10,
+	"Category": "Makanan & Minuman", "Current": "English", "Date": "18/03/2026", "Description": "makan siang",
+	"Emoji": "💸", "From": "Hiburan", "To": "Rekreasi", "Hint": "", "ItemsNote": "", "List": "1. kopi",
+	"Name": "Kucing", "Parent": "Makanan & Minuman", "Period": "Today", "SentAt": "10:15", "Summary": "ID: TX#1",
+	"Text": "kopi 20rb", "TxID": "TX#abcd1234-1700000000", "Type": "Expense", "Usage": "add category <name>",
+}
+
+func keys(messages map[string]string) []string {
+	var list []string
+	for key := range messages {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func TestMessageParity(t *testing.T) {
+	base := languages[domain.DefaultLanguage].messages
+
+	for code, lang := range languages {
+		for key := range base {
+			if _, ok := lang.messages[key]; !ok {
+				t.Errorf("%s is missing %q", code, key)
+			}
+		}
+		for key := range lang.messages {
+			if _, ok := base[key]; !ok {
+				t.Errorf("%s has %q, which %s doesn't", code, key, domain.DefaultLanguage)
+			}
+		}
+	}
+}
+
+func TestMessagesRender(t *testing.T) {
+	for code, lang := range languages {
+		for _, key := range keys(lang.messages) {
+			var buf bytes.Buffer
+			if err := lang.tmpl[key].Execute(&buf, sampleArgs); err != nil {
+				t.Errorf("%s %q: %v", code, key, err)
+				continue
+			}
+			if strings.Contains(buf.String(), "<no value>") {
+				t.Errorf("%s %q uses a field sampleArgs doesn't have: %s", code, key, buf.String())
+			}
+			if got := T(code, key, sampleArgs); got == key {
+				t.Errorf("T(%s, %q) fell back to the key", code, key)
+			}
+		}
+	}
+}
+
+func TestFallback(t *testing.T) {
+	if got, want := T("fr", "type.INCOME"), T(domain.DefaultLanguage, "type.INCOME"); got != want {
+		t.Errorf("unsupported language = %q, want the default %q", got, want)
+	}
+	if got := T(domain.LangEnglish, "no.such.key"); got != "no.such.key" {
+		t.Errorf("unknown key = %q, want the key itself", got)
+	}
+}
+
+func TestPlural(t *testing.T) {
+	tests := []struct {
+		lang  string
+		count int
+		want  string
+	}{
+		{domain.LangEnglish, 1, "1 transaction"},
+		{domain.LangEnglish, 2, "2 transactions"},
+		{domain.LangIndonesian, 1, "1 transaksi"},
+		{domain.LangIndonesian, 2, "2 transaksi"},
+	}
+
+	for _, tt := range tests {
+		got := T(tt.lang, "query.sum", Args{"Value": 20000.0, "Count": tt.count})
+		if !strings.Contains(got, "("+tt.want+")") {
+			t.Errorf("%s with %d = %q, want it to contain %q", tt.lang, tt.count, got, tt.want)
+		}
+	}
+
+	if got := pluralFunc(pluralOneOther)(3, "item"); got != "item" {
+		t.Errorf("plural with one form = %q, want it for every count", got)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		ok   bool
+	}{
+		{"lunch 45k", domain.LangEnglish, true},
+		{"tuku bensin 50 ewu", domain.LangJavanese, true},
+		{"beli kopi 20rb", domain.LangIndonesian, true},
+		{"hi", "", false},
+		{"50rb", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Detect(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Detect(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		ok   bool
+	}{
+		{"english", domain.LangEnglish, true},
+		{"Bahasa  Inggris", domain.LangEnglish, true},
+		{" jawa ", domain.LangJavanese, true},
+		{"basa jawa", domain.LangJavanese, true},
+		{"Indonesia", domain.LangIndonesian, true},
+		{"id", domain.LangIndonesian, true},
+		{"french", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseLanguage(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseLanguage(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
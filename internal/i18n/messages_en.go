@@ -0,0 +1,167 @@
+package i18n
+
+// messagesEN is the English catalog
+var messagesEN = map[string]string{
+	// Account and onboarding
+	"user.blocked": "Your account is blocked. Contact the admin for more information.",
+	"onboarding.welcome": `Hi! I'm a money tracker bot 📒
+
+Choose a plan:
+1️⃣ Free (10 transactions)
+2️⃣ Premium Rp10k/month (contact admin 081389592985)
+
+Type *1* or *2* to choose.`,
+	"onboarding.free":    "✅ Free plan active! You can record up to 10 transactions.\n\nExamples:\n• salary 5 million\n• lunch 45k\n• or send a photo of a receipt!",
+	"onboarding.premium": "📞 Please contact the admin at 081389592985 to upgrade to Premium.\n\nIn the meantime, you can use the Free plan (10 transactions).",
+	"onboarding.invalid": "Invalid choice. Type *1* for Free or *2* for Premium.",
+	"premium.upgraded":   "🎉 Your account has been upgraded to Premium! Unlimited transactions.",
+
+	// General
+	"system.error": "Sorry, something went wrong on our side 😔",
+	"job.dead":     "Sorry, your message couldn't be processed because of a system problem 😔\n\nPlease send it again in a moment.",
+	"smalltalk":    "Hi there! 👋\n\nSend a transaction any time, e.g. *coffee 20k*. Type *help* to see everything I can do.",
+	"help": `I can help you:
+• Record a transaction: "lunch 45k", "salary 5 million"
+• Send a photo of a receipt
+• Send a voice note: "had lunch for 35 thousand"
+• See a summary: "report today", "report this month"
+• Ask: "how much did I spend on coffee last month?"
+• Undo the last transaction: "undo"
+• Edit a transaction: "edit TX#..." or "edit"
+• Delete a transaction: "delete TX#..." or "delete"
+• Receipt details: "details" or "details TX#..."
+• Categories: "categories", "add category ...", "rename category ... to ..."
+• Language: "language indonesian", "language javanese"`,
+
+	// Transaction types
+	"type.INCOME":  "Income",
+	"type.EXPENSE": "Expense",
+
+	// Media
+	"media.unsupported":       "I can only read photos of receipts or transfer screenshots, not other files yet 🙏",
+	"voice.too_large":         "The voice note is too large (max {{.MaxMB}} MB) 😅",
+	"voice.download_failed":   "Couldn't fetch the voice note 😔\n\nCould you send it again?",
+	"voice.transcribe_failed": "I couldn't make out this voice note 😅\n\nCould you say it again or type it?",
+	"image.too_large":         "The image is too large (max {{.MaxMB}} MB) 😅",
+	"image.download_failed":   "Couldn't fetch the image 😔\n\nCould you send it again?",
+	"image.unreadable":        "I couldn't read this image 😅\n\nCould you send it again or type it?",
+
+	// Recording
+	"parse.failed":      "Sorry, I didn't understand that 😅\n\nExample: lunch 45k",
+	"parse.unsure":      "I'm not sure about this transaction 🤔\n\nTry writing it more clearly, e.g.:\n• salary 5 million\n• fuel 50k",
+	"tx.limit":          "❌ You've used up the free limit (10 transactions).\n\nUpgrade to Premium? Contact admin 081389592985",
+	"tx.save_failed":    "Sorry, the transaction couldn't be saved 😔",
+	"tx.saved":          "✅ Transaction saved!\n\n{{.Emoji}} {{.Type}}\nRp {{amount .Amount}} - {{.Description}}\n\nID: {{.TxID}}{{.ItemsNote}}\nType *undo* within 60 seconds to cancel.",
+	"tx.items_note":     "\n{{.Count}} {{plural .Count \"item\" \"items\"}} recorded, type *details* to see {{plural .Count \"it\" \"them\"}}.",
+	"tx.saved_many":     "✅ {{.Count}} {{plural .Count \"transaction\" \"transactions\"}} saved!\n",
+	"tx.undo_all":       "\nType *undo* within 60 seconds to cancel them all.",
+	"tx.summary":        "ID: {{.TxID}}\n{{.Type}} Rp {{amount .Amount}} - {{.Description}}\nCategory: {{.Category}}\nDate: {{.Date}}",
+	"tx.unavailable":    "Sorry, the service is having problems 😔\n\nPlease send it again in a few minutes.",
+	"tx.deferred":       "📝 Noted, it will be processed.\n\nThe AI service is having problems; I'll let you know once the transaction is saved.",
+	"tx.deferred_dead":  "Sorry, this message couldn't be processed because of a service problem 😔\n\nSend it again if you still want it recorded.",
+	"tx.deferred_image": "⏳ The image you sent {{.SentAt}}:\n\n",
+	"tx.deferred_audio": "⏳ The voice note you sent {{.SentAt}}:\n\n",
+	"tx.deferred_text":  "⏳ Your message \"{{.Text}}\" ({{.SentAt}}):\n\n",
+
+	// Confirmation
+	"confirm.ask":                "Please confirm:\n{{.List}}\nType *yes* to save or *no* to cancel.{{.Hint}}",
+	"confirm.hint":               "\nYou can also correct it, e.g. *yes but 45k*",
+	"confirm.far_future":         "⚠️ The date is in the future: {{.Date}}\n",
+	"confirm.items_mismatch":     "⚠️ The receipt total Rp{{amount .Amount}} doesn't match the {{.Count}} {{plural .Count \"item\" \"items\"}} (Rp{{amount .ItemsTotal}})\n",
+	"confirm.correction_unclear": "I didn't understand the correction 🤔\n\nExamples: *yes but 45k*, *yes category food*, or type *no* to cancel.",
+	"confirm.cancelled":          "❌ Transaction not saved.",
+	"confirm.dropped":            "The transaction waiting for confirmation was not saved.",
+	"confirm.expired":            "⌛ Confirmation timed out, the transaction was not saved.{{.Summary}}\n\nSend the message again if you still want it recorded.",
+
+	// Undo, edit and delete
+	"undo.none":      "There's no transaction to undo.",
+	"undo.expired":   "The undo window has passed (60 seconds).",
+	"undo.failed":    "Couldn't undo the transaction 😔",
+	"undo.done":      "✅ Last transaction undone!",
+	"undo.done_many": "✅ Last {{.Count}} {{plural .Count \"transaction\" \"transactions\"}} undone!",
+
+	"edit.not_found":       "Transaction not found 🤔\n\nExample: *edit TX#abcd1234-1700000000* or *edit*",
+	"edit.menu":            "✏️ Edit transaction:\n{{.Summary}}\n\nWhat do you want to change?\n1️⃣ Amount\n2️⃣ Category\n3️⃣ Description\n4️⃣ Type (income/expense)\n5️⃣ Date\n\nType a number or field name, e.g. *amount 45k*. Type *cancel* to stop.",
+	"edit.cancelled":       "Edit cancelled.",
+	"edit.gone":            "The transaction no longer exists, edit cancelled.",
+	"edit.pick_field":      "Choose a field: *amount*, *category*, *description*, *type* or *date* (or 1-5).",
+	"edit.failed":          "Couldn't update the transaction 😔",
+	"edit.done":            "✅ Transaction updated!\n\n{{.Summary}}",
+	"edit.ask.amount":      "What's the new amount? E.g. *45k*",
+	"edit.ask.category":    "What's the new category? E.g. *food*",
+	"edit.ask.description": "What's the new description?",
+	"edit.ask.type":        "Is it *income* or *expense*?",
+	"edit.ask.date":        "What's the new date? E.g. *yesterday*, *25/01* or *2026-01-25*",
+	"edit.ask.value":       "What's the new value?",
+	"edit.invalid.amount":  "Invalid amount",
+	"edit.invalid.type":    "Invalid type",
+	"edit.invalid.date":    "Invalid date",
+
+	"delete.not_found": "Transaction not found 🤔\n\nExample: *delete TX#abcd1234-1700000000* or *delete*",
+	"delete.failed":    "Couldn't delete the transaction 😔",
+	"delete.done":      "🗑️ Transaction deleted:\n{{.Summary}}",
+	"delete.confirm":   "Delete this transaction?\n{{.Summary}}\n\nReply *yes* to delete or *no* to keep it.",
+	"delete.cancelled": "Transaction kept.",
+
+	// Reports
+	"report.failed":        "Couldn't create the summary 😔",
+	"report.title":         "📊 *Summary {{.Period}}*\n\n",
+	"report.period.today":  "Today",
+	"report.period.week":   "This Week",
+	"report.period.month":  "This Month",
+	"report.income":        "💰 Income: Rp {{amount .Amount}}\n",
+	"report.expense":       "💸 Expenses: Rp {{amount .Amount}}\n",
+	"report.net":           "📈 Net Balance: Rp {{amount .Amount}}\n\n",
+	"report.top":           "🏷️ *Top Categories:*\n",
+	"report.receipt_items": "\n🧾 *Shopping Breakdown (from receipts):*\n",
+	"report.empty":         "\nNo transactions in this period yet.",
+
+	"items.not_found": "Transaction not found 🤔\n\nExample: *details TX#abcd1234-1700000000* or *details*",
+	"items.failed":    "Couldn't fetch the details 😔",
+	"items.title":     "🧾 *Details {{.TxID}}*\n{{.Description}} - Rp {{amount .Amount}}\n",
+	"items.none":      "\nThis transaction has no itemised details.",
+	"items.other":     "other",
+
+	// Questions about the ledger
+	"query.unavailable":     "Sorry, the AI service is having problems so I can't answer questions right now 😔\n\nIn the meantime *report today* and *report this month* still work.",
+	"query.unclear":         "Sorry, I didn't understand the question 😅\n\nExamples:\n• how much did I spend on coffee last month?\n• what was my biggest expense this week?\n• top 3 expense categories this month",
+	"query.failed":          "Couldn't fetch your transactions 😔",
+	"query.subject":         "Transactions",
+	"query.subject.EXPENSE": "Expenses",
+	"query.subject.INCOME":  "Income",
+	"query.none":            "No matching transactions in this period yet.",
+	"query.sum":             "Total: *Rp {{amount .Value}}* ({{.Count}} {{plural .Count \"transaction\" \"transactions\"}})",
+	"query.count":           "Count: *{{.Count}} {{plural .Count \"transaction\" \"transactions\"}}* (total Rp {{amount .Value}})",
+	"query.avg":             "Average: *Rp {{amount .Value}}* per transaction ({{.Count}} {{plural .Count \"transaction\" \"transactions\"}})",
+	"query.max":             "Biggest: *Rp {{amount .Amount}}* - {{.Description}}\n🏷️ {{.Category}}, {{.Date}}",
+	"query.max_list":        "Biggest:\n",
+	"query.top":             "🏷️ Top categories:\n",
+	"query.top_line":        "{{.Rank}}. {{.Category}}: Rp {{amount .Total}} ({{.Count}} {{plural .Count \"transaction\" \"transactions\"}})\n",
+
+	// Categories
+	"category.usage": `Category commands:
+• *categories* - list your categories
+• *add category Skincare in Belanja*
+• *add category Freelance income*
+• *rename category Hiburan to Rekreasi*
+• *merge category Kopi & Jajan into Makanan & Minuman*`,
+	"category.list":            "🗂️ *Your categories*\n\n{{.List}}\n\n{{.Usage}}",
+	"category.list_failed":     "Sorry, couldn't fetch your categories 😔",
+	"category.section.EXPENSE": "💸 *Expenses*",
+	"category.section.INCOME":  "💰 *Income*",
+	"category.rename_example":  "Example: *rename category Hiburan to Rekreasi*",
+	"category.merge_example":   "Example: *merge category Kopi & Jajan into Makanan & Minuman*",
+	"category.added":           "✅ Category {{.Emoji}} *{{.Name}}* added{{with .Parent}} under *{{.}}*{{end}}.",
+	"category.renamed":         "✅ Category *{{.From}}* is now called *{{.To}}*.",
+	"category.merged":          "✅ Category *{{.From}}* merged into *{{.To}}*.",
+	"category.not_found":       "Category *{{.Name}}* not found 🤔\n\nType *categories* to see the list.",
+	"category.exists":          "Category *{{.Name}}* already exists.",
+	"category.type_mismatch":   "Income and expense categories can't be mixed.",
+	"category.same":            "Those are the same category, nothing to merge.",
+	"category.failed":          "Sorry, couldn't change the category 😔",
+
+	// Language
+	"language.menu":    "🌐 Current language: *{{.Current}}*\n\nSwitch to:\n• *language indonesian*\n• *language english*\n• *language javanese*",
+	"language.changed": "✅ Done, I'll reply in English from now on.",
+	"language.failed":  "Sorry, couldn't change the language 😔",
+}
@@ -0,0 +1,168 @@
+package i18n
+
+// messagesID is the Indonesian catalog. It is the fallback for keys missing
+// from the other languages, so every key must be here.
+var messagesID = map[string]string{
+	// Account and onboarding
+	"user.blocked": "Akun Anda diblokir. Hubungi admin untuk informasi lebih lanjut.",
+	"onboarding.welcome": `Halo! Aku bot pencatat keuangan 📒
+
+Pilih paket:
+1️⃣ Free (10 transaksi)
+2️⃣ Premium Rp10rb/bulan (hubungi admin 081389592985)
+
+Ketik *1* atau *2* untuk memilih.`,
+	"onboarding.free":    "✅ Paket Free aktif! Kamu bisa mencatat hingga 10 transaksi.\n\nContoh penggunaan:\n• catat pemasukan 100000 gaji\n• beli bensin 50rb\n• atau kirim foto struk!",
+	"onboarding.premium": "📞 Silakan hubungi admin di 081389592985 untuk upgrade ke Premium.\n\nSementara itu, kamu bisa pakai paket Free (10 transaksi).",
+	"onboarding.invalid": "Pilihan tidak valid. Ketik *1* untuk Free atau *2* untuk Premium.",
+	"premium.upgraded":   "🎉 Akun kamu sudah di-upgrade ke Premium! Unlimited transaksi.",
+
+	// General
+	"system.error": "Maaf, terjadi kesalahan sistem 😔",
+	"job.dead":     "Maaf, pesan kamu gagal diproses karena gangguan sistem 😔\n\nCoba kirim ulang sebentar lagi ya.",
+	"smalltalk":    "Halo juga! 👋\n\nKirim transaksi kapan saja, contoh: *beli kopi 20rb*. Ketik *bantuan* untuk lihat semua fitur.",
+	"help": `Aku bisa bantu kamu:
+• Catat transaksi: "catat pemasukan 100rb gaji"
+• Kirim foto struk
+• Kirim voice note: "tadi makan siang 35 ribu"
+• Lihat rekap: "rekap hari ini", "rekap bulan ini"
+• Tanya: "berapa total jajan kopi bulan lalu?"
+• Undo transaksi terakhir: "undo"
+• Edit transaksi: "edit TX#..." atau "ubah yang terakhir"
+• Hapus transaksi: "hapus TX#..." atau "hapus yang terakhir"
+• Rincian struk: "rincian" atau "rincian TX#..."
+• Kategori: "kategori", "tambah kategori ...", "ubah kategori ... jadi ..."
+• Bahasa: "bahasa inggris", "basa jawa"`,
+
+	// Transaction types
+	"type.INCOME":  "Pemasukan",
+	"type.EXPENSE": "Pengeluaran",
+
+	// Media
+	"media.unsupported":       "Aku baru bisa membaca foto struk atau screenshot transfer, belum bisa file lain 🙏",
+	"voice.too_large":         "Voice note-nya terlalu besar (maks {{.MaxMB}} MB) 😅",
+	"voice.download_failed":   "Gagal mengambil voice note 😔\n\nBisa kirim ulang?",
+	"voice.transcribe_failed": "Aku belum bisa mendengar voice note ini 😅\n\nBisa ulangi atau ketik manual?",
+	"image.too_large":         "Gambarnya terlalu besar (maks {{.MaxMB}} MB) 😅",
+	"image.download_failed":   "Gagal mengambil gambar 😔\n\nBisa kirim ulang?",
+	"image.unreadable":        "Aku belum bisa membaca gambar ini 😅\n\nBisa kirim ulang atau ketik manual?",
+
+	// Recording
+	"parse.failed":      "Maaf, aku belum bisa memahami pesan ini 😅\n\nContoh: catat pemasukan 100000 gaji",
+	"parse.unsure":      "Aku kurang yakin dengan transaksi ini 🤔\n\nCoba tulis lebih jelas, contoh:\n• catat pemasukan 100000 gaji\n• beli bensin 50rb",
+	"tx.limit":          "❌ Limit free sudah habis (10 transaksi).\n\nUpgrade ke Premium? Hubungi admin 081389592985",
+	"tx.save_failed":    "Maaf, gagal menyimpan transaksi 😔",
+	"tx.saved":          "✅ Transaksi tersimpan!\n\n{{.Emoji}} {{.Type}}\nRp {{amount .Amount}} - {{.Description}}\n\nID: {{.TxID}}{{.ItemsNote}}\nKetik *undo* dalam 60 detik untuk membatalkan.",
+	"tx.items_note":     "\n{{.Count}} item tercatat, ketik *rincian* untuk melihat.",
+	"tx.saved_many":     "✅ {{.Count}} transaksi tersimpan!\n",
+	"tx.undo_all":       "\nKetik *undo* dalam 60 detik untuk membatalkan semuanya.",
+	"tx.summary":        "ID: {{.TxID}}\n{{.Type}} Rp {{amount .Amount}} - {{.Description}}\nKategori: {{.Category}}\nTanggal: {{.Date}}",
+	"tx.unavailable":    "Maaf, layanan sedang gangguan 😔\n\nCoba kirim ulang beberapa menit lagi ya.",
+	"tx.deferred":       "📝 Dicatat, akan diproses.\n\nLayanan AI sedang gangguan, aku kabari lagi begitu transaksinya tersimpan.",
+	"tx.deferred_dead":  "Maaf, pesan ini gagal diproses karena gangguan layanan 😔\n\nKirim ulang ya kalau masih mau dicatat.",
+	"tx.deferred_image": "⏳ Gambar yang kamu kirim {{.SentAt}}:\n\n",
+	"tx.deferred_audio": "⏳ Voice note yang kamu kirim {{.SentAt}}:\n\n",
+	"tx.deferred_text":  "⏳ Pesan \"{{.Text}}\" ({{.SentAt}}):\n\n",
+
+	// Confirmation
+	"confirm.ask":                "Konfirmasi transaksi:\n{{.List}}\nKetik *ya* untuk simpan atau *tidak* untuk batal.{{.Hint}}",
+	"confirm.hint":               "\nBisa juga koreksi, contoh: *ya tapi 45rb*",
+	"confirm.far_future":         "⚠️ Tanggalnya di masa depan: {{.Date}}\n",
+	"confirm.items_mismatch":     "⚠️ Total struk Rp{{amount .Amount}} tidak sama dengan jumlah {{.Count}} item (Rp{{amount .ItemsTotal}})\n",
+	"confirm.correction_unclear": "Koreksinya belum aku pahami 🤔\n\nContoh: *ya tapi 45rb*, *ya kategori makan*, atau ketik *tidak* untuk batal.",
+	"confirm.cancelled":          "❌ Transaksi tidak disimpan.",
+	"confirm.dropped":            "Transaksi yang menunggu konfirmasi tidak disimpan.",
+	"confirm.expired":            "⌛ Waktu konfirmasi habis, transaksi tidak disimpan.{{.Summary}}\n\nKirim ulang pesannya kalau masih mau dicatat.",
+
+	// Undo, edit and delete
+	"undo.none":      "Tidak ada transaksi untuk dibatalkan.",
+	"undo.expired":   "Waktu undo sudah habis (60 detik).",
+	"undo.failed":    "Gagal membatalkan transaksi 😔",
+	"undo.done":      "✅ Transaksi terakhir dibatalkan!",
+	"undo.done_many": "✅ {{.Count}} transaksi terakhir dibatalkan!",
+
+	"edit.not_found":       "Transaksi tidak ditemukan 🤔\n\nContoh: *edit TX#abcd1234-1700000000* atau *ubah yang terakhir*",
+	"edit.menu":            "✏️ Edit transaksi:\n{{.Summary}}\n\nMau ubah apa?\n1️⃣ Nominal\n2️⃣ Kategori\n3️⃣ Keterangan\n4️⃣ Jenis (pemasukan/pengeluaran)\n5️⃣ Tanggal\n\nKetik nomor atau nama field, contoh: *nominal 45rb*. Ketik *batal* untuk keluar.",
+	"edit.cancelled":       "Edit dibatalkan.",
+	"edit.gone":            "Transaksi sudah tidak ada, edit dibatalkan.",
+	"edit.pick_field":      "Pilih field: *nominal*, *kategori*, *keterangan*, *jenis*, atau *tanggal* (atau 1-5).",
+	"edit.failed":          "Gagal mengubah transaksi 😔",
+	"edit.done":            "✅ Transaksi diperbarui!\n\n{{.Summary}}",
+	"edit.ask.amount":      "Nominal barunya berapa? Contoh: *45rb*",
+	"edit.ask.category":    "Kategori barunya apa? Contoh: *makan*",
+	"edit.ask.description": "Keterangan barunya apa?",
+	"edit.ask.type":        "Jenisnya *pemasukan* atau *pengeluaran*?",
+	"edit.ask.date":        "Tanggal barunya? Contoh: *kemarin*, *senin lalu*, *tgl 25* atau *25/01*",
+	"edit.ask.value":       "Nilai barunya apa?",
+	"edit.invalid.amount":  "Nominal tidak valid",
+	"edit.invalid.type":    "Jenis tidak valid",
+	"edit.invalid.date":    "Tanggal tidak valid",
+
+	"delete.not_found": "Transaksi tidak ditemukan 🤔\n\nContoh: *hapus TX#abcd1234-1700000000* atau *hapus yang terakhir*",
+	"delete.failed":    "Gagal menghapus transaksi 😔",
+	"delete.done":      "🗑️ Transaksi dihapus:\n{{.Summary}}",
+	"delete.confirm":   "Hapus transaksi ini?\n{{.Summary}}\n\nKetik *ya* untuk hapus atau *tidak* untuk batal.",
+	"delete.cancelled": "Transaksi tidak dihapus.",
+
+	// Reports
+	"report.failed":        "Gagal membuat rekap 😔",
+	"report.title":         "📊 *Rekap {{.Period}}*\n\n",
+	"report.period.today":  "Hari Ini",
+	"report.period.week":   "Minggu Ini",
+	"report.period.month":  "Bulan Ini",
+	"report.income":        "💰 Pemasukan: Rp {{amount .Amount}}\n",
+	"report.expense":       "💸 Pengeluaran: Rp {{amount .Amount}}\n",
+	"report.net":           "📈 Saldo Bersih: Rp {{amount .Amount}}\n\n",
+	"report.top":           "🏷️ *Top Kategori:*\n",
+	"report.receipt_items": "\n🧾 *Rincian Belanja (dari struk):*\n",
+	"report.empty":         "\nBelum ada transaksi di periode ini.",
+
+	"items.not_found": "Transaksi tidak ditemukan 🤔\n\nContoh: *rincian TX#abcd1234-1700000000* atau *rincian*",
+	"items.failed":    "Gagal mengambil rincian 😔",
+	"items.title":     "🧾 *Rincian {{.TxID}}*\n{{.Description}} - Rp {{amount .Amount}}\n",
+	"items.none":      "\nTransaksi ini tidak punya rincian item.",
+	"items.other":     "lainnya",
+
+	// Questions about the ledger
+	"query.unavailable":     "Maaf, layanan AI sedang gangguan jadi pertanyaan belum bisa dijawab 😔\n\nSementara itu *rekap hari ini* atau *rekap bulan ini* tetap bisa dipakai.",
+	"query.unclear":         "Maaf, pertanyaannya belum aku pahami 😅\n\nContoh:\n• berapa total jajan kopi bulan lalu?\n• pengeluaran terbesar minggu ini apa?\n• top 3 kategori pengeluaran bulan ini",
+	"query.failed":          "Gagal mengambil data transaksi 😔",
+	"query.subject":         "Transaksi",
+	"query.subject.EXPENSE": "Pengeluaran",
+	"query.subject.INCOME":  "Pemasukan",
+	"query.none":            "Belum ada transaksi yang cocok di periode ini.",
+	"query.sum":             "Total: *Rp {{amount .Value}}* ({{.Count}} transaksi)",
+	"query.count":           "Jumlah: *{{.Count}} transaksi* (total Rp {{amount .Value}})",
+	"query.avg":             "Rata-rata: *Rp {{amount .Value}}* per transaksi ({{.Count}} transaksi)",
+	"query.max":             "Terbesar: *Rp {{amount .Amount}}* - {{.Description}}\n🏷️ {{.Category}}, {{.Date}}",
+	"query.max_list":        "Terbesar:\n",
+	"query.top":             "🏷️ Kategori terbesar:\n",
+	"query.top_line":        "{{.Rank}}. {{.Category}}: Rp {{amount .Total}} ({{.Count}} transaksi)\n",
+
+	// Categories
+	"category.usage": `Perintah kategori:
+• *kategori* - lihat daftar kategori
+• *tambah kategori Skincare di Belanja*
+• *tambah kategori Freelance pemasukan*
+• *ubah kategori Hiburan jadi Rekreasi*
+• *gabung kategori Kopi & Jajan ke Makanan & Minuman*`,
+	"category.list":            "🗂️ *Kategori kamu*\n\n{{.List}}\n\n{{.Usage}}",
+	"category.list_failed":     "Maaf, gagal mengambil daftar kategori 😔",
+	"category.section.EXPENSE": "💸 *Pengeluaran*",
+	"category.section.INCOME":  "💰 *Pemasukan*",
+	"category.rename_example":  "Contoh: *ubah kategori Hiburan jadi Rekreasi*",
+	"category.merge_example":   "Contoh: *gabung kategori Kopi & Jajan ke Makanan & Minuman*",
+	"category.added":           "✅ Kategori {{.Emoji}} *{{.Name}}* ditambahkan{{with .Parent}} di bawah *{{.}}*{{end}}.",
+	"category.renamed":         "✅ Kategori *{{.From}}* sekarang bernama *{{.To}}*.",
+	"category.merged":          "✅ Kategori *{{.From}}* digabung ke *{{.To}}*.",
+	"category.not_found":       "Kategori *{{.Name}}* tidak ditemukan 🤔\n\nKetik *kategori* untuk melihat daftarnya.",
+	"category.exists":          "Kategori *{{.Name}}* sudah ada.",
+	"category.type_mismatch":   "Kategori pemasukan dan pengeluaran tidak bisa dicampur.",
+	"category.same":            "Kategorinya sama, tidak ada yang digabung.",
+	"category.failed":          "Maaf, gagal mengubah kategori 😔",
+
+	// Language
+	"language.menu":    "🌐 Bahasa sekarang: *{{.Current}}*\n\nGanti dengan:\n• *bahasa indonesia*\n• *bahasa inggris*\n• *basa jawa*",
+	"language.changed": "✅ Oke, mulai sekarang aku balas pakai Bahasa Indonesia.",
+	"language.failed":  "Maaf, gagal mengganti bahasa 😔",
+}
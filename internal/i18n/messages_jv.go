@@ -0,0 +1,168 @@
+package i18n
+
+// messagesJV is the Javanese (ngoko) catalog. Commands in examples are the
+// Indonesian ones, which the bot understands in every language.
+var messagesJV = map[string]string{
+	// Account and onboarding
+	"user.blocked": "Akunmu diblokir. Hubungi admin kanggo informasi luwih lanjut.",
+	"onboarding.welcome": `Halo! Aku bot pencatat keuangan 📒
+
+Pilih paket:
+1️⃣ Free (10 transaksi)
+2️⃣ Premium Rp10rb/sasi (hubungi admin 081389592985)
+
+Ketik *1* utawa *2* kanggo milih.`,
+	"onboarding.free":    "✅ Paket Free aktif! Kowe iso nyathet nganti 10 transaksi.\n\nConto:\n• gajian 5 yuta\n• tuku bensin 50 ewu\n• utawa kirim foto struk!",
+	"onboarding.premium": "📞 Monggo hubungi admin ing 081389592985 kanggo upgrade dadi Premium.\n\nSakdurunge, kowe iso nganggo paket Free (10 transaksi).",
+	"onboarding.invalid": "Pilihane ora valid. Ketik *1* kanggo Free utawa *2* kanggo Premium.",
+	"premium.upgraded":   "🎉 Akunmu wis di-upgrade dadi Premium! Transaksi ora diwatesi.",
+
+	// General
+	"system.error": "Ngapunten, ana masalah sistem 😔",
+	"job.dead":     "Ngapunten, pesenmu gagal diproses amarga ana gangguan sistem 😔\n\nJajal kirim maneh sedhela engkas ya.",
+	"smalltalk":    "Halo uga! 👋\n\nKirim transaksi kapan wae, conto: *tuku kopi 20 ewu*. Ketik *bantuan* kanggo ndeleng kabeh fitur.",
+	"help": `Aku iso mbantu kowe:
+• Nyathet transaksi: "tuku bensin 50 ewu", "gajian 5 yuta"
+• Kirim foto struk
+• Kirim voice note: "mau mangan awan 35 ewu"
+• Ndeleng rekap: "rekap dina iki", "rekap wulan iki"
+• Takon: "berapa total jajan kopi bulan lalu?"
+• Mbatalke transaksi pungkasan: "undo"
+• Ngowahi transaksi: "edit TX#..." utawa "ubah yang terakhir"
+• Mbusak transaksi: "hapus TX#..." utawa "hapus yang terakhir"
+• Rincian struk: "rincian" utawa "rincian TX#..."
+• Kategori: "kategori", "tambah kategori ...", "ubah kategori ... jadi ..."
+• Basa: "basa indonesia", "basa inggris"`,
+
+	// Transaction types
+	"type.INCOME":  "Pemasukan",
+	"type.EXPENSE": "Pengeluaran",
+
+	// Media
+	"media.unsupported":       "Aku lagi iso maca foto struk utawa screenshot transfer, durung iso file liyane 🙏",
+	"voice.too_large":         "Voice note-e kegedhen (maks {{.MaxMB}} MB) 😅",
+	"voice.download_failed":   "Gagal njupuk voice note 😔\n\nIso dikirim maneh?",
+	"voice.transcribe_failed": "Aku durung iso krungu voice note iki 😅\n\nIso diulang utawa diketik wae?",
+	"image.too_large":         "Gambare kegedhen (maks {{.MaxMB}} MB) 😅",
+	"image.download_failed":   "Gagal njupuk gambar 😔\n\nIso dikirim maneh?",
+	"image.unreadable":        "Aku durung iso maca gambar iki 😅\n\nIso dikirim maneh utawa diketik wae?",
+
+	// Recording
+	"parse.failed":      "Ngapunten, aku durung mudheng pesen iki 😅\n\nConto: tuku bensin 50 ewu",
+	"parse.unsure":      "Aku kurang yakin karo transaksi iki 🤔\n\nJajal tulis luwih cetha, conto:\n• gajian 5 yuta\n• tuku bensin 50 ewu",
+	"tx.limit":          "❌ Limit free wis entek (10 transaksi).\n\nUpgrade dadi Premium? Hubungi admin 081389592985",
+	"tx.save_failed":    "Ngapunten, gagal nyimpen transaksi 😔",
+	"tx.saved":          "✅ Transaksi kasimpen!\n\n{{.Emoji}} {{.Type}}\nRp {{amount .Amount}} - {{.Description}}\n\nID: {{.TxID}}{{.ItemsNote}}\nKetik *undo* sajrone 60 detik kanggo mbatalke.",
+	"tx.items_note":     "\n{{.Count}} item kacathet, ketik *rincian* kanggo ndeleng.",
+	"tx.saved_many":     "✅ {{.Count}} transaksi kasimpen!\n",
+	"tx.undo_all":       "\nKetik *undo* sajrone 60 detik kanggo mbatalke kabeh.",
+	"tx.summary":        "ID: {{.TxID}}\n{{.Type}} Rp {{amount .Amount}} - {{.Description}}\nKategori: {{.Category}}\nTanggal: {{.Date}}",
+	"tx.unavailable":    "Ngapunten, layanan lagi gangguan 😔\n\nJajal kirim maneh sawetara menit engkas ya.",
+	"tx.deferred":       "📝 Kacathet, bakal diproses.\n\nLayanan AI lagi gangguan, mengko tak kabari yen transaksine wis kasimpen.",
+	"tx.deferred_dead":  "Ngapunten, pesen iki gagal diproses amarga layanan gangguan 😔\n\nKirim maneh yen isih pengin dicathet.",
+	"tx.deferred_image": "⏳ Gambar sing mbok kirim {{.SentAt}}:\n\n",
+	"tx.deferred_audio": "⏳ Voice note sing mbok kirim {{.SentAt}}:\n\n",
+	"tx.deferred_text":  "⏳ Pesen \"{{.Text}}\" ({{.SentAt}}):\n\n",
+
+	// Confirmation
+	"confirm.ask":                "Konfirmasi transaksi:\n{{.List}}\nKetik *ya* kanggo nyimpen utawa *ora* kanggo mbatalke.{{.Hint}}",
+	"confirm.hint":               "\nIso uga dikoreksi, conto: *ya tapi 45rb*",
+	"confirm.far_future":         "⚠️ Tanggale ing mangsa ngarep: {{.Date}}\n",
+	"confirm.items_mismatch":     "⚠️ Total struk Rp{{amount .Amount}} ora padha karo jumlah {{.Count}} item (Rp{{amount .ItemsTotal}})\n",
+	"confirm.correction_unclear": "Koreksine durung tak mudheng 🤔\n\nConto: *ya tapi 45rb*, *ya kategori makan*, utawa ketik *ora* kanggo mbatalke.",
+	"confirm.cancelled":          "❌ Transaksi ora disimpen.",
+	"confirm.dropped":            "Transaksi sing ngenteni konfirmasi ora disimpen.",
+	"confirm.expired":            "⌛ Wektu konfirmasi entek, transaksi ora disimpen.{{.Summary}}\n\nKirim maneh pesene yen isih pengin dicathet.",
+
+	// Undo, edit and delete
+	"undo.none":      "Ora ana transaksi sing iso dibatalke.",
+	"undo.expired":   "Wektu undo wis entek (60 detik).",
+	"undo.failed":    "Gagal mbatalke transaksi 😔",
+	"undo.done":      "✅ Transaksi pungkasan dibatalke!",
+	"undo.done_many": "✅ {{.Count}} transaksi pungkasan dibatalke!",
+
+	"edit.not_found":       "Transaksi ora ketemu 🤔\n\nConto: *edit TX#abcd1234-1700000000* utawa *ubah yang terakhir*",
+	"edit.menu":            "✏️ Edit transaksi:\n{{.Summary}}\n\nArep ngowahi apa?\n1️⃣ Nominal\n2️⃣ Kategori\n3️⃣ Keterangan\n4️⃣ Jenis (pemasukan/pengeluaran)\n5️⃣ Tanggal\n\nKetik nomer utawa jeneng field, conto: *nominal 45rb*. Ketik *batal* kanggo metu.",
+	"edit.cancelled":       "Edit dibatalke.",
+	"edit.gone":            "Transaksine wis ora ana, edit dibatalke.",
+	"edit.pick_field":      "Pilih field: *nominal*, *kategori*, *keterangan*, *jenis*, utawa *tanggal* (utawa 1-5).",
+	"edit.failed":          "Gagal ngowahi transaksi 😔",
+	"edit.done":            "✅ Transaksi wis dianyari!\n\n{{.Summary}}",
+	"edit.ask.amount":      "Nominal anyare pira? Conto: *45rb*",
+	"edit.ask.category":    "Kategori anyare apa? Conto: *makan*",
+	"edit.ask.description": "Keterangan anyare apa?",
+	"edit.ask.type":        "Jenise *pemasukan* utawa *pengeluaran*?",
+	"edit.ask.date":        "Tanggal anyare? Conto: *wingi*, *senin lalu*, *tgl 25* utawa *25/01*",
+	"edit.ask.value":       "Nilai anyare apa?",
+	"edit.invalid.amount":  "Nominal ora valid",
+	"edit.invalid.type":    "Jenis ora valid",
+	"edit.invalid.date":    "Tanggal ora valid",
+
+	"delete.not_found": "Transaksi ora ketemu 🤔\n\nConto: *hapus TX#abcd1234-1700000000* utawa *hapus yang terakhir*",
+	"delete.failed":    "Gagal mbusak transaksi 😔",
+	"delete.done":      "🗑️ Transaksi dibusak:\n{{.Summary}}",
+	"delete.confirm":   "Busak transaksi iki?\n{{.Summary}}\n\nKetik *ya* kanggo mbusak utawa *ora* kanggo batal.",
+	"delete.cancelled": "Transaksi ora dibusak.",
+
+	// Reports
+	"report.failed":        "Gagal nggawe rekap 😔",
+	"report.title":         "📊 *Rekap {{.Period}}*\n\n",
+	"report.period.today":  "Dina Iki",
+	"report.period.week":   "Minggu Iki",
+	"report.period.month":  "Wulan Iki",
+	"report.income":        "💰 Pemasukan: Rp {{amount .Amount}}\n",
+	"report.expense":       "💸 Pengeluaran: Rp {{amount .Amount}}\n",
+	"report.net":           "📈 Saldo Bersih: Rp {{amount .Amount}}\n\n",
+	"report.top":           "🏷️ *Kategori Paling Gedhe:*\n",
+	"report.receipt_items": "\n🧾 *Rincian Blanja (saka struk):*\n",
+	"report.empty":         "\nDurung ana transaksi ing periode iki.",
+
+	"items.not_found": "Transaksi ora ketemu 🤔\n\nConto: *rincian TX#abcd1234-1700000000* utawa *rincian*",
+	"items.failed":    "Gagal njupuk rincian 😔",
+	"items.title":     "🧾 *Rincian {{.TxID}}*\n{{.Description}} - Rp {{amount .Amount}}\n",
+	"items.none":      "\nTransaksi iki ora duwe rincian item.",
+	"items.other":     "liyane",
+
+	// Questions about the ledger
+	"query.unavailable":     "Ngapunten, layanan AI lagi gangguan dadi pitakonan durung iso dijawab 😔\n\nSakdurunge *rekap dina iki* utawa *rekap wulan iki* tetep iso dienggo.",
+	"query.unclear":         "Ngapunten, pitakonane durung tak mudheng 😅\n\nConto:\n• pira total jajan kopi wulan wingi?\n• pengeluaran paling gedhe minggu iki apa?\n• top 3 kategori pengeluaran bulan ini",
+	"query.failed":          "Gagal njupuk data transaksi 😔",
+	"query.subject":         "Transaksi",
+	"query.subject.EXPENSE": "Pengeluaran",
+	"query.subject.INCOME":  "Pemasukan",
+	"query.none":            "Durung ana transaksi sing cocog ing periode iki.",
+	"query.sum":             "Total: *Rp {{amount .Value}}* ({{.Count}} transaksi)",
+	"query.count":           "Cacah: *{{.Count}} transaksi* (total Rp {{amount .Value}})",
+	"query.avg":             "Rata-rata: *Rp {{amount .Value}}* saben transaksi ({{.Count}} transaksi)",
+	"query.max":             "Paling gedhe: *Rp {{amount .Amount}}* - {{.Description}}\n🏷️ {{.Category}}, {{.Date}}",
+	"query.max_list":        "Paling gedhe:\n",
+	"query.top":             "🏷️ Kategori paling gedhe:\n",
+	"query.top_line":        "{{.Rank}}. {{.Category}}: Rp {{amount .Total}} ({{.Count}} transaksi)\n",
+
+	// Categories
+	"category.usage": `Printah kategori:
+• *kategori* - ndeleng daftar kategori
+• *tambah kategori Skincare di Belanja*
+• *tambah kategori Freelance pemasukan*
+• *ubah kategori Hiburan jadi Rekreasi*
+• *gabung kategori Kopi & Jajan ke Makanan & Minuman*`,
+	"category.list":            "🗂️ *Kategorimu*\n\n{{.List}}\n\n{{.Usage}}",
+	"category.list_failed":     "Ngapunten, gagal njupuk daftar kategori 😔",
+	"category.section.EXPENSE": "💸 *Pengeluaran*",
+	"category.section.INCOME":  "💰 *Pemasukan*",
+	"category.rename_example":  "Conto: *ubah kategori Hiburan jadi Rekreasi*",
+	"category.merge_example":   "Conto: *gabung kategori Kopi & Jajan ke Makanan & Minuman*",
+	"category.added":           "✅ Kategori {{.Emoji}} *{{.Name}}* ditambahke{{with .Parent}} ing ngisor *{{.}}*{{end}}.",
+	"category.renamed":         "✅ Kategori *{{.From}}* saiki jenenge *{{.To}}*.",
+	"category.merged":          "✅ Kategori *{{.From}}* digabung menyang *{{.To}}*.",
+	"category.not_found":       "Kategori *{{.Name}}* ora ketemu 🤔\n\nKetik *kategori* kanggo ndeleng daftare.",
+	"category.exists":          "Kategori *{{.Name}}* wis ana.",
+	"category.type_mismatch":   "Kategori pemasukan lan pengeluaran ora iso dicampur.",
+	"category.same":            "Kategorine padha, ora ana sing digabung.",
+	"category.failed":          "Ngapunten, gagal ngowahi kategori 😔",
+
+	// Language
+	"language.menu":    "🌐 Basa saiki: *{{.Current}}*\n\nGanti dadi:\n• *basa indonesia*\n• *basa inggris*\n• *basa jawa*",
+	"language.changed": "✅ Siap, wiwit saiki aku mbales nganggo basa Jawa.",
+	"language.failed":  "Ngapunten, gagal ngganti basa 😔",
+}
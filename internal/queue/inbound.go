@@ -0,0 +1,260 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/repository"
+)
+
+const (
+	// jobTimeout bounds a single processing attempt
+	jobTimeout = 2 * time.Minute
+	// jobLease is how long a PROCESSING job may go without finishing before
+	// another worker takes it over; must be longer than jobTimeout
+	jobLease = 5 * time.Minute
+	// pollInterval is how often idle workers look for due retries
+	pollInterval = 2 * time.Second
+	// maintenanceInterval is how often stale jobs are reclaimed
+	maintenanceInterval = time.Minute
+	// doneRetentionHours is how long finished jobs are kept
+	doneRetentionHours = 72
+)
+
+// errPanic marks a job whose handler panicked. Such jobs are dead-lettered
+// instead of retried: the panic may come after replies were sent or
+// transactions saved, and a retry would repeat them.
+var errPanic = errors.New("handler panicked")
+
+// JobHandler processes inbound jobs
+type JobHandler interface {
+	// ProcessJob handles one job; a returned error schedules a retry and a
+	// panic dead-letters the job
+	ProcessJob(ctx context.Context, job *domain.InboundJob) error
+	// JobDead is called once a job has been moved to the dead-letter status
+	JobDead(ctx context.Context, job *domain.InboundJob)
+}
+
+// InboundQueue is a Postgres-backed queue of webhook messages consumed by a
+// bounded pool of workers. Failed jobs are retried with exponential backoff
+// and dead-lettered after maxAttempts. Messages from one sender are processed
+// one at a time, in order.
+type InboundQueue struct {
+	repo        *repository.InboundJobRepository
+	locker      Locker
+	workers     int
+	maxAttempts int
+
+	handler JobHandler
+	wake    chan struct{}
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func NewInboundQueue(repo *repository.InboundJobRepository, locker Locker, workers, maxAttempts int) *InboundQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &InboundQueue{
+		repo:        repo,
+		locker:      locker,
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		wake:        make(chan struct{}, workers),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Enqueue persists a message and wakes an idle worker. Duplicate WA message
+// IDs are ignored. messageAt orders messages of the same sender.
+func (q *InboundQueue) Enqueue(ctx context.Context, waMessageID, msisdn string, messageAt time.Time, payload json.RawMessage) error {
+	inserted, err := q.repo.Enqueue(ctx, waMessageID, msisdn, messageAt, payload)
+	if err != nil {
+		return err
+	}
+	if !inserted {
+		log.Printf("Message already queued: %s", waMessageID)
+		return nil
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Start launches the workers
+func (q *InboundQueue) Start(handler JobHandler) {
+	q.handler = handler
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+
+	q.wg.Add(1)
+	go q.maintenance()
+
+	log.Printf("Inbound queue started with %d workers", q.workers)
+}
+
+// Shutdown stops claiming new jobs and waits for in-flight jobs to finish.
+// Jobs still running when ctx expires stay PROCESSING and are picked up
+// again after their lease expires.
+func (q *InboundQueue) Shutdown(ctx context.Context) error {
+	close(q.stop)
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("inbound queue drain: %w", ctx.Err())
+	}
+}
+
+func (q *InboundQueue) worker() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		job, err := q.repo.ClaimNext(context.Background())
+		if err != nil {
+			log.Printf("Failed to claim job: %v", err)
+		}
+
+		if job == nil {
+			select {
+			case <-q.stop:
+				return
+			case <-q.wake:
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+
+		q.run(job)
+	}
+}
+
+func (q *InboundQueue) run(job *domain.InboundJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+
+	err := q.process(ctx, job)
+
+	// Bookkeeping gets its own context so a timed-out job can still be recorded
+	bgCtx := context.Background()
+
+	if err == nil {
+		if err := q.repo.MarkDone(bgCtx, job.ID); err != nil {
+			log.Printf("Failed to mark job %d done: %v", job.ID, err)
+		}
+		return
+	}
+
+	log.Printf("Job %d (%s) failed (attempt %d/%d): %v", job.ID, job.WAMessageID, job.Attempts, q.maxAttempts, err)
+
+	if job.Attempts >= q.maxAttempts || errors.Is(err, errPanic) {
+		if err := q.repo.MarkDead(bgCtx, job.ID, err.Error()); err != nil {
+			log.Printf("Failed to mark job %d dead: %v", job.ID, err)
+			return
+		}
+		q.handler.JobDead(bgCtx, job)
+		return
+	}
+
+	if err := q.repo.MarkRetry(bgCtx, job.ID, err.Error(), time.Now().Add(backoff(job.Attempts))); err != nil {
+		log.Printf("Failed to schedule retry for job %d: %v", job.ID, err)
+	}
+}
+
+// Lock takes the sender lock that jobs from msisdn run under, for work done
+// outside the queue that must not interleave with them
+func (q *InboundQueue) Lock(ctx context.Context, msisdn string) (func(), error) {
+	return q.locker.Lock(ctx, msisdn)
+}
+
+// process runs the handler under the sender's lock, turning panics into
+// errPanic errors
+func (q *InboundQueue) process(ctx context.Context, job *domain.InboundJob) (err error) {
+	unlock, err := q.locker.Lock(ctx, job.MSISDN)
+	if err != nil {
+		return fmt.Errorf("failed to lock sender: %w", err)
+	}
+	defer unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %d (%s) panicked: %v\n%s", job.ID, job.WAMessageID, r, debug.Stack())
+			err = fmt.Errorf("%w: %v", errPanic, r)
+		}
+	}()
+
+	return q.handler.ProcessJob(ctx, job)
+}
+
+func (q *InboundQueue) maintenance() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx := context.Background()
+
+		dead, err := q.repo.ReclaimStale(ctx, jobLease, q.maxAttempts)
+		if err != nil {
+			log.Printf("Failed to reclaim stale jobs: %v", err)
+		}
+		for _, job := range dead {
+			log.Printf("Job %d (%s) dead-lettered after lease expiry", job.ID, job.WAMessageID)
+			q.handler.JobDead(ctx, job)
+		}
+
+		if err := q.repo.CleanupDone(ctx, doneRetentionHours); err != nil {
+			log.Printf("Failed to cleanup jobs: %v", err)
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt: 5s, 10s, 20s, ... capped at 10 minutes
+func backoff(attempts int) time.Duration {
+	delay := 5 * time.Second
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= 10*time.Minute {
+			return 10 * time.Minute
+		}
+	}
+	return delay
+}
@@ -0,0 +1,232 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/repository"
+	"github.com/nicolaananda/catatuang/internal/testutil"
+)
+
+// stubHandler fails the first failures attempts of every job
+type stubHandler struct {
+	failures  int
+	processed map[string]int
+	dead      []string
+}
+
+func (h *stubHandler) ProcessJob(ctx context.Context, job *domain.InboundJob) error {
+	h.processed[job.WAMessageID]++
+	if h.processed[job.WAMessageID] <= h.failures {
+		return errors.New("handler failed")
+	}
+	return nil
+}
+
+func (h *stubHandler) JobDead(ctx context.Context, job *domain.InboundJob) {
+	h.dead = append(h.dead, job.WAMessageID)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{8, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestInboundQueueRetriesAndDeadLetters(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewInboundJobRepository(db)
+	handler := &stubHandler{failures: 5, processed: map[string]int{}}
+	q := NewInboundQueue(repo, NewLocalLocker(), 1, 2)
+	q.handler = handler
+
+	payload := []byte(`{"message":{"id":"3EB0A"}}`)
+	if err := q.Enqueue(ctx, "3EB0A", "6281234567890", time.Now(), payload); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	// Webhook redeliveries are ignored
+	if inserted, err := repo.Enqueue(ctx, "3EB0A", "6281234567890", time.Now(), payload); err != nil || inserted {
+		t.Fatalf("second Enqueue = %v, %v, want ignored", inserted, err)
+	}
+
+	job, err := repo.ClaimNext(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimNext = %v, %v", job, err)
+	}
+	q.run(job)
+
+	var status string
+	if err := db.QueryRow(`SELECT status FROM inbound_jobs WHERE id = $1`, job.ID).Scan(&status); err != nil {
+		t.Fatalf("failed to read job: %v", err)
+	}
+	if status != domain.JobPending {
+		t.Fatalf("failed job is %s, want PENDING", status)
+	}
+
+	// Not due yet
+	if next, err := repo.ClaimNext(ctx); err != nil || next != nil {
+		t.Fatalf("ClaimNext before the retry = %v, %v", next, err)
+	}
+
+	if _, err := db.Exec(`UPDATE inbound_jobs SET run_at = NOW() WHERE id = $1`, job.ID); err != nil {
+		t.Fatalf("failed to make job due: %v", err)
+	}
+	job, err = repo.ClaimNext(ctx)
+	if err != nil || job == nil || job.Attempts != 2 {
+		t.Fatalf("ClaimNext = %+v, %v, want the second attempt", job, err)
+	}
+	q.run(job)
+
+	if err := db.QueryRow(`SELECT status FROM inbound_jobs WHERE id = $1`, job.ID).Scan(&status); err != nil {
+		t.Fatalf("failed to read job: %v", err)
+	}
+	if status != domain.JobDead || len(handler.dead) != 1 {
+		t.Fatalf("job is %s with %d dead notices, want DEAD and 1", status, len(handler.dead))
+	}
+}
+
+func TestInboundQueueRecoversFromPanics(t *testing.T) {
+	q := NewInboundQueue(nil, NewLocalLocker(), 1, 1)
+	q.handler = &panicHandler{}
+
+	err := q.process(context.Background(), &domain.InboundJob{MSISDN: "6281234567890"})
+	if !errors.Is(err, errPanic) {
+		t.Fatalf("process = %v for a panicking handler, want errPanic", err)
+	}
+}
+
+func TestInboundQueueDeadLettersPanics(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewInboundJobRepository(db)
+	q := NewInboundQueue(repo, NewLocalLocker(), 1, 5)
+	handler := &panicHandler{}
+	q.handler = handler
+
+	if err := q.Enqueue(ctx, "3EB0P", "6281234567890", time.Now(), []byte(`{}`)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	job, err := repo.ClaimNext(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("ClaimNext = %v, %v", job, err)
+	}
+	q.run(job)
+
+	// The handler may have replied before panicking, so it isn't run again
+	var status string
+	if err := db.QueryRow(`SELECT status FROM inbound_jobs WHERE id = $1`, job.ID).Scan(&status); err != nil {
+		t.Fatalf("failed to read job: %v", err)
+	}
+	if status != domain.JobDead || handler.dead != 1 {
+		t.Fatalf("panicked job is %s with %d dead notices, want DEAD and 1 on the first attempt", status, handler.dead)
+	}
+}
+
+type panicHandler struct {
+	dead int
+}
+
+func (h *panicHandler) ProcessJob(ctx context.Context, job *domain.InboundJob) error {
+	panic("boom")
+}
+
+func (h *panicHandler) JobDead(ctx context.Context, job *domain.InboundJob) {
+	h.dead++
+}
+
+// blockingHandler holds every job until release is closed
+type blockingHandler struct {
+	started chan string
+	release chan struct{}
+}
+
+func (h *blockingHandler) ProcessJob(ctx context.Context, job *domain.InboundJob) error {
+	h.started <- job.WAMessageID
+	<-h.release
+	return nil
+}
+
+func (h *blockingHandler) JobDead(ctx context.Context, job *domain.InboundJob) {}
+
+func TestInboundQueueShutdownDrains(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewInboundJobRepository(db)
+	handler := &blockingHandler{started: make(chan string, 1), release: make(chan struct{})}
+	q := NewInboundQueue(repo, NewLocalLocker(), 2, 1)
+	q.Start(handler)
+
+	if err := q.Enqueue(ctx, "3EB0A", "6281234567890", time.Now(), []byte(`{}`)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	select {
+	case <-handler.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job never started")
+	}
+
+	// Shutdown waits for the job in flight
+	done := make(chan error, 1)
+	go func() { done <- q.Shutdown(ctx) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned %v while a job was running", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(handler.release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Shutdown: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown didn't return after the job finished")
+	}
+
+	var status string
+	if err := db.QueryRow(`SELECT status FROM inbound_jobs WHERE wa_message_id = '3EB0A'`).Scan(&status); err != nil {
+		t.Fatalf("failed to read job: %v", err)
+	}
+	if status != domain.JobDone {
+		t.Fatalf("drained job is %s, want DONE", status)
+	}
+}
+
+func TestInboundQueueShutdownDeadline(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	handler := &blockingHandler{started: make(chan string, 1), release: make(chan struct{})}
+	defer close(handler.release)
+	q := NewInboundQueue(repository.NewInboundJobRepository(db), NewLocalLocker(), 1, 1)
+	q.Start(handler)
+
+	if err := q.Enqueue(ctx, "3EB0A", "6281234567890", time.Now(), []byte(`{}`)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	<-handler.started
+
+	// A job that outlives the shutdown timeout is left for its lease to expire
+	shutdownCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := q.Shutdown(shutdownCtx); err == nil {
+		t.Fatal("Shutdown returned nil with a job still running")
+	}
+}
@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Locker serialises processing of jobs that share a key (the sender MSISDN).
+// ClaimNext already hands out one job per sender at a time; the locker also
+// covers the window where a stale job is reclaimed while its first worker is
+// still running.
+type Locker interface {
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// LocalLocker is an in-process keyed mutex, enough for a single instance
+type LocalLocker struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+func NewLocalLocker() *LocalLocker {
+	return &LocalLocker{locks: make(map[string]*keyLock)}
+}
+
+func (l *LocalLocker) Lock(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = &keyLock{ch: make(chan struct{}, 1)}
+		l.locks[key] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	select {
+	case lock.ch <- struct{}{}:
+	case <-ctx.Done():
+		l.release(key, lock)
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-lock.ch
+		l.release(key, lock)
+	}, nil
+}
+
+// release drops a reference and forgets the key once nobody waits on it
+func (l *LocalLocker) release(key string, lock *keyLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, key)
+	}
+}
+
+// AdvisoryLocker uses Postgres session advisory locks, so jobs of one sender
+// are serialised across all instances sharing the database
+type AdvisoryLocker struct {
+	db *sql.DB
+}
+
+func NewAdvisoryLocker(db *sql.DB) *AdvisoryLocker {
+	return &AdvisoryLocker{db: db}
+}
+
+func (l *AdvisoryLocker) Lock(ctx context.Context, key string) (func(), error) {
+	// Session locks belong to a connection, so hold one for the whole job
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	lockKey := "inbound:" + key
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock(hashtext($1))`, lockKey); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+
+	return func() {
+		_, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, lockKey)
+		if err != nil {
+			log.Printf("Failed to release advisory lock %s: %v", lockKey, err)
+			// Never return a connection that still holds the lock to the pool
+			conn.Raw(func(driverConn interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
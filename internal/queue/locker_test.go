@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/repository"
+	"github.com/nicolaananda/catatuang/internal/testutil"
+)
+
+func TestLocalLocker(t *testing.T) {
+	l := NewLocalLocker()
+	ctx := context.Background()
+
+	unlock, err := l.Lock(ctx, "6281111")
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	// Another sender isn't held up
+	other, err := l.Lock(ctx, "6282222")
+	if err != nil {
+		t.Fatalf("Lock(other sender): %v", err)
+	}
+	other()
+
+	// The same sender waits until the lock is released
+	acquired := make(chan func())
+	go func() {
+		second, err := l.Lock(ctx, "6281111")
+		if err != nil {
+			t.Errorf("second Lock: %v", err)
+			close(acquired)
+			return
+		}
+		acquired <- second
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second Lock acquired while the first was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case second := <-acquired:
+		if second != nil {
+			second()
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second Lock not acquired after unlock")
+	}
+
+	if len(l.locks) != 0 {
+		t.Errorf("%d keys left after every lock was released", len(l.locks))
+	}
+}
+
+func TestLocalLockerContext(t *testing.T) {
+	l := NewLocalLocker()
+	unlock, err := l.Lock(context.Background(), "6281111")
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Lock(ctx, "6281111"); err == nil {
+		t.Fatal("Lock succeeded while the key was held")
+	}
+}
+
+func TestClaimNextKeepsSenderOrder(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewInboundJobRepository(db)
+
+	base := time.Now().Add(-time.Minute)
+	enqueue := func(id, msisdn string, at time.Time) {
+		t.Helper()
+		if _, err := repo.Enqueue(ctx, id, msisdn, at, []byte(`{}`)); err != nil {
+			t.Fatalf("Enqueue(%s): %v", id, err)
+		}
+	}
+	// Delivered out of order
+	enqueue("A2", "6281111", base.Add(2*time.Second))
+	enqueue("A1", "6281111", base.Add(time.Second))
+	enqueue("B1", "6282222", base.Add(3*time.Second))
+
+	first, err := repo.ClaimNext(ctx)
+	if err != nil || first == nil || first.WAMessageID != "A1" {
+		t.Fatalf("first claim = %+v, %v, want A1", first, err)
+	}
+
+	// A2 waits for A1; another sender runs in parallel
+	second, err := repo.ClaimNext(ctx)
+	if err != nil || second == nil || second.WAMessageID != "B1" {
+		t.Fatalf("second claim = %+v, %v, want B1", second, err)
+	}
+	if next, err := repo.ClaimNext(ctx); err != nil || next != nil {
+		t.Fatalf("claim while A1 is processing = %+v, %v, want none", next, err)
+	}
+
+	if err := repo.MarkDone(ctx, first.ID); err != nil {
+		t.Fatalf("MarkDone: %v", err)
+	}
+	third, err := repo.ClaimNext(ctx)
+	if err != nil || third == nil || third.WAMessageID != "A2" {
+		t.Fatalf("claim after A1 = %+v, %v, want A2", third, err)
+	}
+}
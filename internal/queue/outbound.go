@@ -0,0 +1,225 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/repository"
+	"github.com/nicolaananda/catatuang/internal/whatsapp"
+)
+
+const (
+	// sendLease is how long a SENDING message may go without finishing before
+	// it is requeued; longer than the WhatsApp client's request timeout
+	sendLease = 2 * time.Minute
+	// outboundPollInterval is how often idle senders look for due messages,
+	// including ones held back by the per-recipient interval
+	outboundPollInterval = time.Second
+)
+
+// Sender delivers a text message and returns the provider's message ID
+type Sender interface {
+	SendMessage(ctx context.Context, to, message string) (string, error)
+}
+
+// OutboundQueue is a Postgres-backed queue of replies. Messages to one
+// recipient go out in order and at most once per recipientInterval, all
+// senders together respect a global rate, and failed sends are retried with
+// exponential backoff when the error is transient.
+type OutboundQueue struct {
+	repo              *repository.OutboundMessageRepository
+	sender            Sender
+	workers           int
+	maxAttempts       int
+	recipientInterval time.Duration
+	limiter           *rateLimiter
+
+	wake chan struct{}
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewOutboundQueue(repo *repository.OutboundMessageRepository, sender Sender, workers, maxAttempts, ratePerSecond int, recipientInterval time.Duration) *OutboundQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &OutboundQueue{
+		repo:              repo,
+		sender:            sender,
+		workers:           workers,
+		maxAttempts:       maxAttempts,
+		recipientInterval: recipientInterval,
+		limiter:           newRateLimiter(ratePerSecond),
+		wake:              make(chan struct{}, workers),
+		stop:              make(chan struct{}),
+	}
+}
+
+// Enqueue stores a message for delivery and wakes an idle sender. to may be
+// a bare MSISDN or a JID; both queue under the same recipient.
+func (q *OutboundQueue) Enqueue(ctx context.Context, to, message string) error {
+	if _, err := q.repo.Enqueue(ctx, whatsapp.JID(to), message); err != nil {
+		return err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// RecordReceipt applies a delivery or read receipt to the matching message
+func (q *OutboundQueue) RecordReceipt(ctx context.Context, gowaMessageID, status string) error {
+	found, err := q.repo.UpdateReceipt(ctx, gowaMessageID, status)
+	if err != nil {
+		return err
+	}
+	if !found {
+		log.Printf("Receipt for unknown message %s (%s)", gowaMessageID, status)
+	}
+	return nil
+}
+
+// Start launches the senders
+func (q *OutboundQueue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+
+	q.wg.Add(1)
+	go q.maintenance()
+
+	log.Printf("Outbound queue started with %d senders", q.workers)
+}
+
+// Shutdown stops claiming new messages and waits for in-flight sends.
+// Messages still queued are sent after the next start.
+func (q *OutboundQueue) Shutdown(ctx context.Context) error {
+	close(q.stop)
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("outbound queue drain: %w", ctx.Err())
+	}
+}
+
+func (q *OutboundQueue) worker() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		msg, err := q.repo.ClaimNext(context.Background(), q.recipientInterval)
+		if err != nil {
+			log.Printf("Failed to claim outbound message: %v", err)
+		}
+
+		if msg == nil {
+			select {
+			case <-q.stop:
+				return
+			case <-q.wake:
+			case <-time.After(outboundPollInterval):
+			}
+			continue
+		}
+
+		q.send(msg)
+	}
+}
+
+func (q *OutboundQueue) send(msg *domain.OutboundMessage) {
+	ctx := context.Background()
+	q.limiter.Wait()
+
+	gowaID, err := q.sender.SendMessage(ctx, msg.Recipient, msg.Body)
+	if err == nil {
+		if err := q.repo.MarkSent(ctx, msg.ID, gowaID); err != nil {
+			log.Printf("Failed to mark outbound message %d sent: %v", msg.ID, err)
+		}
+		return
+	}
+
+	log.Printf("Failed to send message %d to %s (attempt %d/%d): %v", msg.ID, msg.Recipient, msg.Attempts, q.maxAttempts, err)
+
+	if !whatsapp.IsRetryable(err) || msg.Attempts >= q.maxAttempts {
+		if err := q.repo.MarkFailed(ctx, msg.ID, err.Error()); err != nil {
+			log.Printf("Failed to mark outbound message %d failed: %v", msg.ID, err)
+		}
+		return
+	}
+
+	if err := q.repo.MarkRetry(ctx, msg.ID, err.Error(), time.Now().Add(backoff(msg.Attempts))); err != nil {
+		log.Printf("Failed to schedule retry for outbound message %d: %v", msg.ID, err)
+	}
+}
+
+func (q *OutboundQueue) maintenance() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if err := q.repo.ReclaimStale(context.Background(), sendLease, q.maxAttempts); err != nil {
+			log.Printf("Failed to reclaim stale outbound messages: %v", err)
+		}
+	}
+}
+
+// rateLimiter spaces calls evenly so at most perSecond happen each second
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	if perSecond < 1 {
+		perSecond = 1
+	}
+	return &rateLimiter{interval: time.Second / time.Duration(perSecond)}
+}
+
+// Wait blocks until the caller's slot comes up
+func (l *rateLimiter) Wait() {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(slot))
+}
@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/repository"
+	"github.com/nicolaananda/catatuang/internal/testutil"
+	"github.com/nicolaananda/catatuang/internal/whatsapp"
+)
+
+// stubSender returns errs in turn, then succeeds
+type stubSender struct {
+	errs []error
+	sent []string
+}
+
+func (s *stubSender) SendMessage(ctx context.Context, to, message string) (string, error) {
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return "", err
+	}
+	s.sent = append(s.sent, message)
+	return "3EB0SENT", nil
+}
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(20)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		l.Wait()
+	}
+	// The first call goes straight away, then one every 50ms
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("5 calls at 20/s took %v, want at least 200ms", elapsed)
+	}
+}
+
+func TestOutboundQueueDelivery(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+	repo := repository.NewOutboundMessageRepository(db)
+	sender := &stubSender{errs: []error{
+		&whatsapp.SendError{StatusCode: 503, Body: "busy"},
+		&whatsapp.SendError{StatusCode: 400, Body: "invalid phone"},
+	}}
+	q := NewOutboundQueue(repo, sender, 1, 3, 100, 0)
+
+	status := func(id int64) string {
+		t.Helper()
+		var s string
+		if err := db.QueryRow(`SELECT status FROM outbound_messages WHERE id = $1`, id).Scan(&s); err != nil {
+			t.Fatalf("failed to read message: %v", err)
+		}
+		return s
+	}
+	claim := func() *domain.OutboundMessage {
+		t.Helper()
+		if _, err := db.Exec(`UPDATE outbound_messages SET run_at = NOW() WHERE status = 'QUEUED'`); err != nil {
+			t.Fatalf("failed to make messages due: %v", err)
+		}
+		msg, err := repo.ClaimNext(ctx, 0)
+		if err != nil || msg == nil {
+			t.Fatalf("ClaimNext = %v, %v", msg, err)
+		}
+		return msg
+	}
+
+	if err := q.Enqueue(ctx, "6281234567890@s.whatsapp.net", "halo"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// A 503 is retried
+	msg := claim()
+	q.send(msg)
+	if got := status(msg.ID); got != domain.OutboundQueued {
+		t.Fatalf("after a 503 the message is %s, want QUEUED", got)
+	}
+
+	// A 400 is not
+	msg = claim()
+	q.send(msg)
+	if got := status(msg.ID); got != domain.OutboundFailed {
+		t.Fatalf("after a 400 the message is %s, want FAILED", got)
+	}
+
+	// A bare MSISDN and a JID are the same recipient
+	if err := q.Enqueue(ctx, "6281234567890", "apa kabar"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	var recipients int
+	if err := db.QueryRow(`SELECT COUNT(DISTINCT recipient) FROM outbound_messages`).Scan(&recipients); err != nil {
+		t.Fatalf("failed to count recipients: %v", err)
+	}
+	if recipients != 1 {
+		t.Fatalf("messages to one user have %d recipients, want 1", recipients)
+	}
+	msg = claim()
+	q.send(msg)
+	if got := status(msg.ID); got != domain.OutboundSent || len(sender.sent) != 1 {
+		t.Fatalf("message is %s after %d sends, want SENT", got, len(sender.sent))
+	}
+
+	// Receipts only move forward
+	if err := q.RecordReceipt(ctx, "3EB0SENT", domain.OutboundRead); err != nil {
+		t.Fatalf("RecordReceipt(read): %v", err)
+	}
+	if err := q.RecordReceipt(ctx, "3EB0SENT", domain.OutboundDelivered); err != nil {
+		t.Fatalf("RecordReceipt(delivered): %v", err)
+	}
+	if got := status(msg.ID); got != domain.OutboundRead {
+		t.Fatalf("after read then delivered the message is %s, want READ", got)
+	}
+}
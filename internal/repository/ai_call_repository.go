@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+type AICallRepository struct {
+	db *sql.DB
+}
+
+func NewAICallRepository(db *sql.DB) *AICallRepository {
+	return &AICallRepository{db: db}
+}
+
+func (r *AICallRepository) Create(ctx context.Context, call *domain.AICall) error {
+	query := `
+		INSERT INTO ai_calls (user_id, wa_message_id, call_type, model, prompt_version, prompt_tokens,
+		                      completion_tokens, cost_usd, latency_ms, retry_count, outcome, error)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, NULLIF($12, ''))
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		call.UserID, call.WAMessageID, call.CallType, call.Model, call.PromptVersion, call.PromptTokens,
+		call.CompletionTokens, call.CostUSD, call.LatencyMs, call.RetryCount, call.Outcome, call.Error,
+	).Scan(&call.ID, &call.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create ai call: %w", err)
+	}
+
+	return nil
+}
+
+// UsageByDay aggregates calls per calendar day
+func (r *AICallRepository) UsageByDay(ctx context.Context, start, end time.Time) ([]*domain.AIUsageSummary, error) {
+	return r.summarize(ctx, `TO_CHAR(DATE(c.created_at), 'YYYY-MM-DD')`, `key`, start, end)
+}
+
+// UsageByPlan aggregates calls per user plan; calls without a user are "UNKNOWN"
+func (r *AICallRepository) UsageByPlan(ctx context.Context, start, end time.Time) ([]*domain.AIUsageSummary, error) {
+	return r.summarize(ctx, `COALESCE(u.plan, 'UNKNOWN')`, `cost_usd DESC`, start, end)
+}
+
+// UsageByUser aggregates calls per user MSISDN, most expensive first
+func (r *AICallRepository) UsageByUser(ctx context.Context, start, end time.Time) ([]*domain.AIUsageSummary, error) {
+	return r.summarize(ctx, `COALESCE(u.msisdn, 'UNKNOWN')`, `cost_usd DESC`, start, end)
+}
+
+// summarize groups calls in [start, end) by keyExpr. keyExpr and orderBy are
+// fixed SQL fragments from this file, never user input.
+func (r *AICallRepository) summarize(ctx context.Context, keyExpr, orderBy string, start, end time.Time) ([]*domain.AIUsageSummary, error) {
+	query := fmt.Sprintf(`
+		SELECT %s AS key,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE c.outcome <> 'success'),
+		       COALESCE(SUM(c.prompt_tokens), 0),
+		       COALESCE(SUM(c.completion_tokens), 0),
+		       COALESCE(SUM(c.cost_usd), 0) AS cost_usd,
+		       COALESCE(AVG(c.latency_ms), 0)
+		FROM ai_calls c
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.created_at >= $1 AND c.created_at < $2
+		GROUP BY 1
+		ORDER BY %s
+	`, keyExpr, orderBy)
+
+	rows, err := r.db.QueryContext(ctx, query, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ai usage: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []*domain.AIUsageSummary
+	for rows.Next() {
+		s := &domain.AIUsageSummary{}
+		err := rows.Scan(&s.Key, &s.Calls, &s.Failures, &s.PromptTokens, &s.CompletionTokens, &s.CostUSD, &s.AvgLatencyMs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ai usage: %w", err)
+		}
+		summaries = append(summaries, s)
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+type CategoryMemoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryMemoryRepository(db *sql.DB) *CategoryMemoryRepository {
+	return &CategoryMemoryRepository{db: db}
+}
+
+// Upsert remembers the category for a keyword. Repeating the same category
+// strengthens the memory; a different one replaces it.
+func (r *CategoryMemoryRepository) Upsert(ctx context.Context, m *domain.CategoryMemory) error {
+	query := `
+		INSERT INTO category_memory (user_id, keyword, category, type)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, keyword) DO UPDATE SET
+			hits = CASE
+				WHEN category_memory.category = EXCLUDED.category AND category_memory.type = EXCLUDED.type
+				THEN category_memory.hits + 1
+				ELSE 1
+			END,
+			category = EXCLUDED.category,
+			type = EXCLUDED.type
+		RETURNING id, hits, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, m.UserID, m.Keyword, m.Category, m.Type).
+		Scan(&m.ID, &m.Hits, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert category memory: %w", err)
+	}
+
+	return nil
+}
+
+// ListByUser returns the user's memories, strongest and most recent first
+func (r *CategoryMemoryRepository) ListByUser(ctx context.Context, userID int64, limit int) ([]*domain.CategoryMemory, error) {
+	query := `
+		SELECT id, user_id, keyword, category, type, hits, created_at, updated_at
+		FROM category_memory
+		WHERE user_id = $1
+		ORDER BY hits DESC, updated_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list category memory: %w", err)
+	}
+	defer rows.Close()
+
+	var memories []*domain.CategoryMemory
+	for rows.Next() {
+		m := &domain.CategoryMemory{}
+		err := rows.Scan(&m.ID, &m.UserID, &m.Keyword, &m.Category, &m.Type, &m.Hits, &m.CreatedAt, &m.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category memory: %w", err)
+		}
+		memories = append(memories, m)
+	}
+
+	return memories, nil
+}
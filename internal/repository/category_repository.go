@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+type CategoryRepository struct {
+	db DBTX
+}
+
+func NewCategoryRepository(db *sql.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs queries inside tx
+func (r *CategoryRepository) WithTx(tx *sql.Tx) *CategoryRepository {
+	return &CategoryRepository{db: tx}
+}
+
+const categorySelect = `
+	SELECT c.id, c.user_id, c.parent_id, c.name, c.type, c.created_at,
+	       COALESCE(array_agg(s.synonym ORDER BY s.synonym) FILTER (WHERE s.synonym IS NOT NULL), '{}')
+	FROM categories c
+	LEFT JOIN category_synonyms s ON s.category_id = c.id
+`
+
+// ListDefaults returns the default taxonomy
+func (r *CategoryRepository) ListDefaults(ctx context.Context) ([]*domain.Category, error) {
+	return r.list(ctx, categorySelect+` WHERE c.user_id IS NULL GROUP BY c.id ORDER BY c.id`)
+}
+
+// ListByUser returns the categories a user owns, empty if they still use the defaults
+func (r *CategoryRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.Category, error) {
+	return r.list(ctx, categorySelect+` WHERE c.user_id = $1 GROUP BY c.id ORDER BY c.id`, userID)
+}
+
+func (r *CategoryRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.Category, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []*domain.Category
+	for rows.Next() {
+		c := &domain.Category{}
+		var userID, parentID sql.NullInt64
+		err := rows.Scan(&c.ID, &userID, &parentID, &c.Name, &c.Type, &c.CreatedAt, pq.Array(&c.Synonyms))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		if userID.Valid {
+			c.UserID = &userID.Int64
+		}
+		if parentID.Valid {
+			c.ParentID = &parentID.Int64
+		}
+		categories = append(categories, c)
+	}
+
+	return categories, nil
+}
+
+// Create inserts a category and its synonyms; its own name is always a synonym
+func (r *CategoryRepository) Create(ctx context.Context, c *domain.Category) error {
+	query := `
+		INSERT INTO categories (user_id, parent_id, name, type)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, c.UserID, c.ParentID, c.Name, c.Type).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+
+	if err := r.AddSynonym(ctx, c.ID, c.Name); err != nil {
+		return err
+	}
+	for _, synonym := range c.Synonyms {
+		if err := r.AddSynonym(ctx, c.ID, synonym); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *CategoryRepository) AddSynonym(ctx context.Context, categoryID int64, synonym string) error {
+	query := `
+		INSERT INTO category_synonyms (category_id, synonym)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, categoryID, strings.ToLower(strings.TrimSpace(synonym)))
+	if err != nil {
+		return fmt.Errorf("failed to add category synonym: %w", err)
+	}
+
+	return nil
+}
+
+// Rename changes a category's name and the denormalised name on its transactions
+func (r *CategoryRepository) Rename(ctx context.Context, id int64, name string) error {
+	if _, err := r.db.ExecContext(ctx, `UPDATE categories SET name = $1 WHERE id = $2`, name, id); err != nil {
+		return fmt.Errorf("failed to rename category: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, `UPDATE transactions SET category = $1 WHERE category_id = $2`, name, id); err != nil {
+		return fmt.Errorf("failed to rename category on transactions: %w", err)
+	}
+
+	return r.AddSynonym(ctx, id, name)
+}
+
+// Merge moves transactions, synonyms and subcategories of fromID to toID and
+// deletes fromID
+func (r *CategoryRepository) Merge(ctx context.Context, fromID, toID int64) error {
+	queries := []string{
+		`UPDATE transactions SET category_id = $2, category = (SELECT name FROM categories WHERE id = $2) WHERE category_id = $1`,
+		`INSERT INTO category_synonyms (category_id, synonym)
+		 SELECT $2, synonym FROM category_synonyms WHERE category_id = $1
+		 ON CONFLICT DO NOTHING`,
+		`UPDATE categories SET parent_id = $2 WHERE parent_id = $1 AND id <> $2`,
+		`DELETE FROM categories WHERE id = $1`,
+	}
+
+	for _, query := range queries {
+		if _, err := r.db.ExecContext(ctx, query, fromID, toID); err != nil {
+			return fmt.Errorf("failed to merge categories: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CopyDefaults gives the user their own copy of the default taxonomy and
+// moves their transactions onto it. Returns the new categories.
+func (r *CategoryRepository) CopyDefaults(ctx context.Context, userID int64) ([]*domain.Category, error) {
+	defaults, err := r.ListDefaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Defaults are ordered by ID, so parents are copied before their children
+	newIDs := make(map[int64]int64, len(defaults))
+	copies := make([]*domain.Category, 0, len(defaults))
+	for _, d := range defaults {
+		c := &domain.Category{
+			UserID:   &userID,
+			Name:     d.Name,
+			Type:     d.Type,
+			Synonyms: d.Synonyms,
+		}
+		if d.ParentID != nil {
+			parentID := newIDs[*d.ParentID]
+			c.ParentID = &parentID
+		}
+
+		if err := r.Create(ctx, c); err != nil {
+			return nil, err
+		}
+		newIDs[d.ID] = c.ID
+		copies = append(copies, c)
+
+		query := `UPDATE transactions SET category_id = $1 WHERE user_id = $2 AND category_id = $3`
+		if _, err := r.db.ExecContext(ctx, query, c.ID, userID, d.ID); err != nil {
+			return nil, fmt.Errorf("failed to move transactions to copied category: %w", err)
+		}
+	}
+
+	return copies, nil
+}
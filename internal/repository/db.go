@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX is implemented by both *sql.DB and *sql.Tx, so repositories can run
+// inside a database transaction started by a service
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
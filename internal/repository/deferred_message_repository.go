@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+type DeferredMessageRepository struct {
+	db *sql.DB
+}
+
+func NewDeferredMessageRepository(db *sql.DB) *DeferredMessageRepository {
+	return &DeferredMessageRepository{db: db}
+}
+
+// Create stores a message for later parsing. A message deferred twice, e.g.
+// when its inbound job is retried, is stored once.
+func (r *DeferredMessageRepository) Create(ctx context.Context, msg *domain.DeferredMessage) error {
+	query := `
+		INSERT INTO deferred_messages (user_id, wa_message_id, msisdn, kind, text, media, mime_type, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		ON CONFLICT (wa_message_id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, msg.UserID, msg.WAMessageID, msg.MSISDN, msg.Kind, msg.Text, msg.Media, msg.MimeType, msg.SentAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create deferred message: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimNext locks the oldest due message, or returns nil if there is none.
+// Messages left PROCESSING longer than lease by a crashed worker are claimed
+// again.
+func (r *DeferredMessageRepository) ClaimNext(ctx context.Context, lease time.Duration) (*domain.DeferredMessage, error) {
+	query := `
+		UPDATE deferred_messages
+		SET status = 'PROCESSING', attempts = attempts + 1, locked_at = NOW()
+		WHERE id = (
+			SELECT id FROM deferred_messages
+			WHERE (status = 'PENDING' AND run_at <= NOW())
+			   OR (status = 'PROCESSING' AND locked_at < NOW() - INTERVAL '1 second' * $1)
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, wa_message_id, msisdn, kind, text, media, COALESCE(mime_type, ''), sent_at,
+		          status, attempts, COALESCE(last_error, ''), run_at, created_at
+	`
+
+	msg := &domain.DeferredMessage{}
+	err := r.db.QueryRowContext(ctx, query, int(lease.Seconds())).Scan(
+		&msg.ID, &msg.UserID, &msg.WAMessageID, &msg.MSISDN, &msg.Kind, &msg.Text, &msg.Media, &msg.MimeType, &msg.SentAt,
+		&msg.Status, &msg.Attempts, &msg.LastError, &msg.RunAt, &msg.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deferred message: %w", err)
+	}
+
+	return msg, nil
+}
+
+// MarkDone finishes a message and drops the stored media
+func (r *DeferredMessageRepository) MarkDone(ctx context.Context, id int64) error {
+	query := `UPDATE deferred_messages SET status = 'DONE', locked_at = NULL, last_error = NULL, media = NULL WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark deferred message done: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry puts a message back to be parsed again at runAt
+func (r *DeferredMessageRepository) MarkRetry(ctx context.Context, id int64, lastError string, runAt time.Time) error {
+	query := `UPDATE deferred_messages SET status = 'PENDING', locked_at = NULL, last_error = $1, run_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, lastError, runAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark deferred message for retry: %w", err)
+	}
+	return nil
+}
+
+// MarkDead gives up on a message
+func (r *DeferredMessageRepository) MarkDead(ctx context.Context, id int64, lastError string) error {
+	query := `UPDATE deferred_messages SET status = 'DEAD', locked_at = NULL, last_error = $1, media = NULL WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, lastError, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark deferred message dead: %w", err)
+	}
+	return nil
+}
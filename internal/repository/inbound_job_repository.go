@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+type InboundJobRepository struct {
+	db *sql.DB
+}
+
+func NewInboundJobRepository(db *sql.DB) *InboundJobRepository {
+	return &InboundJobRepository{db: db}
+}
+
+// Enqueue persists an inbound message. Returns false if a job for the same
+// WA message already exists, which makes webhook redeliveries idempotent.
+// messageAt is the WhatsApp send time; zero means now.
+func (r *InboundJobRepository) Enqueue(ctx context.Context, waMessageID, msisdn string, messageAt time.Time, payload json.RawMessage) (bool, error) {
+	if messageAt.IsZero() {
+		messageAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO inbound_jobs (wa_message_id, msisdn, message_at, payload)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (wa_message_id) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, waMessageID, msisdn, messageAt, []byte(payload))
+	if err != nil {
+		return false, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ClaimNext locks the oldest runnable job for this worker, or returns nil if
+// there is none. SKIP LOCKED lets several workers and instances poll safely.
+// A sender's job is only runnable when none of their other jobs is being
+// processed and none of their earlier messages is still pending, so each chat
+// is handled in order while different senders run in parallel.
+func (r *InboundJobRepository) ClaimNext(ctx context.Context) (*domain.InboundJob, error) {
+	query := `
+		UPDATE inbound_jobs
+		SET status = 'PROCESSING', attempts = attempts + 1, locked_at = NOW()
+		WHERE id = (
+			SELECT j.id FROM inbound_jobs j
+			WHERE j.status = 'PENDING' AND j.run_at <= NOW()
+			  AND NOT EXISTS (
+				SELECT 1 FROM inbound_jobs o
+				WHERE o.msisdn = j.msisdn AND o.id <> j.id
+				  AND (o.status = 'PROCESSING'
+				       OR (o.status = 'PENDING' AND (o.message_at, o.id) < (j.message_at, j.id)))
+			  )
+			ORDER BY j.message_at, j.id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, wa_message_id, COALESCE(msisdn, ''), payload, status, attempts, COALESCE(last_error, ''), message_at, run_at, created_at
+	`
+
+	job := &domain.InboundJob{}
+	var payload []byte
+	err := r.db.QueryRowContext(ctx, query).Scan(
+		&job.ID, &job.WAMessageID, &job.MSISDN, &payload, &job.Status,
+		&job.Attempts, &job.LastError, &job.MessageAt, &job.RunAt, &job.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	job.Payload = json.RawMessage(payload)
+	return job, nil
+}
+
+func (r *InboundJobRepository) MarkDone(ctx context.Context, id int64) error {
+	query := `UPDATE inbound_jobs SET status = 'DONE', locked_at = NULL, last_error = NULL WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark job done: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry puts a failed job back in the queue to run again at runAt
+func (r *InboundJobRepository) MarkRetry(ctx context.Context, id int64, lastError string, runAt time.Time) error {
+	query := `UPDATE inbound_jobs SET status = 'PENDING', locked_at = NULL, last_error = $1, run_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, lastError, runAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark job for retry: %w", err)
+	}
+	return nil
+}
+
+// MarkDead moves a job that keeps failing to the dead-letter status
+func (r *InboundJobRepository) MarkDead(ctx context.Context, id int64, lastError string) error {
+	query := `UPDATE inbound_jobs SET status = 'DEAD', locked_at = NULL, last_error = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, lastError, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark job dead: %w", err)
+	}
+	return nil
+}
+
+// ReclaimStale releases jobs whose worker died mid-processing (crash, deploy)
+// after the lease expired. Jobs out of attempts are dead-lettered and returned.
+func (r *InboundJobRepository) ReclaimStale(ctx context.Context, lease time.Duration, maxAttempts int) ([]*domain.InboundJob, error) {
+	query := `
+		UPDATE inbound_jobs
+		SET status = CASE WHEN attempts >= $2 THEN 'DEAD' ELSE 'PENDING' END,
+		    locked_at = NULL,
+		    last_error = 'worker lease expired'
+		WHERE status = 'PROCESSING' AND locked_at < NOW() - INTERVAL '1 second' * $1
+		RETURNING id, wa_message_id, COALESCE(msisdn, ''), payload, status, attempts, COALESCE(last_error, ''), message_at, run_at, created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, int(lease.Seconds()), maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reclaim stale jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var dead []*domain.InboundJob
+	for rows.Next() {
+		job := &domain.InboundJob{}
+		var payload []byte
+		err := rows.Scan(
+			&job.ID, &job.WAMessageID, &job.MSISDN, &payload, &job.Status,
+			&job.Attempts, &job.LastError, &job.MessageAt, &job.RunAt, &job.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		job.Payload = json.RawMessage(payload)
+		if job.Status == domain.JobDead {
+			dead = append(dead, job)
+		}
+	}
+
+	return dead, nil
+}
+
+func (r *InboundJobRepository) CleanupDone(ctx context.Context, olderThanHours int) error {
+	query := `DELETE FROM inbound_jobs WHERE status = 'DONE' AND updated_at < NOW() - INTERVAL '1 hour' * $1`
+
+	_, err := r.db.ExecContext(ctx, query, olderThanHours)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup jobs: %w", err)
+	}
+
+	return nil
+}
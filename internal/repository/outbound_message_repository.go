@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+type OutboundMessageRepository struct {
+	db *sql.DB
+}
+
+func NewOutboundMessageRepository(db *sql.DB) *OutboundMessageRepository {
+	return &OutboundMessageRepository{db: db}
+}
+
+// Enqueue stores a message to be sent and returns its ID
+func (r *OutboundMessageRepository) Enqueue(ctx context.Context, recipient, body string) (int64, error) {
+	query := `INSERT INTO outbound_messages (recipient, body) VALUES ($1, $2) RETURNING id`
+
+	var id int64
+	if err := r.db.QueryRowContext(ctx, query, recipient, body).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to enqueue outbound message: %w", err)
+	}
+
+	return id, nil
+}
+
+// ClaimNext locks the oldest sendable message, or returns nil if there is none.
+// A recipient's message is only sendable when nothing older for them is still
+// queued or sending, and nothing was sent to them within recipientInterval,
+// so each chat gets replies in order and at a bounded rate.
+func (r *OutboundMessageRepository) ClaimNext(ctx context.Context, recipientInterval time.Duration) (*domain.OutboundMessage, error) {
+	query := `
+		UPDATE outbound_messages
+		SET status = 'SENDING', attempts = attempts + 1, locked_at = NOW()
+		WHERE id = (
+			SELECT m.id FROM outbound_messages m
+			WHERE m.status = 'QUEUED' AND m.run_at <= NOW()
+			  AND NOT EXISTS (
+				SELECT 1 FROM outbound_messages o
+				WHERE o.recipient = m.recipient AND o.id <> m.id
+				  AND (o.status = 'SENDING'
+				       OR (o.status = 'QUEUED' AND o.id < m.id)
+				       OR o.sent_at > NOW() - INTERVAL '1 millisecond' * $1)
+			  )
+			ORDER BY m.id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, recipient, body, status, attempts, COALESCE(last_error, ''), COALESCE(gowa_message_id, ''), run_at, created_at
+	`
+
+	msg := &domain.OutboundMessage{}
+	err := r.db.QueryRowContext(ctx, query, recipientInterval.Milliseconds()).Scan(
+		&msg.ID, &msg.Recipient, &msg.Body, &msg.Status, &msg.Attempts,
+		&msg.LastError, &msg.GowaMessageID, &msg.RunAt, &msg.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbound message: %w", err)
+	}
+
+	return msg, nil
+}
+
+// MarkSent records a successful send and the message ID GOWA assigned
+func (r *OutboundMessageRepository) MarkSent(ctx context.Context, id int64, gowaMessageID string) error {
+	query := `
+		UPDATE outbound_messages
+		SET status = 'SENT', gowa_message_id = NULLIF($1, ''), sent_at = NOW(), locked_at = NULL, last_error = NULL
+		WHERE id = $2
+	`
+	_, err := r.db.ExecContext(ctx, query, gowaMessageID, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbound message sent: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry puts a failed message back in the queue to be sent again at runAt
+func (r *OutboundMessageRepository) MarkRetry(ctx context.Context, id int64, lastError string, runAt time.Time) error {
+	query := `UPDATE outbound_messages SET status = 'QUEUED', locked_at = NULL, last_error = $1, run_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, lastError, runAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbound message for retry: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed gives up on a message
+func (r *OutboundMessageRepository) MarkFailed(ctx context.Context, id int64, lastError string) error {
+	query := `UPDATE outbound_messages SET status = 'FAILED', locked_at = NULL, last_error = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, lastError, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbound message failed: %w", err)
+	}
+	return nil
+}
+
+// UpdateReceipt applies a GOWA delivery or read receipt. Statuses only move
+// forward, so a late "delivered" never overwrites "read". Returns false if no
+// tracked message has that GOWA ID.
+func (r *OutboundMessageRepository) UpdateReceipt(ctx context.Context, gowaMessageID, status string) (bool, error) {
+	var query string
+	switch status {
+	case domain.OutboundDelivered:
+		query = `
+			UPDATE outbound_messages
+			SET status = CASE WHEN status = 'SENT' THEN 'DELIVERED' ELSE status END,
+			    delivered_at = COALESCE(delivered_at, NOW())
+			WHERE gowa_message_id = $1
+		`
+	case domain.OutboundRead:
+		query = `
+			UPDATE outbound_messages
+			SET status = 'READ',
+			    delivered_at = COALESCE(delivered_at, NOW()),
+			    read_at = COALESCE(read_at, NOW())
+			WHERE gowa_message_id = $1
+		`
+	default:
+		return false, fmt.Errorf("unsupported receipt status: %s", status)
+	}
+
+	result, err := r.db.ExecContext(ctx, query, gowaMessageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update receipt: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// ReclaimStale requeues messages whose sender died mid-send. The message may
+// already have reached the user, so a reclaim can produce a duplicate.
+func (r *OutboundMessageRepository) ReclaimStale(ctx context.Context, lease time.Duration, maxAttempts int) error {
+	query := `
+		UPDATE outbound_messages
+		SET status = CASE WHEN attempts >= $2 THEN 'FAILED' ELSE 'QUEUED' END,
+		    locked_at = NULL,
+		    last_error = 'sender lease expired'
+		WHERE status = 'SENDING' AND locked_at < NOW() - INTERVAL '1 second' * $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, int(lease.Seconds()), maxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to reclaim stale outbound messages: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+type PromptShadowRepository struct {
+	db *sql.DB
+}
+
+func NewPromptShadowRepository(db *sql.DB) *PromptShadowRepository {
+	return &PromptShadowRepository{db: db}
+}
+
+func (r *PromptShadowRepository) Create(ctx context.Context, run *domain.PromptShadowRun) error {
+	query := `
+		INSERT INTO prompt_shadow_runs (user_id, wa_message_id, call_type, primary_version, candidate_version,
+		                                primary_output, candidate_output, candidate_error, agrees)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, NULLIF($8, ''), $9)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		run.UserID, run.WAMessageID, run.CallType, run.PrimaryVersion, run.CandidateVersion,
+		nullJSON(run.PrimaryOutput), nullJSON(run.CandidateOutput), run.CandidateError, run.Agrees,
+	).Scan(&run.ID, &run.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create prompt shadow run: %w", err)
+	}
+
+	return nil
+}
+
+// nullJSON stores empty output as NULL rather than invalid JSON
+func nullJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
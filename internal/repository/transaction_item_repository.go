@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+type TransactionItemRepository struct {
+	db DBTX
+}
+
+func NewTransactionItemRepository(db *sql.DB) *TransactionItemRepository {
+	return &TransactionItemRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs queries inside tx
+func (r *TransactionItemRepository) WithTx(tx *sql.Tx) *TransactionItemRepository {
+	return &TransactionItemRepository{db: tx}
+}
+
+func (r *TransactionItemRepository) Create(ctx context.Context, item *domain.TransactionItem) error {
+	query := `
+		INSERT INTO transaction_items (transaction_id, name, quantity, unit_price, subtotal, discount, tax, category)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		item.TransactionID,
+		item.Name,
+		item.Quantity,
+		item.UnitPrice,
+		item.Subtotal,
+		item.Discount,
+		item.Tax,
+		item.Category,
+	).Scan(&item.ID, &item.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create transaction item: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TransactionItemRepository) GetByTransactionID(ctx context.Context, transactionID int64) ([]*domain.TransactionItem, error) {
+	query := `
+		SELECT id, transaction_id, name, quantity, unit_price, subtotal, discount, tax, COALESCE(category, ''), created_at
+		FROM transaction_items
+		WHERE transaction_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*domain.TransactionItem
+	for rows.Next() {
+		item := &domain.TransactionItem{}
+		err := rows.Scan(
+			&item.ID, &item.TransactionID, &item.Name, &item.Quantity, &item.UnitPrice,
+			&item.Subtotal, &item.Discount, &item.Tax, &item.Category, &item.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// GetCategoryTotals sums item line totals per item category for a user's
+// expenses in a date range, e.g. groceries vs household on a supermarket receipt
+func (r *TransactionItemRepository) GetCategoryTotals(ctx context.Context, userID int64, start, end time.Time) (map[string]float64, error) {
+	query := `
+		SELECT COALESCE(NULLIF(i.category, ''), 'lainnya'), SUM(i.subtotal - i.discount + i.tax)
+		FROM transaction_items i
+		JOIN transactions t ON t.id = i.transaction_id
+		WHERE t.user_id = $1 AND t.is_deleted = false AND t.type = 'EXPENSE'
+		  AND t.transaction_date >= $2 AND t.transaction_date < $3
+		GROUP BY 1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item category totals: %w", err)
+	}
+	defer rows.Close()
+
+	totals := make(map[string]float64)
+	for rows.Next() {
+		var category string
+		var total float64
+		if err := rows.Scan(&category, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan item category total: %w", err)
+		}
+		totals[category] = total
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// ledgerQueryBuilder collects WHERE conditions and their arguments. Every
+// value goes through a placeholder; only fixed fragments from this file are
+// written into the SQL.
+type ledgerQueryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (b *ledgerQueryBuilder) add(condition string, value interface{}) {
+	b.args = append(b.args, value)
+	b.conditions = append(b.conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(b.args))))
+}
+
+// addAny adds one condition that holds when condition holds for any of values
+func (b *ledgerQueryBuilder) addAny(condition string, values []string) {
+	alternatives := make([]string, len(values))
+	for i, value := range values {
+		b.args = append(b.args, value)
+		alternatives[i] = strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(b.args)))
+	}
+	b.conditions = append(b.conditions, "("+strings.Join(alternatives, " OR ")+")")
+}
+
+func (b *ledgerQueryBuilder) where() string {
+	return strings.Join(b.conditions, " AND ")
+}
+
+// escapeLike makes a keyword match literally inside ILIKE '%...%'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ledgerConditions filters the user's transactions by a query plan
+func ledgerConditions(userID int64, q *domain.LedgerQuery) *ledgerQueryBuilder {
+	b := &ledgerQueryBuilder{}
+	b.add("user_id = ?", userID)
+	b.conditions = append(b.conditions, "is_deleted = false")
+	b.add("transaction_date >= ?", q.Start)
+	b.add("transaction_date < ?", q.End)
+	if q.Type != "" {
+		b.add("type = ?", q.Type)
+	}
+	if len(q.Categories) > 0 {
+		lowered := make([]string, len(q.Categories))
+		for i, c := range q.Categories {
+			lowered[i] = strings.ToLower(c)
+		}
+		b.add("LOWER(category) = ANY(?)", pq.Array(lowered))
+	}
+	// Keywords are alternatives, e.g. "coffee" and its translation "kopi"
+	if len(q.Keywords) > 0 {
+		escaped := make([]string, len(q.Keywords))
+		for i, keyword := range q.Keywords {
+			escaped[i] = escapeLike(keyword)
+		}
+		b.addAny("description ILIKE '%' || ? || '%'", escaped)
+	}
+	return b
+}
+
+// Query runs a ledger query plan over the user's transactions
+func (r *TransactionRepository) Query(ctx context.Context, userID int64, q *domain.LedgerQuery) (*domain.LedgerResult, error) {
+	b := ledgerConditions(userID, q)
+
+	limit := q.Limit
+	if limit <= 0 || limit > domain.MaxLedgerQueryLimit {
+		limit = domain.MaxLedgerQueryLimit
+	}
+
+	result := &domain.LedgerResult{}
+
+	// Every aggregation reports the total and count of matching transactions
+	query := `SELECT COALESCE(SUM(amount), 0), COUNT(*) FROM transactions WHERE ` + b.where()
+	if err := r.db.QueryRowContext(ctx, query, b.args...).Scan(&result.Value, &result.Count); err != nil {
+		return nil, fmt.Errorf("failed to query ledger: %w", err)
+	}
+
+	switch q.Aggregation {
+	case domain.AggregateSum, domain.AggregateCount:
+	case domain.AggregateAvg:
+		if result.Count > 0 {
+			result.Value /= float64(result.Count)
+		}
+	case domain.AggregateMax:
+		txs, err := r.queryLargest(ctx, b, limit)
+		if err != nil {
+			return nil, err
+		}
+		result.Transactions = txs
+	case domain.AggregateTop:
+		groups, err := r.queryTopCategories(ctx, b, limit)
+		if err != nil {
+			return nil, err
+		}
+		result.Groups = groups
+	default:
+		return nil, fmt.Errorf("unsupported aggregation: %s", q.Aggregation)
+	}
+
+	return result, nil
+}
+
+func (r *TransactionRepository) queryLargest(ctx context.Context, b *ledgerQueryBuilder, limit int) ([]*domain.Transaction, error) {
+	query := fmt.Sprintf(`
+		SELECT id, tx_id, user_id, type, amount, category, category_id, description, transaction_date,
+		       wa_message_id, ai_confidence, ai_version, is_deleted, created_at, updated_at
+		FROM transactions
+		WHERE %s
+		ORDER BY amount DESC, transaction_date DESC
+		LIMIT %d
+	`, b.where(), limit)
+
+	rows, err := r.db.QueryContext(ctx, query, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query largest transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*domain.Transaction
+	for rows.Next() {
+		tx := &domain.Transaction{}
+		err := rows.Scan(
+			&tx.ID, &tx.TxID, &tx.UserID, &tx.Type, &tx.Amount, &tx.Category, &tx.CategoryID, &tx.Description,
+			&tx.TransactionDate, &tx.WAMessageID, &tx.AIConfidence, &tx.AIVersion,
+			&tx.IsDeleted, &tx.CreatedAt, &tx.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, nil
+}
+
+func (r *TransactionRepository) queryTopCategories(ctx context.Context, b *ledgerQueryBuilder, limit int) ([]*domain.LedgerGroup, error) {
+	query := fmt.Sprintf(`
+		SELECT COALESCE(NULLIF(category, ''), 'lainnya'), SUM(amount) AS total, COUNT(*)
+		FROM transactions
+		WHERE %s
+		GROUP BY 1
+		ORDER BY total DESC
+		LIMIT %d
+	`, b.where(), limit)
+
+	rows, err := r.db.QueryContext(ctx, query, b.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top categories: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []*domain.LedgerGroup
+	for rows.Next() {
+		g := &domain.LedgerGroup{}
+		if err := rows.Scan(&g.Key, &g.Total, &g.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan top category: %w", err)
+		}
+		groups = append(groups, g)
+	}
+
+	return groups, nil
+}
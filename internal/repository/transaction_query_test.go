@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+func TestLedgerConditions(t *testing.T) {
+	start := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	b := ledgerConditions(42, &domain.LedgerQuery{
+		Type:        domain.TypeExpense,
+		Keywords:    []string{"coffee", "kopi", "50%_off"},
+		Start:       start,
+		End:         end,
+		Aggregation: domain.AggregateSum,
+	})
+
+	// Keywords are alternatives grouped into one condition
+	want := "user_id = $1 AND is_deleted = false AND transaction_date >= $2 AND transaction_date < $3 AND type = $4 AND " +
+		"(description ILIKE '%' || $5 || '%' OR description ILIKE '%' || $6 || '%' OR description ILIKE '%' || $7 || '%')"
+	if got := b.where(); got != want {
+		t.Errorf("where =\n%s\nwant\n%s", got, want)
+	}
+
+	wantArgs := []interface{}{int64(42), start, end, domain.TypeExpense, "coffee", "kopi", `50\%\_off`}
+	if !reflect.DeepEqual(b.args, wantArgs) {
+		t.Errorf("args = %v, want %v", b.args, wantArgs)
+	}
+}
+
+func TestLedgerConditionsWithoutKeywords(t *testing.T) {
+	b := ledgerConditions(42, &domain.LedgerQuery{Categories: []string{"Makanan & Minuman"}, Aggregation: domain.AggregateSum})
+
+	want := "user_id = $1 AND is_deleted = false AND transaction_date >= $2 AND transaction_date < $3 AND LOWER(category) = ANY($4)"
+	if got := b.where(); got != want {
+		t.Errorf("where =\n%s\nwant\n%s", got, want)
+	}
+}
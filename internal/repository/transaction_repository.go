@@ -10,17 +10,22 @@ import (
 )
 
 type TransactionRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewTransactionRepository(db *sql.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs queries inside tx
+func (r *TransactionRepository) WithTx(tx *sql.Tx) *TransactionRepository {
+	return &TransactionRepository{db: tx}
+}
+
 func (r *TransactionRepository) Create(ctx context.Context, tx *domain.Transaction) error {
 	query := `
-		INSERT INTO transactions (tx_id, user_id, type, amount, category, description, transaction_date, wa_message_id, ai_confidence, ai_version)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO transactions (tx_id, user_id, type, amount, category, category_id, description, transaction_date, wa_message_id, ai_confidence, ai_version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -30,6 +35,7 @@ func (r *TransactionRepository) Create(ctx context.Context, tx *domain.Transacti
 		tx.Type,
 		tx.Amount,
 		tx.Category,
+		tx.CategoryID,
 		tx.Description,
 		tx.TransactionDate,
 		tx.WAMessageID,
@@ -46,7 +52,7 @@ func (r *TransactionRepository) Create(ctx context.Context, tx *domain.Transacti
 
 func (r *TransactionRepository) GetByID(ctx context.Context, id int64) (*domain.Transaction, error) {
 	query := `
-		SELECT id, tx_id, user_id, type, amount, category, description, transaction_date, 
+		SELECT id, tx_id, user_id, type, amount, category, category_id, description, transaction_date, 
 		       wa_message_id, ai_confidence, ai_version, is_deleted, created_at, updated_at
 		FROM transactions
 		WHERE id = $1
@@ -54,7 +60,7 @@ func (r *TransactionRepository) GetByID(ctx context.Context, id int64) (*domain.
 
 	tx := &domain.Transaction{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&tx.ID, &tx.TxID, &tx.UserID, &tx.Type, &tx.Amount, &tx.Category, &tx.Description,
+		&tx.ID, &tx.TxID, &tx.UserID, &tx.Type, &tx.Amount, &tx.Category, &tx.CategoryID, &tx.Description,
 		&tx.TransactionDate, &tx.WAMessageID, &tx.AIConfidence, &tx.AIVersion,
 		&tx.IsDeleted, &tx.CreatedAt, &tx.UpdatedAt,
 	)
@@ -71,7 +77,7 @@ func (r *TransactionRepository) GetByID(ctx context.Context, id int64) (*domain.
 
 func (r *TransactionRepository) GetByTxID(ctx context.Context, txID string) (*domain.Transaction, error) {
 	query := `
-		SELECT id, tx_id, user_id, type, amount, category, description, transaction_date, 
+		SELECT id, tx_id, user_id, type, amount, category, category_id, description, transaction_date, 
 		       wa_message_id, ai_confidence, ai_version, is_deleted, created_at, updated_at
 		FROM transactions
 		WHERE tx_id = $1
@@ -79,7 +85,7 @@ func (r *TransactionRepository) GetByTxID(ctx context.Context, txID string) (*do
 
 	tx := &domain.Transaction{}
 	err := r.db.QueryRowContext(ctx, query, txID).Scan(
-		&tx.ID, &tx.TxID, &tx.UserID, &tx.Type, &tx.Amount, &tx.Category, &tx.Description,
+		&tx.ID, &tx.TxID, &tx.UserID, &tx.Type, &tx.Amount, &tx.Category, &tx.CategoryID, &tx.Description,
 		&tx.TransactionDate, &tx.WAMessageID, &tx.AIConfidence, &tx.AIVersion,
 		&tx.IsDeleted, &tx.CreatedAt, &tx.UpdatedAt,
 	)
@@ -96,7 +102,7 @@ func (r *TransactionRepository) GetByTxID(ctx context.Context, txID string) (*do
 
 func (r *TransactionRepository) GetLastByUser(ctx context.Context, userID int64) (*domain.Transaction, error) {
 	query := `
-		SELECT id, tx_id, user_id, type, amount, category, description, transaction_date, 
+		SELECT id, tx_id, user_id, type, amount, category, category_id, description, transaction_date, 
 		       wa_message_id, ai_confidence, ai_version, is_deleted, created_at, updated_at
 		FROM transactions
 		WHERE user_id = $1 AND is_deleted = false
@@ -106,7 +112,7 @@ func (r *TransactionRepository) GetLastByUser(ctx context.Context, userID int64)
 
 	tx := &domain.Transaction{}
 	err := r.db.QueryRowContext(ctx, query, userID).Scan(
-		&tx.ID, &tx.TxID, &tx.UserID, &tx.Type, &tx.Amount, &tx.Category, &tx.Description,
+		&tx.ID, &tx.TxID, &tx.UserID, &tx.Type, &tx.Amount, &tx.Category, &tx.CategoryID, &tx.Description,
 		&tx.TransactionDate, &tx.WAMessageID, &tx.AIConfidence, &tx.AIVersion,
 		&tx.IsDeleted, &tx.CreatedAt, &tx.UpdatedAt,
 	)
@@ -121,9 +127,42 @@ func (r *TransactionRepository) GetLastByUser(ctx context.Context, userID int64)
 	return tx, nil
 }
 
+// GetByUserAndMessageID returns all live transactions recorded from one WA message
+func (r *TransactionRepository) GetByUserAndMessageID(ctx context.Context, userID int64, waMessageID string) ([]*domain.Transaction, error) {
+	query := `
+		SELECT id, tx_id, user_id, type, amount, category, category_id, description, transaction_date, 
+		       wa_message_id, ai_confidence, ai_version, is_deleted, created_at, updated_at
+		FROM transactions
+		WHERE user_id = $1 AND wa_message_id = $2 AND is_deleted = false
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, waMessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*domain.Transaction
+	for rows.Next() {
+		tx := &domain.Transaction{}
+		err := rows.Scan(
+			&tx.ID, &tx.TxID, &tx.UserID, &tx.Type, &tx.Amount, &tx.Category, &tx.CategoryID, &tx.Description,
+			&tx.TransactionDate, &tx.WAMessageID, &tx.AIConfidence, &tx.AIVersion,
+			&tx.IsDeleted, &tx.CreatedAt, &tx.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, nil
+}
+
 func (r *TransactionRepository) GetByUserAndDateRange(ctx context.Context, userID int64, start, end time.Time) ([]*domain.Transaction, error) {
 	query := `
-		SELECT id, tx_id, user_id, type, amount, category, description, transaction_date, 
+		SELECT id, tx_id, user_id, type, amount, category, category_id, description, transaction_date, 
 		       wa_message_id, ai_confidence, ai_version, is_deleted, created_at, updated_at
 		FROM transactions
 		WHERE user_id = $1 AND is_deleted = false 
@@ -141,7 +180,7 @@ func (r *TransactionRepository) GetByUserAndDateRange(ctx context.Context, userI
 	for rows.Next() {
 		tx := &domain.Transaction{}
 		err := rows.Scan(
-			&tx.ID, &tx.TxID, &tx.UserID, &tx.Type, &tx.Amount, &tx.Category, &tx.Description,
+			&tx.ID, &tx.TxID, &tx.UserID, &tx.Type, &tx.Amount, &tx.Category, &tx.CategoryID, &tx.Description,
 			&tx.TransactionDate, &tx.WAMessageID, &tx.AIConfidence, &tx.AIVersion,
 			&tx.IsDeleted, &tx.CreatedAt, &tx.UpdatedAt,
 		)
@@ -157,12 +196,12 @@ func (r *TransactionRepository) GetByUserAndDateRange(ctx context.Context, userI
 func (r *TransactionRepository) Update(ctx context.Context, tx *domain.Transaction) error {
 	query := `
 		UPDATE transactions
-		SET type = $1, amount = $2, category = $3, description = $4, transaction_date = $5
-		WHERE id = $6
+		SET type = $1, amount = $2, category = $3, category_id = $4, description = $5, transaction_date = $6
+		WHERE id = $7
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
-		tx.Type, tx.Amount, tx.Category, tx.Description, tx.TransactionDate, tx.ID,
+		tx.Type, tx.Amount, tx.Category, tx.CategoryID, tx.Description, tx.TransactionDate, tx.ID,
 	)
 
 	if err != nil {
@@ -9,16 +9,21 @@ import (
 )
 
 type UserRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs queries inside tx
+func (r *UserRepository) WithTx(tx *sql.Tx) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 func (r *UserRepository) GetByMSISDN(ctx context.Context, msisdn string) (*domain.User, error) {
 	query := `
-		SELECT id, msisdn, plan, free_tx_count, premium_until, is_blocked, created_at, updated_at
+		SELECT id, msisdn, plan, free_tx_count, premium_until, is_blocked, language, created_at, updated_at
 		FROM users
 		WHERE msisdn = $1
 	`
@@ -31,6 +36,37 @@ func (r *UserRepository) GetByMSISDN(ctx context.Context, msisdn string) (*domai
 		&user.FreeTxCount,
 		&user.PremiumUntil,
 		&user.IsBlocked,
+		&user.Language,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	query := `
+		SELECT id, msisdn, plan, free_tx_count, premium_until, is_blocked, language, created_at, updated_at
+		FROM users
+		WHERE id = $1
+	`
+
+	user := &domain.User{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.MSISDN,
+		&user.Plan,
+		&user.FreeTxCount,
+		&user.PremiumUntil,
+		&user.IsBlocked,
+		&user.Language,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -47,13 +83,14 @@ func (r *UserRepository) GetByMSISDN(ctx context.Context, msisdn string) (*domai
 
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (msisdn, plan, free_tx_count)
-		VALUES ($1, $2, $3)
-		RETURNING id, created_at, updated_at
+		INSERT INTO users (msisdn, plan, free_tx_count, language)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'id'))
+		RETURNING id, language, created_at, updated_at
 	`
 
-	err := r.db.QueryRowContext(ctx, query, user.MSISDN, user.Plan, user.FreeTxCount).Scan(
+	err := r.db.QueryRowContext(ctx, query, user.MSISDN, user.Plan, user.FreeTxCount, user.Language).Scan(
 		&user.ID,
+		&user.Language,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -87,18 +124,38 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
-func (r *UserRepository) IncrementFreeTxCount(ctx context.Context, userID int64) error {
-	query := `UPDATE users SET free_tx_count = free_tx_count + 1 WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, userID)
+// UpdateLanguage sets the language the bot replies to the user in
+func (r *UserRepository) UpdateLanguage(ctx context.Context, userID int64, language string) error {
+	query := `UPDATE users SET language = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, language, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update language: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepository) IncrementFreeTxCount(ctx context.Context, userID int64, n int) error {
+	query := `UPDATE users SET free_tx_count = free_tx_count + $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, n, userID)
 	if err != nil {
 		return fmt.Errorf("failed to increment tx count: %w", err)
 	}
 	return nil
 }
 
+// DecrementFreeTxCount gives back n free transactions, never going below zero
+func (r *UserRepository) DecrementFreeTxCount(ctx context.Context, userID int64, n int) error {
+	query := `UPDATE users SET free_tx_count = GREATEST(free_tx_count - $1, 0) WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, n, userID)
+	if err != nil {
+		return fmt.Errorf("failed to decrement tx count: %w", err)
+	}
+	return nil
+}
+
 func (r *UserRepository) GetAll(ctx context.Context) ([]*domain.User, error) {
 	query := `
-		SELECT id, msisdn, plan, free_tx_count, premium_until, is_blocked, created_at, updated_at
+		SELECT id, msisdn, plan, free_tx_count, premium_until, is_blocked, language, created_at, updated_at
 		FROM users
 		ORDER BY created_at DESC
 	`
@@ -119,6 +176,7 @@ func (r *UserRepository) GetAll(ctx context.Context) ([]*domain.User, error) {
 			&user.FreeTxCount,
 			&user.PremiumUntil,
 			&user.IsBlocked,
+			&user.Language,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
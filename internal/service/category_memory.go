@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+// maxMemoryKeywordWords keeps keywords to the start of a merchant or item
+// name, so "kopi susu" and "kopi susu gula aren" share one memory
+const maxMemoryKeywordWords = 2
+
+// memoryScanLimit bounds how many memories are matched per parse
+const memoryScanLimit = 500
+
+var (
+	memoryWordPattern = regexp.MustCompile(`\p{L}+`)
+
+	// Verbs, fillers and amount words that say nothing about the merchant
+	memoryStopwords = map[string]bool{
+		"beli": true, "bayar": true, "belanja": true, "isi": true, "topup": true, "catat": true,
+		"pengeluaran": true, "pemasukan": true, "dapat": true, "dapet": true, "terima": true,
+		"di": true, "ke": true, "dari": true, "untuk": true, "buat": true, "dan": true,
+		"yang": true, "sama": true, "tadi": true, "rp": true, "rb": true, "ribu": true,
+		"k": true, "jt": true, "juta": true,
+		"buy": true, "bought": true, "pay": true, "paid": true, "at": true, "for": true, "the": true,
+		"tuku": true, "mbayar": true, "ewu": true, "yuta": true,
+	}
+)
+
+// MemoryKeyword reduces a transaction description to the words that identify
+// the merchant or item: "beli kopi kenangan 25rb" → "kopi kenangan"
+func MemoryKeyword(description string) string {
+	words := memoryWords(description)
+	if len(words) > maxMemoryKeywordWords {
+		words = words[:maxMemoryKeywordWords]
+	}
+	return strings.Join(words, " ")
+}
+
+func memoryWords(text string) []string {
+	var words []string
+	for _, word := range memoryWordPattern.FindAllString(strings.ToLower(text), -1) {
+		if !memoryStopwords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// LearnCorrection remembers that the user filed description under the
+// category categoryID, for the next transaction with the same keyword
+func (s *CategoryService) LearnCorrection(ctx context.Context, userID int64, description string, categoryID int64) error {
+	keyword := MemoryKeyword(description)
+	if keyword == "" {
+		return nil
+	}
+
+	categories, err := s.Categories(ctx, userID)
+	if err != nil {
+		return err
+	}
+	var category *domain.Category
+	for _, c := range categories {
+		if c.ID == categoryID {
+			category = c
+			break
+		}
+	}
+	if category == nil {
+		return fmt.Errorf("category %d not found", categoryID)
+	}
+
+	return s.memoryRepo.Upsert(ctx, &domain.CategoryMemory{
+		UserID:   userID,
+		Keyword:  keyword,
+		Category: category.Name,
+		Type:     category.Type,
+	})
+}
+
+// ApplyMemory overrides parsed categories with the ones the user taught us
+func (s *CategoryService) ApplyMemory(ctx context.Context, userID int64, parsed []*domain.ParsedTransaction) error {
+	memories, err := s.memoryRepo.ListByUser(ctx, userID, memoryScanLimit)
+	if err != nil || len(memories) == 0 {
+		return err
+	}
+
+	for _, p := range parsed {
+		if m := matchMemory(memories, p.Description, p.Type); m != nil {
+			p.Category = m.Category
+			p.CategoryID = 0
+		}
+	}
+	return nil
+}
+
+// matchMemory finds the memory for a description. The longest keyword found
+// in the description wins; ties go to the memory confirmed most often, as
+// memories are ordered by hits. A one-word keyword ("kopi") only matches a
+// one-word description, so it doesn't capture "biji kopi" or "kopi kenangan".
+func matchMemory(memories []*domain.CategoryMemory, description, txType string) *domain.CategoryMemory {
+	words := memoryWords(description)
+
+	var best *domain.CategoryMemory
+	bestLen := 0
+	for _, m := range memories {
+		keyword := strings.Fields(m.Keyword)
+		if m.Type != txType || len(keyword) <= bestLen {
+			continue
+		}
+		if len(keyword) == 1 && len(words) != 1 {
+			continue
+		}
+		if !containsPhrase(words, keyword) {
+			continue
+		}
+		best, bestLen = m, len(keyword)
+	}
+	return best
+}
+
+// CorrectionExamples returns the user's strongest memories for use as
+// few-shot examples
+func (s *CategoryService) CorrectionExamples(ctx context.Context, userID int64, limit int) ([]*domain.CategoryMemory, error) {
+	return s.memoryRepo.ListByUser(ctx, userID, limit)
+}
+
+// containsPhrase reports whether phrase occurs as consecutive words in words
+func containsPhrase(words, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		match := true
+		for j, word := range phrase {
+			if words[i+j] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/repository"
+	"github.com/nicolaananda/catatuang/internal/testutil"
+)
+
+func TestMemoryKeyword(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"beli kopi kenangan 25rb", "kopi kenangan"},
+		{"beli kopi susu", "kopi susu"},
+		{"beli kopi susu gula aren", "kopi susu"},
+		{"Bayar di Indomaret Point", "indomaret point"},
+		{"paid for lunch at the mall", "lunch mall"},
+		{"beli kopi", "kopi"},
+		{"beli 20rb", ""},
+	}
+
+	for _, tt := range tests {
+		if got := MemoryKeyword(tt.description); got != tt.want {
+			t.Errorf("MemoryKeyword(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestMatchMemory(t *testing.T) {
+	memories := []*domain.CategoryMemory{
+		{Keyword: "kopi susu", Category: "Kopi & Jajan", Type: domain.TypeExpense, Hits: 3},
+		{Keyword: "kopi", Category: "Makanan & Minuman", Type: domain.TypeExpense, Hits: 2},
+		{Keyword: "indomaret point", Category: "Bahan Makanan", Type: domain.TypeExpense, Hits: 1},
+		{Keyword: "indomaret", Category: "Belanja", Type: domain.TypeExpense, Hits: 1},
+	}
+
+	tests := []struct {
+		description string
+		txType      string
+		want        string
+	}{
+		{"kopi susu", domain.TypeExpense, "Kopi & Jajan"},
+		{"beli kopi susu gula aren", domain.TypeExpense, "Kopi & Jajan"},
+		{"es kopi susu tetangga", domain.TypeExpense, "Kopi & Jajan"},
+		{"beli kopi", domain.TypeExpense, "Makanan & Minuman"},
+		{"belanja di indomaret point", domain.TypeExpense, "Bahan Makanan"},
+		{"indomaret", domain.TypeExpense, "Belanja"},
+		// One-word memories don't spread to longer descriptions
+		{"biji kopi 1kg", domain.TypeExpense, ""},
+		{"kopi kenangan", domain.TypeExpense, ""},
+		{"indomaret dekat rumah", domain.TypeExpense, ""},
+		{"bensin", domain.TypeExpense, ""},
+		{"kopi susu", domain.TypeIncome, ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if m := matchMemory(memories, tt.description, tt.txType); m != nil {
+			got = m.Category
+		}
+		if got != tt.want {
+			t.Errorf("matchMemory(%q, %s) = %q, want %q", tt.description, tt.txType, got, tt.want)
+		}
+	}
+}
+
+func TestEditTeachesCategory(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+
+	userRepo := repository.NewUserRepository(db)
+	txRepo := repository.NewTransactionRepository(db)
+	itemRepo := repository.NewTransactionItemRepository(db)
+	categories := NewCategoryService(repository.NewCategoryRepository(db), repository.NewCategoryMemoryRepository(db), db)
+	txService := NewTransactionService(txRepo, itemRepo, userRepo, repository.NewAuditRepository(db), categories, db)
+
+	user, _, err := NewUserService(userRepo).GetOrCreateUser(ctx, "6281234567890")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	belanja, err := categories.Resolve(ctx, user.ID, "Belanja", domain.TypeExpense)
+	if err != nil {
+		t.Fatalf("Resolve(Belanja): %v", err)
+	}
+	jajan, err := categories.Resolve(ctx, user.ID, "Kopi & Jajan", domain.TypeExpense)
+	if err != nil {
+		t.Fatalf("Resolve(Kopi & Jajan): %v", err)
+	}
+
+	txs, err := txService.RecordTransactions(ctx, user, []*domain.ParsedTransaction{{
+		Type:        domain.TypeExpense,
+		Amount:      25000,
+		Category:    belanja.Name,
+		CategoryID:  belanja.ID,
+		Description: "beli kopi susu gula aren",
+		Date:        time.Now(),
+		Confidence:  0.9,
+	}}, "3EB0MEMORY0001", "test", 10)
+	if err != nil {
+		t.Fatalf("RecordTransactions: %v", err)
+	}
+
+	// Editing something other than the category teaches nothing
+	if _, err := txService.EditTransaction(ctx, txs[0].TxID, map[string]interface{}{"amount": 27000.0}); err != nil {
+		t.Fatalf("EditTransaction(amount): %v", err)
+	}
+	parsed := []*domain.ParsedTransaction{{Type: domain.TypeExpense, Description: "kopi susu", Category: "makan"}}
+	if err := categories.ApplyMemory(ctx, user.ID, parsed); err != nil {
+		t.Fatalf("ApplyMemory: %v", err)
+	}
+	if parsed[0].Category != "makan" {
+		t.Fatalf("category after amount edit = %q, want unchanged", parsed[0].Category)
+	}
+
+	if _, err := txService.EditTransaction(ctx, txs[0].TxID, map[string]interface{}{"category": jajan.Name, "category_id": jajan.ID}); err != nil {
+		t.Fatalf("EditTransaction(category): %v", err)
+	}
+
+	parsed = []*domain.ParsedTransaction{
+		{Type: domain.TypeExpense, Description: "beli kopi susu", Category: "makan", CategoryID: 1},
+		{Type: domain.TypeExpense, Description: "es kopi susu tetangga", Category: "makan"},
+		{Type: domain.TypeExpense, Description: "beli bensin", Category: "transport"},
+		{Type: domain.TypeExpense, Description: "beli kopi kenangan", Category: "makan"},
+	}
+	if err := categories.ApplyMemory(ctx, user.ID, parsed); err != nil {
+		t.Fatalf("ApplyMemory: %v", err)
+	}
+
+	want := []string{jajan.Name, jajan.Name, "transport", "makan"}
+	for i, p := range parsed {
+		if p.Category != want[i] {
+			t.Errorf("%q category = %q, want %q", p.Description, p.Category, want[i])
+		}
+	}
+	if parsed[0].CategoryID != 0 {
+		t.Errorf("remembered category kept stale CategoryID %d", parsed[0].CategoryID)
+	}
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/i18n"
+	"github.com/nicolaananda/catatuang/internal/repository"
+)
+
+// Fallback categories for parsed categories that match nothing
+const (
+	fallbackExpenseCategory = "Lainnya"
+	fallbackIncomeCategory  = "Pemasukan Lainnya"
+)
+
+type CategoryService struct {
+	categoryRepo *repository.CategoryRepository
+	memoryRepo   *repository.CategoryMemoryRepository
+	db           *sql.DB
+}
+
+func NewCategoryService(categoryRepo *repository.CategoryRepository, memoryRepo *repository.CategoryMemoryRepository, db *sql.DB) *CategoryService {
+	return &CategoryService{categoryRepo: categoryRepo, memoryRepo: memoryRepo, db: db}
+}
+
+// Categories returns the user's taxonomy: their own copy once they have
+// customised it, the defaults otherwise
+func (s *CategoryService) Categories(ctx context.Context, userID int64) ([]*domain.Category, error) {
+	own, err := s.categoryRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(own) > 0 {
+		return own, nil
+	}
+	return s.categoryRepo.ListDefaults(ctx)
+}
+
+// PromptCategories returns the income and expense category names the parser
+// may choose from
+func (s *CategoryService) PromptCategories(ctx context.Context, userID int64) (income, expense []string, err error) {
+	categories, err := s.Categories(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, c := range categories {
+		name := strings.ToLower(c.Name)
+		if c.Type == domain.TypeIncome {
+			income = append(income, name)
+		} else {
+			expense = append(expense, name)
+		}
+	}
+	return income, expense, nil
+}
+
+// Canonicalize maps each parsed category to the user's taxonomy, replacing
+// the free text with the canonical name and setting CategoryID
+func (s *CategoryService) Canonicalize(ctx context.Context, userID int64, parsed []*domain.ParsedTransaction) error {
+	categories, err := s.Categories(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range parsed {
+		if c := resolveCategory(categories, p.Category, p.Type); c != nil {
+			p.Category = c.Name
+			p.CategoryID = c.ID
+		}
+	}
+	return nil
+}
+
+// Resolve finds the user's category for a name, falling back to the
+// catch-all category of txType
+func (s *CategoryService) Resolve(ctx context.Context, userID int64, name, txType string) (*domain.Category, error) {
+	categories, err := s.Categories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := resolveCategory(categories, name, txType)
+	if c == nil {
+		return nil, fmt.Errorf("category not found: %s", name)
+	}
+	return c, nil
+}
+
+// resolveCategory matches name against category names and synonyms,
+// preferring categories of txType, then tries each word of name, then falls
+// back to the catch-all category
+func resolveCategory(categories []*domain.Category, name, txType string) *domain.Category {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	if c := matchCategory(categories, name, txType); c != nil {
+		return c
+	}
+	// "makan siang" → "makan"
+	for _, word := range strings.Fields(name) {
+		if c := matchCategory(categories, word, txType); c != nil {
+			return c
+		}
+	}
+
+	fallback := fallbackExpenseCategory
+	if txType == domain.TypeIncome {
+		fallback = fallbackIncomeCategory
+	}
+	return findCategory(categories, fallback)
+}
+
+func matchCategory(categories []*domain.Category, name, txType string) *domain.Category {
+	if name == "" {
+		return nil
+	}
+
+	var other *domain.Category
+	for _, c := range categories {
+		if !categoryMatches(c, name) {
+			continue
+		}
+		if txType == "" || c.Type == txType {
+			return c
+		}
+		if other == nil {
+			other = c
+		}
+	}
+	return other
+}
+
+func categoryMatches(c *domain.Category, name string) bool {
+	if strings.EqualFold(c.Name, name) {
+		return true
+	}
+	for _, synonym := range c.Synonyms {
+		if synonym == name {
+			return true
+		}
+	}
+	return false
+}
+
+// findCategory looks a category up by exact name (case-insensitive)
+func findCategory(categories []*domain.Category, name string) *domain.Category {
+	for _, c := range categories {
+		if strings.EqualFold(c.Name, strings.TrimSpace(name)) {
+			return c
+		}
+	}
+	return nil
+}
+
+// AddCategory creates a category for the user. parentName is optional; the
+// new category inherits the parent's type when txType is empty.
+func (s *CategoryService) AddCategory(ctx context.Context, userID int64, name, txType, parentName string) (*domain.Category, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("category name is empty")
+	}
+
+	var created *domain.Category
+	err := s.modify(ctx, userID, func(repo *repository.CategoryRepository, categories []*domain.Category) error {
+		if findCategory(categories, name) != nil {
+			return fmt.Errorf("category already exists: %s", name)
+		}
+
+		c := &domain.Category{UserID: &userID, Name: name, Type: txType}
+		if parentName != "" {
+			parent := findCategory(categories, parentName)
+			if parent == nil {
+				return fmt.Errorf("category not found: %s", parentName)
+			}
+			if c.Type == "" {
+				c.Type = parent.Type
+			}
+			if c.Type != parent.Type {
+				return fmt.Errorf("category type mismatch: %s", parentName)
+			}
+			c.ParentID = &parent.ID
+		}
+		if c.Type == "" {
+			c.Type = domain.TypeExpense
+		}
+
+		if err := repo.Create(ctx, c); err != nil {
+			return err
+		}
+		created = c
+		return nil
+	})
+	return created, err
+}
+
+// RenameCategory renames one of the user's categories. The old name stays a
+// synonym so the parser keeps mapping it.
+func (s *CategoryService) RenameCategory(ctx context.Context, userID int64, oldName, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("category name is empty")
+	}
+
+	return s.modify(ctx, userID, func(repo *repository.CategoryRepository, categories []*domain.Category) error {
+		c := findCategory(categories, oldName)
+		if c == nil {
+			return fmt.Errorf("category not found: %s", oldName)
+		}
+		if existing := findCategory(categories, newName); existing != nil && existing.ID != c.ID {
+			return fmt.Errorf("category already exists: %s", newName)
+		}
+		return repo.Rename(ctx, c.ID, newName)
+	})
+}
+
+// MergeCategories moves everything in fromName into toName and deletes fromName
+func (s *CategoryService) MergeCategories(ctx context.Context, userID int64, fromName, toName string) error {
+	return s.modify(ctx, userID, func(repo *repository.CategoryRepository, categories []*domain.Category) error {
+		from := findCategory(categories, fromName)
+		if from == nil {
+			return fmt.Errorf("category not found: %s", fromName)
+		}
+		to := findCategory(categories, toName)
+		if to == nil {
+			return fmt.Errorf("category not found: %s", toName)
+		}
+		if from.ID == to.ID {
+			return fmt.Errorf("cannot merge a category into itself")
+		}
+		if from.Type != to.Type {
+			return fmt.Errorf("category type mismatch: %s", toName)
+		}
+		return repo.Merge(ctx, from.ID, to.ID)
+	})
+}
+
+// modify runs fn on the user's own taxonomy inside a database transaction,
+// copying the defaults first if the user hasn't customised anything yet
+func (s *CategoryService) modify(ctx context.Context, userID int64, fn func(*repository.CategoryRepository, []*domain.Category) error) error {
+	dbTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	repo := s.categoryRepo.WithTx(dbTx)
+
+	categories, err := repo.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(categories) == 0 {
+		if categories, err = repo.CopyDefaults(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := fn(repo, categories); err != nil {
+		return err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// FormatCategories renders the taxonomy as an indented list for chat in lang
+func FormatCategories(lang string, categories []*domain.Category) string {
+	children := make(map[int64][]*domain.Category)
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	var sb strings.Builder
+	for _, txType := range []string{domain.TypeExpense, domain.TypeIncome} {
+		sb.WriteString(i18n.T(lang, "category.section."+txType) + "\n")
+		for _, c := range categories {
+			if c.ParentID != nil || c.Type != txType {
+				continue
+			}
+			sb.WriteString("• " + c.Name + "\n")
+			for _, child := range children[c.ID] {
+				sb.WriteString("   ◦ " + child.Name + "\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// ExpandQueryCategories maps category names from a query plan to the user's
+// canonical names, including the subcategories of parents. Names that match
+// nothing are kept as they are.
+func (s *CategoryService) ExpandQueryCategories(ctx context.Context, userID int64, names []string, txType string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	categories, err := s.Categories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var expanded []string
+	for _, name := range names {
+		c := matchCategory(categories, strings.ToLower(strings.TrimSpace(name)), txType)
+		if c == nil {
+			expanded = append(expanded, name)
+			continue
+		}
+		expanded = append(expanded, c.Name)
+		for _, child := range categories {
+			if child.ParentID != nil && *child.ParentID == c.ID {
+				expanded = append(expanded, child.Name)
+			}
+		}
+	}
+	return expanded, nil
+}
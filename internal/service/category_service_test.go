@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/repository"
+	"github.com/nicolaananda/catatuang/internal/testutil"
+)
+
+func testTaxonomy() []*domain.Category {
+	food := int64(1)
+	return []*domain.Category{
+		{ID: 1, Name: "Makanan & Minuman", Type: domain.TypeExpense, Synonyms: []string{"makan", "makan siang"}},
+		{ID: 2, ParentID: &food, Name: "Kopi & Jajan", Type: domain.TypeExpense, Synonyms: []string{"kopi", "jajan"}},
+		{ID: 3, Name: "Transportasi", Type: domain.TypeExpense, Synonyms: []string{"bensin", "transport"}},
+		{ID: 4, Name: "Lainnya", Type: domain.TypeExpense},
+		{ID: 5, Name: "Gaji", Type: domain.TypeIncome, Synonyms: []string{"gajian"}},
+		{ID: 6, Name: "Bonus", Type: domain.TypeIncome, Synonyms: []string{"thr", "kopi"}},
+		{ID: 7, Name: "Pemasukan Lainnya", Type: domain.TypeIncome},
+	}
+}
+
+func TestResolveCategory(t *testing.T) {
+	categories := testTaxonomy()
+
+	tests := []struct {
+		name   string
+		txType string
+		want   string
+	}{
+		{"Makanan & Minuman", domain.TypeExpense, "Makanan & Minuman"},
+		{"makanan & minuman", domain.TypeExpense, "Makanan & Minuman"},
+		{"makan siang", domain.TypeExpense, "Makanan & Minuman"},
+		{" Bensin ", domain.TypeExpense, "Transportasi"},
+		// Each word is tried when the whole name matches nothing
+		{"makan malam", domain.TypeExpense, "Makanan & Minuman"},
+		// Synonyms of the transaction's type win
+		{"kopi", domain.TypeExpense, "Kopi & Jajan"},
+		{"kopi", domain.TypeIncome, "Bonus"},
+		// A category of the other type beats the fallback
+		{"gaji", domain.TypeExpense, "Gaji"},
+		{"hiburan", domain.TypeExpense, "Lainnya"},
+		{"", domain.TypeExpense, "Lainnya"},
+		{"warisan", domain.TypeIncome, "Pemasukan Lainnya"},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if c := resolveCategory(categories, tt.name, tt.txType); c != nil {
+			got = c.Name
+		}
+		if got != tt.want {
+			t.Errorf("resolveCategory(%q, %s) = %q, want %q", tt.name, tt.txType, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCategories(t *testing.T) {
+	got := FormatCategories("en", testTaxonomy())
+
+	want := strings.Join([]string{
+		"💸 *Expenses*",
+		"• Makanan & Minuman",
+		"   ◦ Kopi & Jajan",
+		"• Transportasi",
+		"• Lainnya",
+		"",
+		"💰 *Income*",
+		"• Gaji",
+		"• Bonus",
+		"• Pemasukan Lainnya",
+	}, "\n")
+	if got != want {
+		t.Errorf("FormatCategories =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCustomCategories(t *testing.T) {
+	db := testutil.DB(t)
+	ctx := context.Background()
+
+	userRepo := repository.NewUserRepository(db)
+	categories := NewCategoryService(repository.NewCategoryRepository(db), repository.NewCategoryMemoryRepository(db), db)
+	users := NewUserService(userRepo)
+
+	user, _, err := users.GetOrCreateUser(ctx, "6281234567890")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	other, _, err := users.GetOrCreateUser(ctx, "6289876543210")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	defaults, err := categories.Categories(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Categories: %v", err)
+	}
+
+	// The first change copies the defaults for the user
+	created, err := categories.AddCategory(ctx, user.ID, "Kucing", "", "Makanan & Minuman")
+	if err != nil {
+		t.Fatalf("AddCategory: %v", err)
+	}
+	if created.Type != domain.TypeExpense || created.ParentID == nil {
+		t.Errorf("Kucing = %s with parent %v, want an EXPENSE subcategory", created.Type, created.ParentID)
+	}
+	own, err := categories.Categories(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Categories: %v", err)
+	}
+	if len(own) != len(defaults)+1 || own[0].UserID == nil || *own[0].UserID != user.ID {
+		t.Errorf("user has %d categories, want their own copy of %d defaults plus Kucing", len(own), len(defaults))
+	}
+
+	if _, err := categories.AddCategory(ctx, user.ID, "kucing", "", ""); err == nil {
+		t.Error("AddCategory accepted a duplicate name")
+	}
+	if _, err := categories.AddCategory(ctx, user.ID, "Hadiah", domain.TypeIncome, "Makanan & Minuman"); err == nil {
+		t.Error("AddCategory accepted an INCOME child of an EXPENSE parent")
+	}
+
+	// Renamed categories keep answering to their old name
+	if err := categories.RenameCategory(ctx, user.ID, "Kucing", "Peliharaan"); err != nil {
+		t.Fatalf("RenameCategory: %v", err)
+	}
+	if c, err := categories.Resolve(ctx, user.ID, "kucing", domain.TypeExpense); err != nil || c.Name != "Peliharaan" {
+		t.Errorf("Resolve(kucing) = %v, %v, want Peliharaan", c, err)
+	}
+
+	if err := categories.MergeCategories(ctx, user.ID, "Peliharaan", "Gaji"); err == nil {
+		t.Error("MergeCategories merged an EXPENSE category into an INCOME one")
+	}
+	if err := categories.MergeCategories(ctx, user.ID, "Peliharaan", "Lainnya"); err != nil {
+		t.Fatalf("MergeCategories: %v", err)
+	}
+	if c, err := categories.Resolve(ctx, user.ID, "Peliharaan", domain.TypeExpense); err != nil || c.Name != "Lainnya" {
+		t.Errorf("Resolve(Peliharaan) after merge = %v, %v, want Lainnya", c, err)
+	}
+
+	// Other users still see the defaults
+	theirs, err := categories.Categories(ctx, other.ID)
+	if err != nil {
+		t.Fatalf("Categories: %v", err)
+	}
+	if len(theirs) != len(defaults) || theirs[0].UserID != nil {
+		t.Errorf("other user has %d categories, want the %d defaults", len(theirs), len(defaults))
+	}
+}
@@ -3,19 +3,22 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/i18n"
 	"github.com/nicolaananda/catatuang/internal/repository"
 )
 
 type ReportService struct {
-	txRepo *repository.TransactionRepository
+	txRepo   *repository.TransactionRepository
+	itemRepo *repository.TransactionItemRepository
 }
 
-func NewReportService(txRepo *repository.TransactionRepository) *ReportService {
-	return &ReportService{txRepo: txRepo}
+func NewReportService(txRepo *repository.TransactionRepository, itemRepo *repository.TransactionItemRepository) *ReportService {
+	return &ReportService{txRepo: txRepo, itemRepo: itemRepo}
 }
 
 type ReportSummary struct {
@@ -23,7 +26,9 @@ type ReportSummary struct {
 	TotalExpense  float64
 	NetBalance    float64
 	TopCategories map[string]float64
-	Transactions  []*domain.Transaction
+	// ItemCategories breaks itemised receipts down by item category
+	ItemCategories map[string]float64
+	Transactions   []*domain.Transaction
 }
 
 func (s *ReportService) GenerateReport(ctx context.Context, userID int64, start, end time.Time) (*ReportSummary, error) {
@@ -52,32 +57,100 @@ func (s *ReportService) GenerateReport(ctx context.Context, userID int64, start,
 
 	summary.NetBalance = summary.TotalIncome - summary.TotalExpense
 
+	summary.ItemCategories, err = s.itemRepo.GetCategoryTotals(ctx, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item categories: %w", err)
+	}
+
 	return summary, nil
 }
 
-func (s *ReportService) FormatReport(summary *ReportSummary, period string) string {
+// FormatReport renders a summary in lang; period is a report period such as
+// domain.PeriodWeek
+func (s *ReportService) FormatReport(lang string, summary *ReportSummary, period string) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("📊 *Rekap %s*\n\n", period))
-	sb.WriteString(fmt.Sprintf("💰 Pemasukan: Rp %.0f\n", summary.TotalIncome))
-	sb.WriteString(fmt.Sprintf("💸 Pengeluaran: Rp %.0f\n", summary.TotalExpense))
-	sb.WriteString(fmt.Sprintf("📈 Saldo Bersih: Rp %.0f\n\n", summary.NetBalance))
+	sb.WriteString(i18n.T(lang, "report.title", i18n.Args{"Period": i18n.T(lang, "report.period."+period)}))
+	sb.WriteString(i18n.T(lang, "report.income", i18n.Args{"Amount": summary.TotalIncome}))
+	sb.WriteString(i18n.T(lang, "report.expense", i18n.Args{"Amount": summary.TotalExpense}))
+	sb.WriteString(i18n.T(lang, "report.net", i18n.Args{"Amount": summary.NetBalance}))
 
 	if len(summary.TopCategories) > 0 {
-		sb.WriteString("🏷️ *Top Kategori:*\n")
-		for cat, amount := range summary.TopCategories {
-			sb.WriteString(fmt.Sprintf("  • %s: Rp %.0f\n", cat, amount))
+		sb.WriteString(i18n.T(lang, "report.top"))
+		for _, cat := range byAmount(summary.TopCategories) {
+			sb.WriteString(fmt.Sprintf("  • %s: Rp %.0f\n", cat, summary.TopCategories[cat]))
+		}
+	}
+
+	if len(summary.ItemCategories) > 0 {
+		sb.WriteString(i18n.T(lang, "report.receipt_items"))
+		for _, cat := range byAmount(summary.ItemCategories) {
+			sb.WriteString(fmt.Sprintf("  • %s: Rp %.0f\n", cat, summary.ItemCategories[cat]))
 		}
 	}
 
 	if len(summary.Transactions) == 0 {
-		sb.WriteString("\nBelum ada transaksi di periode ini.")
+		sb.WriteString(i18n.T(lang, "report.empty"))
+	}
+
+	return sb.String()
+}
+
+// byAmount returns the categories of totals, largest amount first
+func byAmount(totals map[string]float64) []string {
+	categories := make([]string, 0, len(totals))
+	for cat := range totals {
+		categories = append(categories, cat)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := totals[categories[i]], totals[categories[j]]
+		if a != b {
+			return a > b
+		}
+		return categories[i] < categories[j]
+	})
+	return categories
+}
+
+// FormatItemBreakdown lists the line items of one receipt grouped by item category
+func (s *ReportService) FormatItemBreakdown(lang string, tx *domain.Transaction, items []*domain.TransactionItem) string {
+	var sb strings.Builder
+
+	sb.WriteString(i18n.T(lang, "items.title", i18n.Args{"TxID": tx.TxID, "Description": tx.Description, "Amount": tx.Amount}))
+
+	if len(items) == 0 {
+		sb.WriteString(i18n.T(lang, "items.none"))
+		return sb.String()
+	}
+
+	byCategory := make(map[string][]*domain.TransactionItem)
+	var categories []string
+	for _, item := range items {
+		cat := item.Category
+		if cat == "" {
+			cat = i18n.T(lang, "items.other")
+		}
+		if _, ok := byCategory[cat]; !ok {
+			categories = append(categories, cat)
+		}
+		byCategory[cat] = append(byCategory[cat], item)
+	}
+
+	for _, cat := range categories {
+		var total float64
+		for _, item := range byCategory[cat] {
+			total += item.LineTotal()
+		}
+		sb.WriteString(fmt.Sprintf("\n🏷️ *%s*: Rp %.0f\n", cat, total))
+		for _, item := range byCategory[cat] {
+			sb.WriteString(fmt.Sprintf("  • %s x%g: Rp %.0f\n", item.Name, item.Quantity, item.LineTotal()))
+		}
 	}
 
 	return sb.String()
 }
 
-func (s *ReportService) GetDailyReport(ctx context.Context, userID int64, loc *time.Location) (string, error) {
+func (s *ReportService) GetDailyReport(ctx context.Context, userID int64, loc *time.Location, lang string) (string, error) {
 	now := time.Now().In(loc)
 	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	end := start.Add(24 * time.Hour)
@@ -87,10 +160,10 @@ func (s *ReportService) GetDailyReport(ctx context.Context, userID int64, loc *t
 		return "", err
 	}
 
-	return s.FormatReport(summary, "Hari Ini"), nil
+	return s.FormatReport(lang, summary, domain.PeriodToday), nil
 }
 
-func (s *ReportService) GetWeeklyReport(ctx context.Context, userID int64, loc *time.Location) (string, error) {
+func (s *ReportService) GetWeeklyReport(ctx context.Context, userID int64, loc *time.Location, lang string) (string, error) {
 	now := time.Now().In(loc)
 	// Start of week (Monday)
 	weekday := int(now.Weekday())
@@ -106,10 +179,10 @@ func (s *ReportService) GetWeeklyReport(ctx context.Context, userID int64, loc *
 		return "", err
 	}
 
-	return s.FormatReport(summary, "Minggu Ini"), nil
+	return s.FormatReport(lang, summary, domain.PeriodWeek), nil
 }
 
-func (s *ReportService) GetMonthlyReport(ctx context.Context, userID int64, loc *time.Location) (string, error) {
+func (s *ReportService) GetMonthlyReport(ctx context.Context, userID int64, loc *time.Location, lang string) (string, error) {
 	now := time.Now().In(loc)
 	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
 	end := start.AddDate(0, 1, 0)
@@ -119,5 +192,74 @@ func (s *ReportService) GetMonthlyReport(ctx context.Context, userID int64, loc
 		return "", err
 	}
 
-	return s.FormatReport(summary, "Bulan Ini"), nil
+	return s.FormatReport(lang, summary, domain.PeriodMonth), nil
+}
+
+// QueryLedger runs a question's query plan over the user's transactions
+func (s *ReportService) QueryLedger(ctx context.Context, userID int64, q *domain.LedgerQuery) (*domain.LedgerResult, error) {
+	result, err := s.txRepo.Query(ctx, userID, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger: %w", err)
+	}
+	return result, nil
+}
+
+// FormatLedgerAnswer renders a query result as a chat reply in lang
+func (s *ReportService) FormatLedgerAnswer(lang string, q *domain.LedgerQuery, result *domain.LedgerResult) string {
+	var sb strings.Builder
+
+	subject := i18n.T(lang, "query.subject")
+	if q.Type != "" {
+		subject = i18n.T(lang, "query.subject."+q.Type)
+	}
+	var filters []string
+	filters = append(filters, q.Categories...)
+	filters = append(filters, q.Keywords...)
+	if len(filters) > 0 {
+		subject += " " + strings.Join(filters, ", ")
+	}
+
+	sb.WriteString(fmt.Sprintf("🔎 *%s*\n📅 %s\n\n", subject, formatPeriod(q.Start, q.End)))
+
+	if result.Count == 0 {
+		sb.WriteString(i18n.T(lang, "query.none"))
+		return sb.String()
+	}
+
+	switch q.Aggregation {
+	case domain.AggregateSum:
+		sb.WriteString(i18n.T(lang, "query.sum", i18n.Args{"Value": result.Value, "Count": result.Count}))
+	case domain.AggregateCount:
+		sb.WriteString(i18n.T(lang, "query.count", i18n.Args{"Value": result.Value, "Count": result.Count}))
+	case domain.AggregateAvg:
+		sb.WriteString(i18n.T(lang, "query.avg", i18n.Args{"Value": result.Value, "Count": result.Count}))
+	case domain.AggregateMax:
+		if len(result.Transactions) == 1 {
+			tx := result.Transactions[0]
+			sb.WriteString(i18n.T(lang, "query.max", i18n.Args{
+				"Amount": tx.Amount, "Description": tx.Description, "Category": tx.Category, "Date": tx.TransactionDate.Format("02/01/2006"),
+			}))
+			break
+		}
+		sb.WriteString(i18n.T(lang, "query.max_list"))
+		for i, tx := range result.Transactions {
+			sb.WriteString(fmt.Sprintf("%d. Rp %.0f - %s (%s)\n", i+1, tx.Amount, tx.Description, tx.TransactionDate.Format("02/01")))
+		}
+	case domain.AggregateTop:
+		sb.WriteString(i18n.T(lang, "query.top"))
+		for i, g := range result.Groups {
+			sb.WriteString(i18n.T(lang, "query.top_line", i18n.Args{"Rank": i + 1, "Category": g.Key, "Total": g.Total, "Count": g.Count}))
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// formatPeriod renders [start, end) as inclusive dates
+func formatPeriod(start, end time.Time) string {
+	last := end.AddDate(0, 0, -1)
+	if !last.After(start) {
+		return start.Format("02/01/2006")
+	}
+	return fmt.Sprintf("%s - %s", start.Format("02/01/2006"), last.Format("02/01/2006"))
 }
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+)
+
+func TestFormatItemBreakdown(t *testing.T) {
+	s := NewReportService(nil, nil)
+	tx := &domain.Transaction{TxID: "TX#abcd1234-1700000000", Description: "Indomaret", Amount: 58000}
+
+	got := s.FormatItemBreakdown(domain.LangIndonesian, tx, []*domain.TransactionItem{
+		{Name: "Susu", Quantity: 2, UnitPrice: 15000, Category: "Bahan Makanan"},
+		{Name: "Sabun", Quantity: 1, Subtotal: 5000, Category: "Rumah Tangga"},
+		{Name: "Roti", Quantity: 1, Subtotal: 23000, Category: "Bahan Makanan"},
+		{Name: "Kantong", Quantity: 1, Subtotal: 500, Discount: 500},
+	})
+
+	for _, want := range []string{
+		"*Bahan Makanan*: Rp 53000",
+		"• Susu x2: Rp 30000",
+		"• Roti x1: Rp 23000",
+		"*Rumah Tangga*: Rp 5000",
+		"• Kantong x1: Rp 0",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("breakdown is missing %q:\n%s", want, got)
+		}
+	}
+	// Categories keep the receipt's order
+	if strings.Index(got, "Bahan Makanan") > strings.Index(got, "Rumah Tangga") {
+		t.Errorf("categories out of receipt order:\n%s", got)
+	}
+
+	if got := s.FormatItemBreakdown(domain.LangIndonesian, tx, nil); !strings.Contains(got, "Indomaret") {
+		t.Errorf("breakdown without items = %q", got)
+	}
+}
+
+func TestFormatReportOrdersCategoriesByAmount(t *testing.T) {
+	s := NewReportService(nil, nil)
+	summary := &ReportSummary{
+		TotalExpense:   128000,
+		TopCategories:  map[string]float64{"Transportasi": 50000, "Makanan & Minuman": 78000},
+		ItemCategories: map[string]float64{"Rumah Tangga": 5000, "Bahan Makanan": 53000, "Jajanan": 20000, "Bumbu": 5000},
+		Transactions:   []*domain.Transaction{{}},
+	}
+
+	got := s.FormatReport(domain.LangIndonesian, summary, domain.PeriodWeek)
+
+	// Largest first; equal amounts by name
+	want := []string{
+		"• Makanan & Minuman: Rp 78000", "• Transportasi: Rp 50000",
+		"• Bahan Makanan: Rp 53000", "• Jajanan: Rp 20000", "• Bumbu: Rp 5000", "• Rumah Tangga: Rp 5000",
+	}
+	last := -1
+	for _, line := range want {
+		i := strings.Index(got, line)
+		if i < 0 {
+			t.Errorf("report is missing %q:\n%s", line, got)
+			continue
+		}
+		if i < last {
+			t.Errorf("%q is out of order:\n%s", line, got)
+		}
+		last = i
+	}
+}
+
+func TestFormatLedgerAnswer(t *testing.T) {
+	s := NewReportService(nil, nil)
+	march := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name   string
+		q      *domain.LedgerQuery
+		result *domain.LedgerResult
+		want   []string
+	}{
+		{"sum",
+			&domain.LedgerQuery{Type: domain.TypeExpense, Keywords: []string{"kopi"}, Start: march(1), End: march(19), Aggregation: domain.AggregateSum},
+			&domain.LedgerResult{Value: 75000, Count: 3},
+			[]string{"*Expenses kopi*", "01/03/2026 - 18/03/2026", "Total: *Rp 75000* (3 transactions)"}},
+		{"one day count",
+			&domain.LedgerQuery{Start: march(18), End: march(19), Aggregation: domain.AggregateCount},
+			&domain.LedgerResult{Value: 20000, Count: 1},
+			[]string{"*Transactions*", "📅 18/03/2026\n", "Count: *1 transaction* (total Rp 20000)"}},
+		{"biggest",
+			&domain.LedgerQuery{Type: domain.TypeExpense, Start: march(16), End: march(19), Aggregation: domain.AggregateMax, Limit: 1},
+			&domain.LedgerResult{Count: 1, Transactions: []*domain.Transaction{
+				{Amount: 450000, Description: "servis motor", Category: "Transportasi", TransactionDate: march(17)}}},
+			[]string{"Biggest: *Rp 450000* - servis motor", "Transportasi, 17/03/2026"}},
+		{"top categories",
+			&domain.LedgerQuery{Type: domain.TypeExpense, Start: march(1), End: march(19), Aggregation: domain.AggregateTop, Limit: 2},
+			&domain.LedgerResult{Count: 9, Groups: []*domain.LedgerGroup{
+				{Key: "Makanan & Minuman", Total: 600000, Count: 7}, {Key: "Transportasi", Total: 450000, Count: 2}}},
+			[]string{"1. Makanan & Minuman: Rp 600000 (7 transactions)", "2. Transportasi: Rp 450000 (2 transactions)"}},
+		{"nothing found",
+			&domain.LedgerQuery{Type: domain.TypeIncome, Start: march(1), End: march(19), Aggregation: domain.AggregateSum},
+			&domain.LedgerResult{},
+			[]string{"*Income*", "No matching transactions"}},
+	}
+
+	for _, tt := range tests {
+		got := s.FormatLedgerAnswer(domain.LangEnglish, tt.q, tt.result)
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: answer is missing %q:\n%s", tt.name, want, got)
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/nicolaananda/catatuang/internal/domain"
@@ -12,29 +13,49 @@ import (
 )
 
 type TransactionService struct {
-	txRepo    *repository.TransactionRepository
-	userRepo  *repository.UserRepository
-	auditRepo *repository.AuditRepository
-	db        *sql.DB
+	txRepo     *repository.TransactionRepository
+	itemRepo   *repository.TransactionItemRepository
+	userRepo   *repository.UserRepository
+	auditRepo  *repository.AuditRepository
+	categories *CategoryService
+	db         *sql.DB
 }
 
 func NewTransactionService(
 	txRepo *repository.TransactionRepository,
+	itemRepo *repository.TransactionItemRepository,
 	userRepo *repository.UserRepository,
 	auditRepo *repository.AuditRepository,
+	categories *CategoryService,
 	db *sql.DB,
 ) *TransactionService {
 	return &TransactionService{
-		txRepo:    txRepo,
-		userRepo:  userRepo,
-		auditRepo: auditRepo,
-		db:        db,
+		txRepo:     txRepo,
+		itemRepo:   itemRepo,
+		userRepo:   userRepo,
+		auditRepo:  auditRepo,
+		categories: categories,
+		db:         db,
 	}
 }
 
 func (s *TransactionService) RecordTransaction(ctx context.Context, user *domain.User, parsed *domain.ParsedTransaction, waMessageID, aiVersion string, freeLimit int) (*domain.Transaction, error) {
-	// Check if user can record
-	if !user.CanRecord(freeLimit) {
+	txs, err := s.RecordTransactions(ctx, user, []*domain.ParsedTransaction{parsed}, waMessageID, aiVersion, freeLimit)
+	if err != nil {
+		return nil, err
+	}
+	return txs[0], nil
+}
+
+// RecordTransactions records every transaction parsed from one message
+// atomically: either all of them are saved or none are
+func (s *TransactionService) RecordTransactions(ctx context.Context, user *domain.User, parsed []*domain.ParsedTransaction, waMessageID, aiVersion string, freeLimit int) ([]*domain.Transaction, error) {
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("no transactions to record")
+	}
+
+	// Check if user can record the whole batch
+	if !user.CanRecordCount(freeLimit, len(parsed)) {
 		return nil, fmt.Errorf("free limit exceeded")
 	}
 
@@ -45,109 +66,160 @@ func (s *TransactionService) RecordTransaction(ctx context.Context, user *domain
 	}
 	defer dbTx.Rollback()
 
-	// Create transaction
-	tx := &domain.Transaction{
-		UserID:          user.ID,
-		Type:            parsed.Type,
-		Amount:          parsed.Amount,
-		Category:        parsed.Category,
-		Description:     parsed.Description,
-		TransactionDate: parsed.Date,
-		WAMessageID:     waMessageID,
-		AIConfidence:    parsed.Confidence,
-		AIVersion:       aiVersion,
-	}
+	txRepo := s.txRepo.WithTx(dbTx)
+	itemRepo := s.itemRepo.WithTx(dbTx)
+	userRepo := s.userRepo.WithTx(dbTx)
+
+	// Generate TX IDs BEFORE insert to prevent race conditions
+	txIDs := domain.GenerateTxIDs(waMessageID, len(parsed))
 
-	// Generate TX ID BEFORE insert to prevent race conditions
-	tx.TxID = domain.GenerateTxID(waMessageID)
+	txs := make([]*domain.Transaction, 0, len(parsed))
+	for i, p := range parsed {
+		version := aiVersion
+		if p.AIVersion != "" {
+			version = p.AIVersion
+		}
+		var categoryID *int64
+		if p.CategoryID != 0 {
+			id := p.CategoryID
+			categoryID = &id
+		}
+
+		tx := &domain.Transaction{
+			TxID:            txIDs[i],
+			UserID:          user.ID,
+			Type:            p.Type,
+			Amount:          p.Amount,
+			Category:        p.Category,
+			CategoryID:      categoryID,
+			Description:     p.Description,
+			TransactionDate: p.Date,
+			WAMessageID:     waMessageID,
+			AIConfidence:    p.Confidence,
+			AIVersion:       version,
+		}
+
+		if err := txRepo.Create(ctx, tx); err != nil {
+			return nil, fmt.Errorf("failed to create transaction: %w", err)
+		}
+
+		// Receipt line items
+		for _, item := range p.Items {
+			item.TransactionID = tx.ID
+			if err := itemRepo.Create(ctx, item); err != nil {
+				return nil, fmt.Errorf("failed to create transaction item: %w", err)
+			}
+		}
+		tx.Items = p.Items
 
-	if err := s.txRepo.Create(ctx, tx); err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+		txs = append(txs, tx)
 	}
 
 	// Increment user's free transaction count if not premium
 	if !user.IsPremium() {
-		if err := s.userRepo.IncrementFreeTxCount(ctx, user.ID); err != nil {
+		if err := userRepo.IncrementFreeTxCount(ctx, user.ID, len(txs)); err != nil {
 			return nil, fmt.Errorf("failed to increment count: %w", err)
 		}
 	}
 
-	// Audit log
-	newValue, _ := json.Marshal(tx)
-	if err := s.auditRepo.Log(ctx, user.ID, domain.ActionCreate, "transaction", tx.ID, nil, newValue, "system"); err != nil {
-		// Log but don't fail
-		fmt.Printf("Failed to create audit log: %v\n", err)
-	}
-
 	// Commit transaction
 	if err := dbTx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return tx, nil
+	// Audit log after commit so a failed audit insert can't abort the batch
+	for _, tx := range txs {
+		newValue, _ := json.Marshal(tx)
+		if err := s.auditRepo.Log(ctx, user.ID, domain.ActionCreate, "transaction", tx.ID, nil, newValue, "system"); err != nil {
+			// Log but don't fail
+			log.Printf("Failed to create audit log: %v", err)
+		}
+	}
+
+	return txs, nil
 }
 
-func (s *TransactionService) UndoTransaction(ctx context.Context, userID int64, undoWindowSeconds int) error {
+// UndoTransaction reverts the user's last recorded message: every transaction
+// saved from it is removed, so a multi-transaction message is undone as a whole
+func (s *TransactionService) UndoTransaction(ctx context.Context, userID int64, undoWindowSeconds int) ([]*domain.Transaction, error) {
 	// Get last transaction
-	tx, err := s.txRepo.GetLastByUser(ctx, userID)
+	last, err := s.txRepo.GetLastByUser(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("failed to get last transaction: %w", err)
+		return nil, fmt.Errorf("failed to get last transaction: %w", err)
 	}
-	if tx == nil {
-		return fmt.Errorf("no transaction to undo")
+	if last == nil {
+		return nil, fmt.Errorf("no transaction to undo")
 	}
 
 	// Check if within undo window
-	if time.Since(tx.CreatedAt) > time.Duration(undoWindowSeconds)*time.Second {
-		return fmt.Errorf("undo window expired")
+	if time.Since(last.CreatedAt) > time.Duration(undoWindowSeconds)*time.Second {
+		return nil, fmt.Errorf("undo window expired")
+	}
+
+	// Collect the whole batch recorded from the same message
+	batch := []*domain.Transaction{last}
+	if last.WAMessageID != "" {
+		batch, err = s.txRepo.GetByUserAndMessageID(ctx, userID, last.WAMessageID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transaction batch: %w", err)
+		}
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Start database transaction
 	dbTx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to start transaction: %w", err)
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer dbTx.Rollback()
 
-	// Hard delete the transaction
-	if err := s.txRepo.HardDelete(ctx, tx.ID); err != nil {
-		return fmt.Errorf("failed to delete transaction: %w", err)
-	}
+	txRepo := s.txRepo.WithTx(dbTx)
 
-	// Decrement user's free transaction count
-	user, err := s.userRepo.GetByMSISDN(ctx, "")
-	if err == nil && user != nil && !user.IsPremium() {
-		user.FreeTxCount--
-		if user.FreeTxCount < 0 {
-			user.FreeTxCount = 0
+	// Hard delete the transactions
+	for _, tx := range batch {
+		if err := txRepo.HardDelete(ctx, tx.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete transaction: %w", err)
 		}
-		_ = s.userRepo.Update(ctx, user)
 	}
 
-	// Audit log
-	oldValue, _ := json.Marshal(tx)
-	if err := s.auditRepo.Log(ctx, userID, domain.ActionUndo, "transaction", tx.ID, oldValue, nil, "user"); err != nil {
-		fmt.Printf("Failed to create audit log: %v\n", err)
+	// Give back the user's free transactions
+	if user != nil && !user.IsPremium() {
+		if err := s.userRepo.WithTx(dbTx).DecrementFreeTxCount(ctx, userID, len(batch)); err != nil {
+			return nil, fmt.Errorf("failed to decrement count: %w", err)
+		}
 	}
 
 	// Commit
 	if err := dbTx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit: %w", err)
+		return nil, fmt.Errorf("failed to commit: %w", err)
 	}
 
-	return nil
+	// Audit log
+	for _, tx := range batch {
+		oldValue, _ := json.Marshal(tx)
+		if err := s.auditRepo.Log(ctx, userID, domain.ActionUndo, "transaction", tx.ID, oldValue, nil, "user"); err != nil {
+			log.Printf("Failed to create audit log: %v", err)
+		}
+	}
+
+	return batch, nil
 }
 
-func (s *TransactionService) EditTransaction(ctx context.Context, txID string, updates map[string]interface{}) error {
+func (s *TransactionService) EditTransaction(ctx context.Context, txID string, updates map[string]interface{}) (*domain.Transaction, error) {
 	tx, err := s.txRepo.GetByTxID(ctx, txID)
 	if err != nil {
-		return fmt.Errorf("failed to get transaction: %w", err)
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
 	if tx == nil {
-		return fmt.Errorf("transaction not found")
+		return nil, fmt.Errorf("transaction not found")
 	}
 
 	oldValue, _ := json.Marshal(tx)
+	oldCategoryID := tx.CategoryID
 
 	// Apply updates
 	if amount, ok := updates["amount"].(float64); ok {
@@ -155,6 +227,10 @@ func (s *TransactionService) EditTransaction(ctx context.Context, txID string, u
 	}
 	if category, ok := updates["category"].(string); ok {
 		tx.Category = category
+		tx.CategoryID = nil
+	}
+	if categoryID, ok := updates["category_id"].(int64); ok {
+		tx.CategoryID = &categoryID
 	}
 	if description, ok := updates["description"].(string); ok {
 		tx.Description = description
@@ -162,18 +238,28 @@ func (s *TransactionService) EditTransaction(ctx context.Context, txID string, u
 	if txType, ok := updates["type"].(string); ok {
 		tx.Type = txType
 	}
+	if date, ok := updates["date"].(time.Time); ok {
+		tx.TransactionDate = date
+	}
 
 	if err := s.txRepo.Update(ctx, tx); err != nil {
-		return fmt.Errorf("failed to update transaction: %w", err)
+		return nil, fmt.Errorf("failed to update transaction: %w", err)
 	}
 
 	// Audit log
 	newValue, _ := json.Marshal(tx)
 	if err := s.auditRepo.Log(ctx, tx.UserID, domain.ActionUpdate, "transaction", tx.ID, oldValue, newValue, "user"); err != nil {
-		fmt.Printf("Failed to create audit log: %v\n", err)
+		log.Printf("Failed to create audit log: %v", err)
 	}
 
-	return nil
+	// Category corrections teach the parser for next time
+	if tx.CategoryID != nil && (oldCategoryID == nil || *oldCategoryID != *tx.CategoryID) {
+		if err := s.categories.LearnCorrection(ctx, tx.UserID, tx.Description, *tx.CategoryID); err != nil {
+			log.Printf("Failed to learn category correction: %v", err)
+		}
+	}
+
+	return tx, nil
 }
 
 func (s *TransactionService) DeleteTransaction(ctx context.Context, txID string) error {
@@ -193,7 +279,7 @@ func (s *TransactionService) DeleteTransaction(ctx context.Context, txID string)
 
 	// Audit log
 	if err := s.auditRepo.Log(ctx, tx.UserID, domain.ActionDelete, "transaction", tx.ID, oldValue, nil, "user"); err != nil {
-		fmt.Printf("Failed to create audit log: %v\n", err)
+		log.Printf("Failed to create audit log: %v", err)
 	}
 
 	return nil
@@ -202,3 +288,33 @@ func (s *TransactionService) DeleteTransaction(ctx context.Context, txID string)
 func (s *TransactionService) GetTransactionsByDateRange(ctx context.Context, userID int64, start, end time.Time) ([]*domain.Transaction, error) {
 	return s.txRepo.GetByUserAndDateRange(ctx, userID, start, end)
 }
+
+// GetUserTransaction returns the user's transaction with the given TX ID,
+// or their latest transaction when txID is empty
+func (s *TransactionService) GetUserTransaction(ctx context.Context, userID int64, txID string) (*domain.Transaction, error) {
+	var tx *domain.Transaction
+	var err error
+	if txID == "" {
+		tx, err = s.txRepo.GetLastByUser(ctx, userID)
+	} else {
+		tx, err = s.txRepo.GetByTxID(ctx, txID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	// Never expose another user's transaction
+	if tx == nil || tx.UserID != userID || tx.IsDeleted {
+		return nil, fmt.Errorf("transaction not found")
+	}
+
+	return tx, nil
+}
+
+func (s *TransactionService) GetTransactionByID(ctx context.Context, id int64) (*domain.Transaction, error) {
+	return s.txRepo.GetByID(ctx, id)
+}
+
+func (s *TransactionService) GetTransactionItems(ctx context.Context, transactionID int64) ([]*domain.TransactionItem, error) {
+	return s.itemRepo.GetByTransactionID(ctx, transactionID)
+}
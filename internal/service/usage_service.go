@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nicolaananda/catatuang/internal/domain"
+	"github.com/nicolaananda/catatuang/internal/repository"
+)
+
+// Usage groupings accepted by GetAIUsage
+const (
+	UsageByDay  = "day"
+	UsageByPlan = "plan"
+	UsageByUser = "user"
+)
+
+type UsageService struct {
+	aiCallRepo *repository.AICallRepository
+}
+
+func NewUsageService(aiCallRepo *repository.AICallRepository) *UsageService {
+	return &UsageService{aiCallRepo: aiCallRepo}
+}
+
+// GetAIUsage aggregates AI calls over the last `days` days by day, plan or user
+func (s *UsageService) GetAIUsage(ctx context.Context, groupBy string, days int) ([]*domain.AIUsageSummary, error) {
+	end := time.Now()
+	start := end.AddDate(0, 0, -days)
+
+	var summaries []*domain.AIUsageSummary
+	var err error
+	switch groupBy {
+	case UsageByDay:
+		summaries, err = s.aiCallRepo.UsageByDay(ctx, start, end)
+	case UsageByPlan:
+		summaries, err = s.aiCallRepo.UsageByPlan(ctx, start, end)
+	case UsageByUser:
+		summaries, err = s.aiCallRepo.UsageByUser(ctx, start, end)
+	default:
+		return nil, fmt.Errorf("unknown usage grouping: %s", groupBy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if summaries == nil {
+		summaries = []*domain.AIUsageSummary{}
+	}
+	return summaries, nil
+}
@@ -158,3 +158,16 @@ func (s *UserService) DowngradePremium(ctx context.Context, msisdn string) error
 func (s *UserService) GetUserByMSISDN(ctx context.Context, msisdn string) (*domain.User, error) {
 	return s.userRepo.GetByMSISDN(ctx, msisdn)
 }
+
+func (s *UserService) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	return s.userRepo.GetByID(ctx, id)
+}
+
+// SetLanguage changes the language the bot replies to the user in
+func (s *UserService) SetLanguage(ctx context.Context, user *domain.User, language string) error {
+	if err := s.userRepo.UpdateLanguage(ctx, user.ID, language); err != nil {
+		return err
+	}
+	user.Language = language
+	return nil
+}
@@ -84,20 +84,55 @@ func (sm *StateMachine) SetState(ctx context.Context, userID int64, state string
 	return nil
 }
 
+// ClearState ends the user's current state. Expired confirmations are left
+// for TakeExpired, so the user still hears that the record was dropped.
 func (sm *StateMachine) ClearState(ctx context.Context, userID int64) error {
-	query := `DELETE FROM conversation_states WHERE user_id = $1`
-	_, err := sm.db.ExecContext(ctx, query, userID)
+	query := `DELETE FROM conversation_states WHERE user_id = $1 AND (expires_at > NOW() OR state <> $2)`
+	_, err := sm.db.ExecContext(ctx, query, userID, domain.StateAwaitingConfirm)
 	if err != nil {
 		return fmt.Errorf("failed to clear state: %w", err)
 	}
 	return nil
 }
 
+// CleanupExpired deletes expired states except confirmations, which
+// TakeExpired deletes once their users have been told
 func (sm *StateMachine) CleanupExpired(ctx context.Context) error {
-	query := `DELETE FROM conversation_states WHERE expires_at < NOW()`
-	_, err := sm.db.ExecContext(ctx, query)
+	query := `DELETE FROM conversation_states WHERE expires_at < NOW() AND state <> $1`
+	_, err := sm.db.ExecContext(ctx, query, domain.StateAwaitingConfirm)
 	if err != nil {
 		return fmt.Errorf("failed to cleanup expired states: %w", err)
 	}
 	return nil
 }
+
+// TakeExpired deletes expired states of the given type and returns them,
+// so callers can notify users about whatever was pending
+func (sm *StateMachine) TakeExpired(ctx context.Context, state string) ([]*domain.ConversationState, error) {
+	query := `
+		DELETE FROM conversation_states
+		WHERE state = $1 AND expires_at < NOW()
+		RETURNING id, user_id, state, context, expires_at, created_at
+	`
+
+	rows, err := sm.db.QueryContext(ctx, query, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take expired states: %w", err)
+	}
+	defer rows.Close()
+
+	var states []*domain.ConversationState
+	for rows.Next() {
+		s := &domain.ConversationState{}
+		var contextJSON sql.NullString
+		if err := rows.Scan(&s.ID, &s.UserID, &s.State, &contextJSON, &s.ExpiresAt, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan state: %w", err)
+		}
+		if contextJSON.Valid {
+			s.Context = json.RawMessage(contextJSON.String)
+		}
+		states = append(states, s)
+	}
+
+	return states, nil
+}
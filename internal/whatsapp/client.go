@@ -2,12 +2,21 @@ package whatsapp
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
+// requestTimeout bounds every call to the GOWA API
+const requestTimeout = 30 * time.Second
+
 type Client struct {
 	apiURL   string
 	apiToken string
@@ -20,7 +29,7 @@ func NewClient(apiURL, apiToken, deviceID string) *Client {
 		apiURL:   apiURL,
 		apiToken: apiToken,
 		deviceID: deviceID,
-		client:   &http.Client{},
+		client:   &http.Client{Timeout: requestTimeout},
 	}
 }
 
@@ -29,7 +38,45 @@ type SendMessageRequest struct {
 	Message string `json:"message"`
 }
 
-func (c *Client) SendMessage(to, message string) error {
+// SendMessageResponse is GOWA's reply to /send/message
+type SendMessageResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Results struct {
+		MessageID string `json:"message_id"`
+		Status    string `json:"status"`
+	} `json:"results"`
+}
+
+// SendError is a non-200 response from GOWA
+type SendError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *SendError) Error() string {
+	return fmt.Sprintf("failed to send message (status %d): %s", e.StatusCode, e.Body)
+}
+
+// IsRetryable reports whether a send failure is worth retrying: timeouts,
+// network errors, 429 and 5xx responses. Other 4xx responses are permanent.
+func IsRetryable(err error) bool {
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return sendErr.StatusCode == http.StatusTooManyRequests || sendErr.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// SendMessage sends a text message and returns the WhatsApp message ID GOWA
+// assigned to it, which later delivery receipts refer to
+func (c *Client) SendMessage(ctx context.Context, to, message string) (string, error) {
 	// GOWA expects phone number without @ suffix
 	// Remove @s.whatsapp.net if present
 	phone := to
@@ -47,53 +94,61 @@ func (c *Client) SendMessage(to, message string) error {
 
 	body, err := json.Marshal(req)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// GOWA endpoint is /send/message with device_id query parameter
 	url := fmt.Sprintf("%s/send/message?device_id=%s", c.apiURL, c.deviceID)
-	httpReq, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	// GOWA uses Basic Authentication
-	// apiToken should be in format "username:password"
-	if c.apiToken != "" {
-		// If apiToken contains ":", split it as username:password
-		// Otherwise use it as both username and password
-		if bytes.Contains([]byte(c.apiToken), []byte(":")) {
-			parts := bytes.SplitN([]byte(c.apiToken), []byte(":"), 2)
-			httpReq.SetBasicAuth(string(parts[0]), string(parts[1]))
-		} else {
-			// Use same value for both username and password
-			httpReq.SetBasicAuth(c.apiToken, c.apiToken)
-		}
-	}
+	c.setAuth(httpReq)
 
 	resp, err := c.client.Do(httpReq)
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return "", fmt.Errorf("failed to send message: %w", err)
 	}
 	defer resp.Body.Close()
 
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to send message (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return "", &SendError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
-	return nil
+	// The message was accepted even if the response can't be parsed; only
+	// receipt tracking is lost
+	var sendResp SendMessageResponse
+	if err := json.Unmarshal(bodyBytes, &sendResp); err != nil {
+		return "", nil
+	}
+
+	return sendResp.Results.MessageID, nil
 }
 
-func (c *Client) DownloadMedia(mediaURL string) ([]byte, error) {
-	req, err := http.NewRequest("GET", mediaURL, nil)
+// DownloadMedia fetches a media file from GOWA, refusing files larger than maxBytes.
+// location may be an absolute URL on the GOWA host or a path relative to the
+// GOWA API URL.
+func (c *Client) DownloadMedia(ctx context.Context, location string, maxBytes int64) ([]byte, error) {
+	mediaURL, err := c.mediaURL(location)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", mediaURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// GOWA doesn't require auth for media download
+	// Media served by GOWA itself sits behind the same auth as the API
+	c.setAuth(req)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -105,10 +160,64 @@ func (c *Client) DownloadMedia(mediaURL string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to download media: status %d", resp.StatusCode)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	if resp.ContentLength > maxBytes {
+		return nil, fmt.Errorf("media too large: %d bytes", resp.ContentLength)
+	}
+
+	// Read one byte past the limit to detect oversized bodies without a Content-Length
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read media: %w", err)
 	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("media too large: more than %d bytes", maxBytes)
+	}
 
 	return data, nil
 }
+
+// mediaURL resolves a media location from a webhook payload against the API
+// URL. Absolute URLs must point at the GOWA host itself: the payload is
+// untrusted, and the request carries the GOWA credentials.
+func (c *Client) mediaURL(location string) (string, error) {
+	base, err := url.Parse(c.apiURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid GOWA API URL: %w", err)
+	}
+
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid media URL: %w", err)
+	}
+
+	if !ref.IsAbs() && ref.Host == "" {
+		// Relative paths are appended to the API URL, which may itself have a path
+		resolved := *base
+		resolved.Path = strings.TrimRight(base.Path, "/") + "/" + strings.TrimLeft(ref.Path, "/")
+		resolved.RawPath = ""
+		resolved.RawQuery = ref.RawQuery
+		resolved.Fragment = ""
+		return resolved.String(), nil
+	}
+
+	if !strings.EqualFold(ref.Scheme, base.Scheme) || !strings.EqualFold(ref.Host, base.Host) {
+		return "", fmt.Errorf("refusing media URL outside the GOWA host: %s", ref.Redacted())
+	}
+	return ref.String(), nil
+}
+
+// setAuth applies GOWA Basic Authentication.
+// apiToken should be in format "username:password"
+func (c *Client) setAuth(req *http.Request) {
+	if c.apiToken == "" {
+		return
+	}
+
+	// If apiToken contains ":", split it as username:password
+	// Otherwise use it as both username and password
+	if username, password, ok := strings.Cut(c.apiToken, ":"); ok {
+		req.SetBasicAuth(username, password)
+	} else {
+		req.SetBasicAuth(c.apiToken, c.apiToken)
+	}
+}
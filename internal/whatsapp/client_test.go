@@ -0,0 +1,118 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMediaURL(t *testing.T) {
+	c := NewClient("https://gowa.example.com", "user:pass", "")
+
+	tests := []struct {
+		location string
+		want     string
+		wantErr  bool
+	}{
+		{location: "statics/media/a.jpg", want: "https://gowa.example.com/statics/media/a.jpg"},
+		{location: "/statics/media/a.jpg", want: "https://gowa.example.com/statics/media/a.jpg"},
+		{location: "https://gowa.example.com/statics/media/a.jpg", want: "https://gowa.example.com/statics/media/a.jpg"},
+		{location: "https://GOWA.example.com/a.jpg", want: "https://GOWA.example.com/a.jpg"},
+		{location: "https://gowa.example.com.evil.net/a.jpg", wantErr: true},
+		{location: "https://evil.net/a.jpg", wantErr: true},
+		{location: "http://gowa.example.com/a.jpg", wantErr: true},
+		{location: "https://gowa.example.com:8443/a.jpg", wantErr: true},
+		{location: "//evil.net/a.jpg", wantErr: true},
+		{location: "http://169.254.169.254/latest/meta-data", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := c.mediaURL(tt.location)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("mediaURL(%q) = %q, want error", tt.location, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("mediaURL(%q) error: %v", tt.location, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("mediaURL(%q) = %q, want %q", tt.location, got, tt.want)
+		}
+	}
+}
+
+func TestMediaURLWithBasePath(t *testing.T) {
+	c := NewClient("https://example.com/gowa/", "", "")
+
+	got, err := c.mediaURL("statics/media/a.jpg")
+	if err != nil {
+		t.Fatalf("mediaURL error: %v", err)
+	}
+	if want := "https://example.com/gowa/statics/media/a.jpg"; got != want {
+		t.Errorf("mediaURL = %q, want %q", got, want)
+	}
+}
+
+func TestDownloadMedia(t *testing.T) {
+	var gotUser, gotPass string
+	gowa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, _ = r.BasicAuth()
+		w.Write([]byte("image"))
+	}))
+	defer gowa.Close()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request to a foreign host: %s", r.URL)
+	}))
+	defer other.Close()
+
+	c := NewClient(gowa.URL, "user:pass", "")
+	ctx := context.Background()
+
+	data, err := c.DownloadMedia(ctx, "statics/media/a.jpg", 1024)
+	if err != nil {
+		t.Fatalf("DownloadMedia error: %v", err)
+	}
+	if string(data) != "image" {
+		t.Errorf("DownloadMedia = %q, want %q", data, "image")
+	}
+	if gotUser != "user" || gotPass != "pass" {
+		t.Errorf("basic auth = %q:%q, want user:pass", gotUser, gotPass)
+	}
+
+	if _, err := c.DownloadMedia(ctx, other.URL+"/a.jpg", 1024); err == nil {
+		t.Error("DownloadMedia from a foreign host succeeded, want error")
+	}
+
+	if _, err := c.DownloadMedia(ctx, "statics/media/a.jpg", 3); err == nil {
+		t.Error("DownloadMedia over maxBytes succeeded, want error")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&SendError{StatusCode: http.StatusTooManyRequests}, true},
+		{&SendError{StatusCode: http.StatusBadGateway}, true},
+		{&SendError{StatusCode: http.StatusBadRequest}, false},
+		{&SendError{StatusCode: http.StatusUnauthorized}, false},
+		{fmt.Errorf("send: %w", context.DeadlineExceeded), true},
+		{io.ErrUnexpectedEOF, true},
+		{errors.New("failed to marshal request"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package whatsapp
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 // IncomingMessage represents a webhook message from GOWA
 type IncomingMessage struct {
@@ -10,6 +14,11 @@ type IncomingMessage struct {
 	Pushname  string      `json:"pushname"`
 	SenderID  string      `json:"sender_id"`
 	Timestamp string      `json:"timestamp"`
+
+	// Media attachments, sent by GOWA alongside the message object
+	Image    *MediaData `json:"image,omitempty"`
+	Document *MediaData `json:"document,omitempty"`
+	Audio    *MediaData `json:"audio,omitempty"`
 }
 
 // MessageData represents the nested message object
@@ -20,6 +29,26 @@ type MessageData struct {
 	QuotedMessage string `json:"quoted_message,omitempty"`
 }
 
+// MediaData represents an image or document attachment in a GOWA webhook.
+// GOWA stores the file on its side and sends a path relative to its API URL;
+// some versions send an absolute URL instead.
+type MediaData struct {
+	MediaPath string `json:"media_path,omitempty"`
+	URL       string `json:"url,omitempty"`
+	MimeType  string `json:"mime_type,omitempty"`
+	Caption   string `json:"caption,omitempty"`
+	FileName  string `json:"file_name,omitempty"`
+	FileSize  int64  `json:"file_size,omitempty"`
+}
+
+// Location returns the URL or GOWA-relative path of the media file
+func (m *MediaData) Location() string {
+	if m.URL != "" {
+		return m.URL
+	}
+	return m.MediaPath
+}
+
 // GetMessageID returns the message ID
 func (m *IncomingMessage) GetMessageID() string {
 	return m.Message.ID
@@ -74,6 +103,26 @@ func (m *IncomingMessage) GetFrom() string {
 	return phone + "@s.whatsapp.net"
 }
 
+// JID returns the chat ID of a phone number or JID, so a recipient has one
+// form however it was given: "6281234567890" and
+// "6281234567890:40@s.whatsapp.net" are both "6281234567890@s.whatsapp.net"
+func JID(to string) string {
+	if to == "" {
+		return ""
+	}
+
+	phone, server, ok := strings.Cut(to, "@")
+	// Remove device part if present
+	if colonIdx := strings.Index(phone, ":"); colonIdx > 0 {
+		phone = phone[:colonIdx]
+	}
+	// LIDs are sent to like phone numbers, as in GetFrom
+	if !ok || server == "lid" {
+		server = "s.whatsapp.net"
+	}
+	return phone + "@" + server
+}
+
 // GetMSISDN returns just the phone number without WhatsApp suffix (for database storage)
 func (m *IncomingMessage) GetMSISDN() string {
 	// Get the full JID first
@@ -87,18 +136,91 @@ func (m *IncomingMessage) GetMSISDN() string {
 	return fullJID
 }
 
+// GetTimestamp returns when the message was sent, or zero if GOWA's
+// timestamp can't be parsed
+func (m *IncomingMessage) GetTimestamp() time.Time {
+	if t, err := time.Parse(time.RFC3339, m.Timestamp); err == nil {
+		return t
+	}
+	if secs, err := strconv.ParseInt(m.Timestamp, 10, 64); err == nil {
+		return time.Unix(secs, 0)
+	}
+	return time.Time{}
+}
+
 // GetText returns the message text
 func (m *IncomingMessage) GetText() string {
 	return m.Message.Text
 }
 
-// IsImage checks if message contains an image
+// IsImage checks if message contains an image, either as a photo or
+// as an image file sent as document
 func (m *IncomingMessage) IsImage() bool {
-	// GOWA sends media in different format, will handle later
-	return false
+	if m.Image != nil && m.Image.Location() != "" {
+		return true
+	}
+	return m.Document != nil && m.Document.Location() != "" && strings.HasPrefix(m.Document.MimeType, "image/")
+}
+
+// IsAudio checks if message is a voice note or audio file
+func (m *IncomingMessage) IsAudio() bool {
+	return m.Audio != nil && m.Audio.Location() != ""
+}
+
+// GetMedia returns the attached image or document, if any
+func (m *IncomingMessage) GetMedia() *MediaData {
+	if m.Image != nil && m.Image.Location() != "" {
+		return m.Image
+	}
+	if m.Document != nil && m.Document.Location() != "" {
+		return m.Document
+	}
+	return nil
+}
+
+// GetCaption returns the caption of the attached media
+func (m *IncomingMessage) GetCaption() string {
+	if media := m.GetMedia(); media != nil {
+		return media.Caption
+	}
+	return ""
 }
 
 // IsText checks if message is text
 func (m *IncomingMessage) IsText() bool {
 	return m.Message.Text != ""
 }
+
+// ReceiptEvent is a GOWA "message.ack" webhook, sent when messages we sent
+// are delivered to or read by the recipient
+type ReceiptEvent struct {
+	Event   string         `json:"event"`
+	Payload ReceiptPayload `json:"payload"`
+}
+
+// ReceiptPayload lists the acknowledged message IDs
+type ReceiptPayload struct {
+	ChatID      string   `json:"chat_id"`
+	From        string   `json:"from"`
+	IDs         []string `json:"ids"`
+	ReceiptType string   `json:"receipt_type"`
+}
+
+// IsReceipt checks if the webhook is a delivery or read receipt
+func (e *ReceiptEvent) IsReceipt() bool {
+	return e.Event == "message.ack" && len(e.Payload.IDs) > 0
+}
+
+// IsRead checks if the receipt means the recipient read (or played) the message
+func (e *ReceiptEvent) IsRead() bool {
+	switch e.Payload.ReceiptType {
+	case "read", "read-self", "played":
+		return true
+	}
+	return false
+}
+
+// IsDelivered checks if the receipt means the message reached the recipient's device
+func (e *ReceiptEvent) IsDelivered() bool {
+	return e.Payload.ReceiptType == "delivered" || e.Payload.ReceiptType == ""
+}
@@ -0,0 +1,23 @@
+package whatsapp
+
+import "testing"
+
+func TestJID(t *testing.T) {
+	tests := []struct {
+		to   string
+		want string
+	}{
+		{"6281234567890", "6281234567890@s.whatsapp.net"},
+		{"6281234567890@s.whatsapp.net", "6281234567890@s.whatsapp.net"},
+		{"6281234567890:40@s.whatsapp.net", "6281234567890@s.whatsapp.net"},
+		{"88270922903758@lid", "88270922903758@s.whatsapp.net"},
+		{"120363025246125888@g.us", "120363025246125888@g.us"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := JID(tt.to); got != tt.want {
+			t.Errorf("JID(%q) = %q, want %q", tt.to, got, tt.want)
+		}
+	}
+}
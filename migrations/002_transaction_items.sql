@@ -0,0 +1,19 @@
+-- Migration: Line items for itemised receipts
+-- Version: 002
+-- Created: 2026-10-17
+
+-- Transaction items table
+CREATE TABLE IF NOT EXISTS transaction_items (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    quantity DECIMAL(10,3) NOT NULL DEFAULT 1,
+    unit_price DECIMAL(15,2) NOT NULL DEFAULT 0,
+    subtotal DECIMAL(15,2) NOT NULL DEFAULT 0,
+    discount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    tax DECIMAL(15,2) NOT NULL DEFAULT 0,
+    category VARCHAR(100),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tx_items_tx ON transaction_items(transaction_id);
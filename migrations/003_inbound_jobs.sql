@@ -0,0 +1,24 @@
+-- Migration: Durable inbound message queue
+-- Version: 003
+-- Created: 2026-10-17
+
+-- Inbound jobs table
+CREATE TABLE IF NOT EXISTS inbound_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    wa_message_id VARCHAR(100) UNIQUE NOT NULL,
+    msisdn VARCHAR(20),
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING', -- PENDING, PROCESSING, DONE, DEAD
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inbound_status_run ON inbound_jobs(status, run_at);
+CREATE INDEX IF NOT EXISTS idx_inbound_locked ON inbound_jobs(locked_at) WHERE status = 'PROCESSING';
+
+CREATE TRIGGER update_inbound_jobs_updated_at BEFORE UPDATE ON inbound_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
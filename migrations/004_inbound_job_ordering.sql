@@ -0,0 +1,8 @@
+-- Migration: Per-sender ordering of inbound jobs
+-- Version: 004
+-- Created: 2026-10-17
+
+-- WhatsApp send time, used to process one sender's messages in order
+ALTER TABLE inbound_jobs ADD COLUMN IF NOT EXISTS message_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_inbound_msisdn_status ON inbound_jobs(msisdn, status, message_at);
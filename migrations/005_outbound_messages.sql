@@ -0,0 +1,29 @@
+-- Migration: Outbound message queue with delivery tracking
+-- Version: 005
+-- Created: 2026-10-17
+
+-- Outbound messages table
+CREATE TABLE IF NOT EXISTS outbound_messages (
+    id BIGSERIAL PRIMARY KEY,
+    recipient VARCHAR(50) NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'QUEUED', -- QUEUED, SENDING, SENT, DELIVERED, READ, FAILED
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    gowa_message_id VARCHAR(100),
+    run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_at TIMESTAMP,
+    sent_at TIMESTAMP,
+    delivered_at TIMESTAMP,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbound_status_run ON outbound_messages(status, run_at);
+CREATE INDEX IF NOT EXISTS idx_outbound_recipient ON outbound_messages(recipient, status, id);
+CREATE INDEX IF NOT EXISTS idx_outbound_recipient_sent ON outbound_messages(recipient, sent_at);
+CREATE INDEX IF NOT EXISTS idx_outbound_gowa_id ON outbound_messages(gowa_message_id);
+
+CREATE TRIGGER update_outbound_messages_updated_at BEFORE UPDATE ON outbound_messages
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
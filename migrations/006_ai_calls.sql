@@ -0,0 +1,24 @@
+-- Migration: AI usage, cost and latency accounting
+-- Version: 006
+-- Created: 2026-10-17
+
+-- AI calls table
+CREATE TABLE IF NOT EXISTS ai_calls (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    wa_message_id VARCHAR(100),
+    call_type VARCHAR(20) NOT NULL, -- text, vision
+    model VARCHAR(100) NOT NULL,
+    prompt_tokens INT NOT NULL DEFAULT 0,
+    completion_tokens INT NOT NULL DEFAULT 0,
+    cost_usd DECIMAL(12,6) NOT NULL DEFAULT 0,
+    latency_ms INT NOT NULL DEFAULT 0,
+    retry_count INT NOT NULL DEFAULT 0,
+    outcome VARCHAR(20) NOT NULL, -- success, api_error, timeout, invalid_response
+    error TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_calls_created ON ai_calls(created_at);
+CREATE INDEX IF NOT EXISTS idx_ai_calls_user ON ai_calls(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_ai_calls_message ON ai_calls(wa_message_id);
@@ -0,0 +1,119 @@
+-- Migration: Canonical category taxonomy with per-user categories
+-- Version: 007
+-- Created: 2026-10-17
+
+-- Categories table. Rows without user_id are the default taxonomy; a user's
+-- first change copies it into rows they own.
+CREATE TABLE IF NOT EXISTS categories (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    parent_id BIGINT REFERENCES categories(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('INCOME', 'EXPENSE')),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_user_name ON categories(COALESCE(user_id, 0), LOWER(name));
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id);
+
+CREATE TRIGGER update_categories_updated_at BEFORE UPDATE ON categories
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Lowercase words that map to a category, including its own name
+CREATE TABLE IF NOT EXISTS category_synonyms (
+    id BIGSERIAL PRIMARY KEY,
+    category_id BIGINT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    synonym VARCHAR(100) NOT NULL,
+    UNIQUE (category_id, synonym)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_synonyms_synonym ON category_synonyms(synonym);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS category_id BIGINT REFERENCES categories(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_category ON transactions(category_id);
+
+-- Default taxonomy: top-level categories
+INSERT INTO categories (name, type) VALUES
+    ('Makanan & Minuman', 'EXPENSE'),
+    ('Transportasi', 'EXPENSE'),
+    ('Belanja', 'EXPENSE'),
+    ('Tagihan', 'EXPENSE'),
+    ('Tempat Tinggal', 'EXPENSE'),
+    ('Kesehatan', 'EXPENSE'),
+    ('Hiburan', 'EXPENSE'),
+    ('Pendidikan', 'EXPENSE'),
+    ('Donasi', 'EXPENSE'),
+    ('Lainnya', 'EXPENSE'),
+    ('Gaji', 'INCOME'),
+    ('Bonus', 'INCOME'),
+    ('Penjualan', 'INCOME'),
+    ('Transfer Masuk', 'INCOME'),
+    ('Pemasukan Lainnya', 'INCOME');
+
+-- Default taxonomy: subcategories
+INSERT INTO categories (name, type, parent_id)
+SELECT v.name, p.type, p.id
+FROM (VALUES
+    ('Kopi & Jajan', 'Makanan & Minuman'),
+    ('Bahan Makanan', 'Makanan & Minuman'),
+    ('Bensin', 'Transportasi'),
+    ('Ojek & Taksi', 'Transportasi'),
+    ('Parkir & Tol', 'Transportasi'),
+    ('Rumah Tangga', 'Belanja'),
+    ('Pakaian', 'Belanja'),
+    ('Listrik & Air', 'Tagihan'),
+    ('Internet & Pulsa', 'Tagihan')
+) AS v(name, parent)
+JOIN categories p ON p.name = v.parent AND p.user_id IS NULL;
+
+-- Every category matches its own name
+INSERT INTO category_synonyms (category_id, synonym)
+SELECT id, LOWER(name) FROM categories WHERE user_id IS NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO category_synonyms (category_id, synonym)
+SELECT c.id, v.synonym
+FROM (VALUES
+    ('Makanan & Minuman', 'makan'), ('Makanan & Minuman', 'makanan'), ('Makanan & Minuman', 'minuman'),
+    ('Makanan & Minuman', 'minum'), ('Makanan & Minuman', 'food'), ('Makanan & Minuman', 'sarapan'),
+    ('Makanan & Minuman', 'makan siang'), ('Makanan & Minuman', 'makan malam'), ('Makanan & Minuman', 'restoran'),
+    ('Makanan & Minuman', 'makanan & minuman'),
+    ('Kopi & Jajan', 'kopi'), ('Kopi & Jajan', 'ngopi'), ('Kopi & Jajan', 'jajan'), ('Kopi & Jajan', 'snack'),
+    ('Bahan Makanan', 'bahan makanan'), ('Bahan Makanan', 'sembako'), ('Bahan Makanan', 'groceries'), ('Bahan Makanan', 'sayur'),
+    ('Transportasi', 'transport'), ('Transportasi', 'transportation'), ('Transportasi', 'kereta'), ('Transportasi', 'krl'),
+    ('Bensin', 'bbm'), ('Bensin', 'pertalite'), ('Bensin', 'pertamax'), ('Bensin', 'fuel'),
+    ('Ojek & Taksi', 'ojek'), ('Ojek & Taksi', 'ojol'), ('Ojek & Taksi', 'gojek'), ('Ojek & Taksi', 'grab'), ('Ojek & Taksi', 'taksi'),
+    ('Parkir & Tol', 'parkir'), ('Parkir & Tol', 'tol'),
+    ('Belanja', 'shopping'), ('Belanja', 'belanja bulanan'),
+    ('Rumah Tangga', 'rumah tangga'), ('Rumah Tangga', 'perabot'),
+    ('Pakaian', 'baju'), ('Pakaian', 'sepatu'), ('Pakaian', 'fashion'),
+    ('Tagihan', 'bills'), ('Tagihan', 'cicilan'),
+    ('Listrik & Air', 'listrik'), ('Listrik & Air', 'pln'), ('Listrik & Air', 'air'), ('Listrik & Air', 'pdam'), ('Listrik & Air', 'token'),
+    ('Internet & Pulsa', 'internet'), ('Internet & Pulsa', 'wifi'), ('Internet & Pulsa', 'pulsa'), ('Internet & Pulsa', 'kuota'),
+    ('Tempat Tinggal', 'kos'), ('Tempat Tinggal', 'kost'), ('Tempat Tinggal', 'kontrakan'), ('Tempat Tinggal', 'sewa'), ('Tempat Tinggal', 'rent'),
+    ('Kesehatan', 'obat'), ('Kesehatan', 'dokter'), ('Kesehatan', 'apotek'), ('Kesehatan', 'health'), ('Kesehatan', 'perawatan diri'),
+    ('Hiburan', 'nonton'), ('Hiburan', 'bioskop'), ('Hiburan', 'netflix'), ('Hiburan', 'spotify'), ('Hiburan', 'entertainment'), ('Hiburan', 'langganan'),
+    ('Pendidikan', 'sekolah'), ('Pendidikan', 'kursus'), ('Pendidikan', 'buku'), ('Pendidikan', 'education'),
+    ('Donasi', 'sedekah'), ('Donasi', 'zakat'), ('Donasi', 'infaq'), ('Donasi', 'amal'),
+    ('Lainnya', 'lain-lain'), ('Lainnya', 'other'), ('Lainnya', 'others'),
+    ('Gaji', 'salary'), ('Gaji', 'thr'), ('Gaji', 'upah'),
+    ('Bonus', 'komisi'), ('Bonus', 'insentif'),
+    ('Penjualan', 'jual'), ('Penjualan', 'jualan'), ('Penjualan', 'sales'),
+    ('Transfer Masuk', 'transfer'), ('Transfer Masuk', 'transferan'),
+    ('Pemasukan Lainnya', 'lainnya'), ('Pemasukan Lainnya', 'lain-lain'), ('Pemasukan Lainnya', 'other')
+) AS v(name, synonym)
+JOIN categories c ON c.name = v.name AND c.user_id IS NULL
+ON CONFLICT DO NOTHING;
+
+-- Link existing transactions to the default taxonomy and use canonical names
+UPDATE transactions t
+SET category_id = m.id, category = m.name
+FROM (
+    SELECT DISTINCT ON (s.synonym, c.type) s.synonym, c.id, c.name, c.type
+    FROM category_synonyms s
+    JOIN categories c ON c.id = s.category_id
+    WHERE c.user_id IS NULL
+    ORDER BY s.synonym, c.type, c.id
+) m
+WHERE t.category_id IS NULL AND LOWER(TRIM(t.category)) = m.synonym AND t.type = m.type;
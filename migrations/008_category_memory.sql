@@ -0,0 +1,20 @@
+-- Migration: Per-user category memory learned from corrections
+-- Version: 008
+-- Created: 2026-10-17
+
+-- One row per user and keyword (normalised merchant/description words).
+-- hits counts how often the user confirmed the same category.
+CREATE TABLE IF NOT EXISTS category_memory (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    keyword VARCHAR(100) NOT NULL,
+    category VARCHAR(100) NOT NULL,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('INCOME', 'EXPENSE')),
+    hits INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (user_id, keyword)
+);
+
+CREATE TRIGGER update_category_memory_updated_at BEFORE UPDATE ON category_memory
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
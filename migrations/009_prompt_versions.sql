@@ -0,0 +1,28 @@
+-- Migration: Versioned prompts and shadow runs of candidate prompts
+-- Version: 009
+-- Created: 2026-10-17
+
+-- ai_version now holds model and prompt version, e.g. "gpt-4o-mini+text@3f2a9c1b"
+ALTER TABLE transactions ALTER COLUMN ai_version TYPE VARCHAR(150);
+
+ALTER TABLE ai_calls ADD COLUMN IF NOT EXISTS prompt_version VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_ai_calls_prompt ON ai_calls(prompt_version, created_at);
+
+-- Prompt shadow runs table: a candidate prompt run on the same input as the
+-- live one, with both outputs kept for comparison
+CREATE TABLE IF NOT EXISTS prompt_shadow_runs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    wa_message_id VARCHAR(100),
+    call_type VARCHAR(20) NOT NULL, -- text, vision
+    primary_version VARCHAR(150) NOT NULL,
+    candidate_version VARCHAR(150) NOT NULL,
+    primary_output JSONB,
+    candidate_output JSONB,
+    candidate_error TEXT,
+    agrees BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_shadow_candidate ON prompt_shadow_runs(candidate_version, created_at);
@@ -0,0 +1,29 @@
+-- Migration: Messages deferred while the AI provider is unavailable
+-- Version: 010
+-- Created: 2026-10-17
+
+-- Deferred messages table: raw text or receipt images kept for parsing once
+-- the AI circuit breaker closes again
+CREATE TABLE IF NOT EXISTS deferred_messages (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    wa_message_id VARCHAR(100) UNIQUE NOT NULL,
+    msisdn VARCHAR(20) NOT NULL,
+    kind VARCHAR(10) NOT NULL, -- text, image
+    text TEXT NOT NULL DEFAULT '', -- message, transcript or image caption
+    image BYTEA,
+    mime_type VARCHAR(50),
+    sent_at TIMESTAMP NOT NULL, -- UTC; relative dates ("kemarin") resolve against this
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING', -- PENDING, PROCESSING, DONE, DEAD
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_deferred_status_run ON deferred_messages(status, run_at);
+
+CREATE TRIGGER update_deferred_messages_updated_at BEFORE UPDATE ON deferred_messages
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,31 @@
+-- Migration: Per-user reply language
+-- Version: 011
+-- Created: 2026-10-17
+
+-- Language the bot replies in: id (Indonesian), en (English) or jv (Javanese).
+-- Detected from a new user's first message, changed with "bahasa ..."
+ALTER TABLE users ADD COLUMN IF NOT EXISTS language VARCHAR(5) NOT NULL DEFAULT 'id';
+
+-- English and Javanese synonyms for the default categories, so "lunch 45k"
+-- and "kategori mangan" land in the same place as "makan 45rb"
+INSERT INTO category_synonyms (category_id, synonym)
+SELECT c.id, v.synonym
+FROM (VALUES
+    ('Makanan & Minuman', 'lunch'), ('Makanan & Minuman', 'dinner'), ('Makanan & Minuman', 'breakfast'),
+    ('Makanan & Minuman', 'meal'), ('Makanan & Minuman', 'drinks'), ('Makanan & Minuman', 'mangan'),
+    ('Makanan & Minuman', 'ngombe'),
+    ('Kopi & Jajan', 'coffee'), ('Kopi & Jajan', 'snacks'),
+    ('Bahan Makanan', 'grocery'), ('Bahan Makanan', 'blanja'),
+    ('Bensin', 'petrol'), ('Bensin', 'gas'),
+    ('Ojek & Taksi', 'taxi'),
+    ('Parkir & Tol', 'parking'), ('Parkir & Tol', 'toll'),
+    ('Listrik & Air', 'electricity'), ('Listrik & Air', 'water'),
+    ('Internet & Pulsa', 'phone credit'),
+    ('Kesehatan', 'medicine'), ('Kesehatan', 'doctor'), ('Kesehatan', 'pharmacy'),
+    ('Hiburan', 'movie'), ('Hiburan', 'cinema'),
+    ('Pendidikan', 'school'), ('Pendidikan', 'course'), ('Pendidikan', 'books'),
+    ('Donasi', 'donation'), ('Donasi', 'charity'),
+    ('Gaji', 'gajian'), ('Gaji', 'wages')
+) AS v(name, synonym)
+JOIN categories c ON c.name = v.name AND c.user_id IS NULL
+ON CONFLICT DO NOTHING;
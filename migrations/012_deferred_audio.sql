@@ -0,0 +1,7 @@
+-- Migration: Defer voice notes while the AI provider is unavailable
+-- Version: 012
+-- Created: 2026-10-17
+
+-- Deferred messages can now be voice notes (kind audio), transcribed once the
+-- AI circuit breaker closes again, so the stored media is no longer only an image
+ALTER TABLE deferred_messages RENAME COLUMN image TO media;